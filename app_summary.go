@@ -0,0 +1,25 @@
+package tps
+
+// overall health of an app as reported in AppSummary
+const (
+	AppHealthy  = "healthy"
+	AppDegraded = "degraded"
+	AppDown     = "down"
+	AppStopped  = "stopped"
+)
+
+// instance counts of a process, for callers that do not need every instance.
+// Ages are in seconds since the instance's pod started.
+type AppSummary struct {
+	ProcessGuid       string `json:"process_guid"`
+	Desired           int    `json:"desired"`
+	Running           int    `json:"running"`
+	Starting          int    `json:"starting"`
+	Crashed           int    `json:"crashed"`
+	Down              int    `json:"down"`
+	Unknown           int    `json:"unknown"`
+	TotalRestarts     int32  `json:"total_restarts"`
+	OldestInstanceAge int64  `json:"oldest_instance_age"`
+	NewestInstanceAge int64  `json:"newest_instance_age"`
+	Health            string `json:"health"`
+}
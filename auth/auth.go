@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// the token was missing, invalid or expired
+	ErrUnauthorized = errors.New("invalid authorization token")
+	// the token is valid but may not act on what was asked for
+	ErrForbidden = errors.New("authorization token is not allowed to do this")
+)
+
+const (
+	// only users who may change an app, space developers and admins, can
+	// read its environment
+	appEnvPath = "/v2/apps/%s/env"
+	// only members of a space, and admins, can see it
+	spacePath = "/v2/spaces/%s"
+	// only admins can list every user
+	usersPath = "/v2/users?results-per-page=1"
+)
+
+//go:generate counterfeiter -o fakes/fake_authorizer.go . Authorizer
+
+// Authorizer checks the Authorization header of a request before the API
+// acts on it. It returns ErrUnauthorized or ErrForbidden when the request
+// may not go ahead, and any other error when it could not tell.
+type Authorizer interface {
+	// whether authorization may change the app, such as restart its
+	// instances
+	AuthorizeApp(authorization, appGuid string) error
+	// whether authorization may see the space and what runs in it
+	AuthorizeSpace(authorization, spaceGuid string) error
+	// whether authorization is an admin's, who may see every app
+	AuthorizeAdmin(authorization string) error
+}
+
+type BadResponseError struct {
+	StatusCode int
+}
+
+func (b *BadResponseError) Error() string {
+	return fmt.Sprintf("authorization check failed with %d", b.StatusCode)
+}
+
+type ccAuthorizer struct {
+	ccAPIURL   string
+	httpClient *http.Client
+}
+
+// NewCCAuthorizer authorizes requests by making the same request to the
+// public CC API with their token, as the traffic controller does for logs.
+// Checks taking longer than timeout fail, or never when it is zero.
+func NewCCAuthorizer(ccAPIURL string, skipCertVerify bool, timeout time.Duration) Authorizer {
+	return &ccAuthorizer{
+		ccAPIURL: strings.TrimRight(ccAPIURL, "/"),
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				Dial: (&net.Dialer{
+					Timeout:   10 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).Dial,
+				TLSHandshakeTimeout: 10 * time.Second,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: skipCertVerify,
+					MinVersion:         tls.VersionTLS10,
+				},
+			},
+		},
+	}
+}
+
+func (a *ccAuthorizer) AuthorizeApp(authorization, appGuid string) error {
+	return a.check(authorization, fmt.Sprintf(appEnvPath, url.QueryEscape(appGuid)))
+}
+
+func (a *ccAuthorizer) AuthorizeSpace(authorization, spaceGuid string) error {
+	return a.check(authorization, fmt.Sprintf(spacePath, url.QueryEscape(spaceGuid)))
+}
+
+func (a *ccAuthorizer) AuthorizeAdmin(authorization string) error {
+	return a.check(authorization, usersPath)
+}
+
+func (a *ccAuthorizer) check(authorization, path string) error {
+	if authorization == "" {
+		return ErrUnauthorized
+	}
+
+	request, err := http.NewRequest("GET", a.ccAPIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", authorization)
+
+	response, err := a.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden, http.StatusNotFound:
+		// the CC hides apps the token cannot see
+		return ErrForbidden
+	default:
+		return &BadResponseError{response.StatusCode}
+	}
+}
+
+type denyingAuthorizer struct{}
+
+// NewDenyingAuthorizer forbids every request, for when there is nothing to
+// check tokens against
+func NewDenyingAuthorizer() Authorizer {
+	return denyingAuthorizer{}
+}
+
+func (denyingAuthorizer) AuthorizeApp(authorization, appGuid string) error {
+	return ErrForbidden
+}
+
+func (denyingAuthorizer) AuthorizeSpace(authorization, spaceGuid string) error {
+	return ErrForbidden
+}
+
+func (denyingAuthorizer) AuthorizeAdmin(authorization string) error {
+	return ErrForbidden
+}
@@ -0,0 +1,110 @@
+package auth_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/auth"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("CC Authorizer", func() {
+	var (
+		fakeCC     *ghttp.Server
+		authorizer auth.Authorizer
+	)
+
+	BeforeEach(func() {
+		fakeCC = ghttp.NewServer()
+		authorizer = auth.NewCCAuthorizer(fakeCC.URL(), true, time.Second)
+	})
+
+	AfterEach(func() {
+		fakeCC.Close()
+	})
+
+	Describe("AuthorizeApp", func() {
+		respondWith := func(statusCode int) {
+			fakeCC.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/apps/app-guid/env"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer token"),
+				ghttp.RespondWith(statusCode, "{}"),
+			))
+		}
+
+		It("allows tokens the CC lets read the app's environment", func() {
+			respondWith(http.StatusOK)
+			Expect(authorizer.AuthorizeApp("bearer token", "app-guid")).To(Succeed())
+		})
+
+		It("rejects tokens the CC does not accept", func() {
+			respondWith(http.StatusUnauthorized)
+			Expect(authorizer.AuthorizeApp("bearer token", "app-guid")).To(Equal(auth.ErrUnauthorized))
+		})
+
+		It("forbids tokens that cannot change the app", func() {
+			respondWith(http.StatusForbidden)
+			Expect(authorizer.AuthorizeApp("bearer token", "app-guid")).To(Equal(auth.ErrForbidden))
+		})
+
+		It("forbids tokens that cannot see the app", func() {
+			respondWith(http.StatusNotFound)
+			Expect(authorizer.AuthorizeApp("bearer token", "app-guid")).To(Equal(auth.ErrForbidden))
+		})
+
+		It("fails when the CC fails", func() {
+			respondWith(http.StatusInternalServerError)
+			Expect(authorizer.AuthorizeApp("bearer token", "app-guid")).To(Equal(&auth.BadResponseError{StatusCode: http.StatusInternalServerError}))
+		})
+
+		It("rejects a missing token without asking the CC", func() {
+			Expect(authorizer.AuthorizeApp("", "app-guid")).To(Equal(auth.ErrUnauthorized))
+			Expect(fakeCC.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("AuthorizeSpace", func() {
+		It("allows tokens the CC lets see the space", func() {
+			fakeCC.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/spaces/space-guid"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer token"),
+				ghttp.RespondWith(http.StatusOK, "{}"),
+			))
+
+			Expect(authorizer.AuthorizeSpace("bearer token", "space-guid")).To(Succeed())
+		})
+
+		It("forbids tokens that cannot see the space", func() {
+			fakeCC.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, "{}"))
+			Expect(authorizer.AuthorizeSpace("bearer token", "space-guid")).To(Equal(auth.ErrForbidden))
+		})
+	})
+
+	Describe("AuthorizeAdmin", func() {
+		It("allows tokens the CC lets list every user", func() {
+			fakeCC.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/users", "results-per-page=1"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer token"),
+				ghttp.RespondWith(http.StatusOK, "{}"),
+			))
+
+			Expect(authorizer.AuthorizeAdmin("bearer token")).To(Succeed())
+		})
+
+		It("forbids other tokens", func() {
+			fakeCC.AppendHandlers(ghttp.RespondWith(http.StatusForbidden, "{}"))
+			Expect(authorizer.AuthorizeAdmin("bearer token")).To(Equal(auth.ErrForbidden))
+		})
+	})
+})
+
+var _ = Describe("Denying Authorizer", func() {
+	It("forbids every request", func() {
+		authorizer := auth.NewDenyingAuthorizer()
+		Expect(authorizer.AuthorizeApp("bearer token", "app-guid")).To(Equal(auth.ErrForbidden))
+		Expect(authorizer.AuthorizeSpace("bearer token", "space-guid")).To(Equal(auth.ErrForbidden))
+		Expect(authorizer.AuthorizeAdmin("bearer token")).To(Equal(auth.ErrForbidden))
+	})
+})
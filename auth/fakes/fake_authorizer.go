@@ -0,0 +1,137 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/tps/auth"
+)
+
+type FakeAuthorizer struct {
+	AuthorizeAppStub        func(authorization, appGuid string) error
+	authorizeAppMutex       sync.RWMutex
+	authorizeAppArgsForCall []struct {
+		authorization string
+		appGuid       string
+	}
+	authorizeAppReturns struct {
+		result1 error
+	}
+	AuthorizeSpaceStub        func(authorization, spaceGuid string) error
+	authorizeSpaceMutex       sync.RWMutex
+	authorizeSpaceArgsForCall []struct {
+		authorization string
+		spaceGuid     string
+	}
+	authorizeSpaceReturns struct {
+		result1 error
+	}
+	AuthorizeAdminStub        func(authorization string) error
+	authorizeAdminMutex       sync.RWMutex
+	authorizeAdminArgsForCall []struct {
+		authorization string
+	}
+	authorizeAdminReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeAuthorizer) AuthorizeApp(authorization string, appGuid string) error {
+	fake.authorizeAppMutex.Lock()
+	fake.authorizeAppArgsForCall = append(fake.authorizeAppArgsForCall, struct {
+		authorization string
+		appGuid       string
+	}{authorization, appGuid})
+	fake.authorizeAppMutex.Unlock()
+	if fake.AuthorizeAppStub != nil {
+		return fake.AuthorizeAppStub(authorization, appGuid)
+	} else {
+		return fake.authorizeAppReturns.result1
+	}
+}
+
+func (fake *FakeAuthorizer) AuthorizeAppCallCount() int {
+	fake.authorizeAppMutex.RLock()
+	defer fake.authorizeAppMutex.RUnlock()
+	return len(fake.authorizeAppArgsForCall)
+}
+
+func (fake *FakeAuthorizer) AuthorizeAppArgsForCall(i int) (string, string) {
+	fake.authorizeAppMutex.RLock()
+	defer fake.authorizeAppMutex.RUnlock()
+	return fake.authorizeAppArgsForCall[i].authorization, fake.authorizeAppArgsForCall[i].appGuid
+}
+
+func (fake *FakeAuthorizer) AuthorizeAppReturns(result1 error) {
+	fake.AuthorizeAppStub = nil
+	fake.authorizeAppReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuthorizer) AuthorizeSpace(authorization string, spaceGuid string) error {
+	fake.authorizeSpaceMutex.Lock()
+	fake.authorizeSpaceArgsForCall = append(fake.authorizeSpaceArgsForCall, struct {
+		authorization string
+		spaceGuid     string
+	}{authorization, spaceGuid})
+	fake.authorizeSpaceMutex.Unlock()
+	if fake.AuthorizeSpaceStub != nil {
+		return fake.AuthorizeSpaceStub(authorization, spaceGuid)
+	} else {
+		return fake.authorizeSpaceReturns.result1
+	}
+}
+
+func (fake *FakeAuthorizer) AuthorizeSpaceCallCount() int {
+	fake.authorizeSpaceMutex.RLock()
+	defer fake.authorizeSpaceMutex.RUnlock()
+	return len(fake.authorizeSpaceArgsForCall)
+}
+
+func (fake *FakeAuthorizer) AuthorizeSpaceArgsForCall(i int) (string, string) {
+	fake.authorizeSpaceMutex.RLock()
+	defer fake.authorizeSpaceMutex.RUnlock()
+	return fake.authorizeSpaceArgsForCall[i].authorization, fake.authorizeSpaceArgsForCall[i].spaceGuid
+}
+
+func (fake *FakeAuthorizer) AuthorizeSpaceReturns(result1 error) {
+	fake.AuthorizeSpaceStub = nil
+	fake.authorizeSpaceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeAuthorizer) AuthorizeAdmin(authorization string) error {
+	fake.authorizeAdminMutex.Lock()
+	fake.authorizeAdminArgsForCall = append(fake.authorizeAdminArgsForCall, struct {
+		authorization string
+	}{authorization})
+	fake.authorizeAdminMutex.Unlock()
+	if fake.AuthorizeAdminStub != nil {
+		return fake.AuthorizeAdminStub(authorization)
+	} else {
+		return fake.authorizeAdminReturns.result1
+	}
+}
+
+func (fake *FakeAuthorizer) AuthorizeAdminCallCount() int {
+	fake.authorizeAdminMutex.RLock()
+	defer fake.authorizeAdminMutex.RUnlock()
+	return len(fake.authorizeAdminArgsForCall)
+}
+
+func (fake *FakeAuthorizer) AuthorizeAdminArgsForCall(i int) string {
+	fake.authorizeAdminMutex.RLock()
+	defer fake.authorizeAdminMutex.RUnlock()
+	return fake.authorizeAdminArgsForCall[i].authorization
+}
+
+func (fake *FakeAuthorizer) AuthorizeAdminReturns(result1 error) {
+	fake.AuthorizeAdminStub = nil
+	fake.authorizeAdminReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ auth.Authorizer = new(FakeAuthorizer)
@@ -0,0 +1,34 @@
+package cc_client
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/pivotal-golang/lager"
+)
+
+// SwappableClient records each crash with the client it was last given, so
+// the CC credentials can be changed while the watcher runs
+type SwappableClient struct {
+	lock   sync.RWMutex
+	client CcClient
+}
+
+func NewSwappableClient(client CcClient) *SwappableClient {
+	return &SwappableClient{client: client}
+}
+
+func (s *SwappableClient) Swap(client CcClient) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.client = client
+}
+
+func (s *SwappableClient) AppCrashed(guid string, appCrashed cc_messages.AppCrashedRequest, logger lager.Logger) error {
+	s.lock.RLock()
+	client := s.client
+	s.lock.RUnlock()
+
+	return client.AppCrashed(guid, appCrashed, logger)
+}
@@ -0,0 +1,30 @@
+package cc_client_test
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps/cc_client"
+	"github.com/cloudfoundry-incubator/tps/cc_client/fakes"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SwappableClient", func() {
+	It("records crashes with the client it was last given", func() {
+		logger := lagertest.NewTestLogger("test")
+		first := new(fakes.FakeCcClient)
+		second := new(fakes.FakeCcClient)
+
+		client := cc_client.NewSwappableClient(first)
+		Expect(client.AppCrashed("guid", cc_messages.AppCrashedRequest{Index: 1}, logger)).To(Succeed())
+
+		client.Swap(second)
+		Expect(client.AppCrashed("guid", cc_messages.AppCrashedRequest{Index: 2}, logger)).To(Succeed())
+
+		Expect(first.AppCrashedCallCount()).To(Equal(1))
+		Expect(second.AppCrashedCallCount()).To(Equal(1))
+		_, crashed, _ := second.AppCrashedArgsForCall(0)
+		Expect(crashed.Index).To(Equal(2))
+	})
+})
@@ -0,0 +1,303 @@
+package tps
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/tedsuo/rata"
+)
+
+const (
+	DefaultClientTimeout       = 30 * time.Second
+	DefaultClientRetryInterval = time.Second
+)
+
+// ClientConfig configures a Client. Only URL is required.
+type ClientConfig struct {
+	// base URL of a tps listener, e.g. http://tps.service.cf.internal:1518
+	URL string
+
+	// TLS settings for https URLs; nil uses the system defaults
+	TLSConfig *tls.Config
+
+	// time allowed for each attempt at a request; DefaultClientTimeout if 0
+	Timeout time.Duration
+
+	// how many more times GET requests are attempted when the listener cannot
+	// be reached or responds with a 502, 503 or 504
+	Retries       int
+	RetryInterval time.Duration
+
+	// sent with every request, e.g. an Authorization header for the admin
+	// routes. Calls that take an authorization argument override it.
+	Header http.Header
+}
+
+// filters of NamespaceLRPs and AllLRPs; zero values are left out
+type ListLRPsOptions struct {
+	LabelSelector string
+	State         cc_messages.LRPInstanceState
+	Limit         int
+	Continue      string
+}
+
+//go:generate counterfeiter -o fakes/fake_client.go . Client
+
+// Client calls the routes in Routes. Unsuccessful responses are returned as
+// an Error carrying the code from the response body.
+type Client interface {
+	LRPStatus(processGuid string) ([]cc_messages.LRPInstance, error)
+	LRPStats(processGuid, authorization string) ([]cc_messages.LRPInstance, error)
+	BulkLRPStatus(processGuids []string) (map[string][]cc_messages.LRPInstance, error)
+	LRPEvents(processGuid string, page, perPage int) (LRPEvents, error)
+	LRPInstanceStatus(processGuid string, index uint) (cc_messages.LRPInstance, error)
+	RestartLRPInstance(processGuid string, index uint, authorization string) error
+
+	NamespaceLRPs(namespace string, opts ListLRPsOptions) (LRPList, error)
+	AllLRPs(opts ListLRPsOptions) (LRPList, error)
+
+	AppSummary(processGuid string) (AppSummary, error)
+	BulkAppSummary(processGuids []string) (map[string]AppSummary, error)
+
+	LRPStatusV2(processGuid string) (LRPStatus, error)
+	LRPStatsV2(processGuid, authorization string) (LRPStatus, error)
+	BulkLRPStatusV2(processGuids []string) (map[string]LRPStatus, error)
+}
+
+type client struct {
+	requestGenerator *rata.RequestGenerator
+	httpClient       *http.Client
+	retries          int
+	retryInterval    time.Duration
+	header           http.Header
+}
+
+func NewClient(config ClientConfig) Client {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultClientTimeout
+	}
+
+	retryInterval := config.RetryInterval
+	if retryInterval == 0 {
+		retryInterval = DefaultClientRetryInterval
+	}
+
+	return &client{
+		requestGenerator: rata.NewRequestGenerator(config.URL, Routes),
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				Dial: (&net.Dialer{
+					Timeout:   10 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).Dial,
+				TLSHandshakeTimeout: 10 * time.Second,
+				TLSClientConfig:     config.TLSConfig,
+			},
+		},
+		retries:       config.Retries,
+		retryInterval: retryInterval,
+		header:        config.Header,
+	}
+}
+
+func (c *client) LRPStatus(processGuid string) ([]cc_messages.LRPInstance, error) {
+	var instances []cc_messages.LRPInstance
+	err := c.do(LRPStatus, rata.Params{"guid": processGuid}, nil, "", &instances)
+	return instances, err
+}
+
+func (c *client) LRPStats(processGuid, authorization string) ([]cc_messages.LRPInstance, error) {
+	var instances []cc_messages.LRPInstance
+	err := c.do(LRPStats, rata.Params{"guid": processGuid}, nil, authorization, &instances)
+	return instances, err
+}
+
+func (c *client) BulkLRPStatus(processGuids []string) (map[string][]cc_messages.LRPInstance, error) {
+	statuses := map[string][]cc_messages.LRPInstance{}
+	err := c.do(BulkLRPStatus, nil, guidsQuery(processGuids), "", &statuses)
+	return statuses, err
+}
+
+func (c *client) LRPEvents(processGuid string, page, perPage int) (LRPEvents, error) {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(page))
+	}
+	if perPage > 0 {
+		query.Set("per_page", strconv.Itoa(perPage))
+	}
+
+	var events LRPEvents
+	err := c.do(LRPEvents, rata.Params{"guid": processGuid}, query, "", &events)
+	return events, err
+}
+
+func (c *client) LRPInstanceStatus(processGuid string, index uint) (cc_messages.LRPInstance, error) {
+	var instance cc_messages.LRPInstance
+	err := c.do(LRPInstanceStatus, instanceParams(processGuid, index), nil, "", &instance)
+	return instance, err
+}
+
+func (c *client) RestartLRPInstance(processGuid string, index uint, authorization string) error {
+	return c.do(RestartLRPInstance, instanceParams(processGuid, index), nil, authorization, nil)
+}
+
+func (c *client) NamespaceLRPs(namespace string, opts ListLRPsOptions) (LRPList, error) {
+	var list LRPList
+	err := c.do(NamespaceLRPs, rata.Params{"ns": namespace}, opts.query(), "", &list)
+	return list, err
+}
+
+func (c *client) AllLRPs(opts ListLRPsOptions) (LRPList, error) {
+	var list LRPList
+	err := c.do(AllLRPs, nil, opts.query(), "", &list)
+	return list, err
+}
+
+func (c *client) AppSummary(processGuid string) (AppSummary, error) {
+	var summary AppSummary
+	err := c.do(AppSummary, rata.Params{"guid": processGuid}, nil, "", &summary)
+	return summary, err
+}
+
+func (c *client) BulkAppSummary(processGuids []string) (map[string]AppSummary, error) {
+	summaries := map[string]AppSummary{}
+	err := c.do(BulkAppSummary, nil, guidsQuery(processGuids), "", &summaries)
+	return summaries, err
+}
+
+func (c *client) LRPStatusV2(processGuid string) (LRPStatus, error) {
+	var status LRPStatus
+	err := c.do(LRPStatusV2, rata.Params{"guid": processGuid}, nil, "", &status)
+	return status, err
+}
+
+func (c *client) LRPStatsV2(processGuid, authorization string) (LRPStatus, error) {
+	var status LRPStatus
+	err := c.do(LRPStatsV2, rata.Params{"guid": processGuid}, nil, authorization, &status)
+	return status, err
+}
+
+func (c *client) BulkLRPStatusV2(processGuids []string) (map[string]LRPStatus, error) {
+	statuses := map[string]LRPStatus{}
+	err := c.do(BulkLRPStatusV2, nil, guidsQuery(processGuids), "", &statuses)
+	return statuses, err
+}
+
+func (c *client) do(route string, params rata.Params, query url.Values, authorization string, response interface{}) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retryable bool
+		retryable, err = c.attempt(route, params, query, authorization, response)
+		if err == nil || !retryable || attempt >= c.retries {
+			return err
+		}
+		time.Sleep(c.retryInterval)
+	}
+}
+
+// returns whether a failed request may be retried
+func (c *client) attempt(route string, params rata.Params, query url.Values, authorization string, response interface{}) (bool, error) {
+	request, err := c.requestGenerator.CreateRequest(route, params, nil)
+	if err != nil {
+		return false, err
+	}
+	request.URL.RawQuery = query.Encode()
+
+	for key, values := range c.header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	if authorization != "" {
+		request.Header.Set("Authorization", authorization)
+	}
+
+	idempotent := request.Method == "GET"
+
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return idempotent, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return idempotent && retryableStatus(resp.StatusCode), responseError(resp)
+	}
+
+	if response == nil || resp.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+
+	return false, json.NewDecoder(resp.Body).Decode(response)
+}
+
+func retryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// the listener answers with an Error body; responses from anything in front
+// of it (or from listeners that predate the body) may not carry one
+func responseError(resp *http.Response) error {
+	var tpsErr Error
+	err := json.NewDecoder(resp.Body).Decode(&tpsErr)
+	if err != nil || tpsErr.Code == "" {
+		return Error{
+			Code:    statusCodes[resp.StatusCode],
+			Message: fmt.Sprintf("unexpected response: %s", resp.Status),
+		}
+	}
+	return tpsErr
+}
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:          InvalidRequest,
+	http.StatusUnauthorized:        Unauthorized,
+	http.StatusForbidden:           Forbidden,
+	http.StatusNotFound:            ProcessNotFound,
+	http.StatusBadGateway:          UpstreamError,
+	http.StatusServiceUnavailable:  UpstreamUnavailable,
+	http.StatusGatewayTimeout:      UpstreamTimeout,
+	http.StatusInternalServerError: InternalError,
+}
+
+func instanceParams(processGuid string, index uint) rata.Params {
+	return rata.Params{"guid": processGuid, "index": strconv.FormatUint(uint64(index), 10)}
+}
+
+func guidsQuery(processGuids []string) url.Values {
+	return url.Values{"guids": []string{strings.Join(processGuids, ",")}}
+}
+
+func (opts ListLRPsOptions) query() url.Values {
+	query := url.Values{}
+	if opts.LabelSelector != "" {
+		query.Set("labelSelector", opts.LabelSelector)
+	}
+	if opts.State != "" {
+		query.Set("state", string(opts.State))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Continue != "" {
+		query.Set("continue", opts.Continue)
+	}
+	return query
+}
@@ -0,0 +1,186 @@
+package tps_test
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		fakeTPS *ghttp.Server
+		config  tps.ClientConfig
+		client  tps.Client
+	)
+
+	BeforeEach(func() {
+		fakeTPS = ghttp.NewServer()
+		config = tps.ClientConfig{
+			URL:           fakeTPS.URL(),
+			RetryInterval: time.Millisecond,
+			Header:        http.Header{"X-Request-Source": []string{"test"}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		client = tps.NewClient(config)
+	})
+
+	AfterEach(func() {
+		fakeTPS.Close()
+	})
+
+	Describe("LRPStatus", func() {
+		BeforeEach(func() {
+			fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v1/actual_lrps/some-guid"),
+				ghttp.VerifyHeaderKV("X-Request-Source", "test"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []cc_messages.LRPInstance{
+					{ProcessGuid: "some-guid", Index: 1, State: cc_messages.LRPInstanceStateRunning},
+				}),
+			))
+		})
+
+		It("returns the instances", func() {
+			instances, err := client.LRPStatus("some-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instances).To(Equal([]cc_messages.LRPInstance{
+				{ProcessGuid: "some-guid", Index: 1, State: cc_messages.LRPInstanceStateRunning},
+			}))
+		})
+	})
+
+	Describe("LRPStats", func() {
+		BeforeEach(func() {
+			fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v1/actual_lrps/some-guid/stats"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer token"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []cc_messages.LRPInstance{}),
+			))
+		})
+
+		It("sends the authorization", func() {
+			_, err := client.LRPStats("some-guid", "bearer token")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("BulkLRPStatusV2", func() {
+		BeforeEach(func() {
+			fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/bulk_actual_lrp_status", "guids=guid-1%2Cguid-2"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, map[string]tps.LRPStatus{
+					"guid-1": {ProcessGuid: "guid-1"},
+				}),
+			))
+		})
+
+		It("returns a status per guid", func() {
+			statuses, err := client.BulkLRPStatusV2([]string{"guid-1", "guid-2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(statuses).To(HaveKey("guid-1"))
+		})
+	})
+
+	Describe("NamespaceLRPs", func() {
+		BeforeEach(func() {
+			fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v1/namespaces/space/actual_lrps", "limit=10&state=CRASHED"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, tps.LRPList{Continue: "next"}),
+			))
+		})
+
+		It("passes the options as query parameters", func() {
+			list, err := client.NamespaceLRPs("space", tps.ListLRPsOptions{
+				State: cc_messages.LRPInstanceStateCrashed,
+				Limit: 10,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(list.Continue).To(Equal("next"))
+		})
+	})
+
+	Describe("RestartLRPInstance", func() {
+		BeforeEach(func() {
+			fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/v1/actual_lrps/some-guid/instances/2"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+		})
+
+		It("deletes the instance", func() {
+			err := client.RestartLRPInstance("some-guid", 2, "bearer token")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeTPS.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	Describe("errors", func() {
+		Context("when the listener responds with an error body", func() {
+			BeforeEach(func() {
+				fakeTPS.AppendHandlers(ghttp.RespondWithJSONEncoded(http.StatusNotFound, tps.Error{
+					Code:    tps.ProcessNotFound,
+					Message: "no instances found",
+				}))
+			})
+
+			It("returns the error", func() {
+				_, err := client.LRPStatus("some-guid")
+				Expect(err).To(Equal(tps.Error{Code: tps.ProcessNotFound, Message: "no instances found"}))
+			})
+		})
+
+		Context("when the response has no error body", func() {
+			BeforeEach(func() {
+				fakeTPS.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, ""))
+			})
+
+			It("derives the code from the status", func() {
+				_, err := client.LRPStatus("some-guid")
+				Expect(err).To(BeAssignableToTypeOf(tps.Error{}))
+				Expect(err.(tps.Error).Code).To(Equal(tps.Unauthorized))
+			})
+		})
+
+		Context("when the listener is unavailable", func() {
+			BeforeEach(func() {
+				config.Retries = 2
+				fakeTPS.AppendHandlers(
+					ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+					ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, []cc_messages.LRPInstance{}),
+				)
+			})
+
+			It("retries GET requests", func() {
+				_, err := client.LRPStatus("some-guid")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeTPS.ReceivedRequests()).To(HaveLen(3))
+			})
+
+			It("does not retry restarts", func() {
+				err := client.RestartLRPInstance("some-guid", 0, "bearer token")
+				Expect(err).To(HaveOccurred())
+				Expect(fakeTPS.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when a request is not acceptable", func() {
+			BeforeEach(func() {
+				config.Retries = 2
+				fakeTPS.AppendHandlers(ghttp.RespondWith(http.StatusBadRequest, ""))
+			})
+
+			It("does not retry it", func() {
+				_, err := client.LRPStatus("some-guid")
+				Expect(err).To(HaveOccurred())
+				Expect(fakeTPS.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+	})
+})
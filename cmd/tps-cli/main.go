@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps"
+)
+
+var tpsURL = flag.String(
+	"url",
+	"http://127.0.0.1:1518",
+	"URL of the tps-listener",
+)
+
+var authorization = flag.String(
+	"authorization",
+	os.Getenv("TPS_AUTHORIZATION"),
+	"Authorization header sent to routes that require one, e.g. 'bearer <token>'; defaults to $TPS_AUTHORIZATION",
+)
+
+var skipSSLVerification = flag.Bool(
+	"skipSSLVerification",
+	false,
+	"Skip SSL verification",
+)
+
+var timeout = flag.Duration(
+	"timeout",
+	tps.DefaultClientTimeout,
+	"time allowed for each request",
+)
+
+var printJSON = flag.Bool(
+	"json",
+	false,
+	"print the responses as JSON instead of tables",
+)
+
+var watchInterval = flag.Duration(
+	"interval",
+	2*time.Second,
+	"how often watch refreshes",
+)
+
+var guidsFile = flag.String(
+	"file",
+	"",
+	"file of process guids, one per line, for bulk; - reads standard input",
+)
+
+const usage = `usage: tps-cli [flags] <command> [args]
+
+commands:
+  status <process-guid>    instances of a process
+  stats <process-guid>     instances of a process with their container metrics
+  summary <process-guid>   instance counts of a process
+  watch <process-guid>     refresh the status of a process every -interval
+  bulk [process-guid...]   status of many processes, or of the guids in -file
+
+flags:
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	client := tps.NewClient(tps.ClientConfig{
+		URL:       *tpsURL,
+		TLSConfig: &tls.Config{InsecureSkipVerify: *skipSSLVerification},
+		Timeout:   *timeout,
+	})
+
+	err := run(client, flag.Arg(0), flag.Args()[1:], os.Stdout)
+	if err == errUsage {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tps-cli:", err)
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New("usage")
+
+func run(client tps.Client, command string, args []string, out io.Writer) error {
+	switch command {
+	case "status", "stats", "summary", "watch":
+		if len(args) != 1 {
+			return errUsage
+		}
+	}
+
+	switch command {
+	case "status":
+		return status(client, args[0], out)
+	case "stats":
+		if *authorization == "" {
+			return errors.New("stats requires -authorization")
+		}
+		stats, err := client.LRPStatsV2(args[0], *authorization)
+		if err != nil {
+			return err
+		}
+		return output(out, stats, func(w io.Writer) { writeStats(w, stats) })
+	case "summary":
+		summary, err := client.AppSummary(args[0])
+		if err != nil {
+			return err
+		}
+		return output(out, summary, func(w io.Writer) { writeSummaries(w, map[string]tps.AppSummary{args[0]: summary}) })
+	case "watch":
+		return watch(client, args[0], out)
+	case "bulk":
+		guids, err := bulkGuids(args)
+		if err != nil {
+			return err
+		}
+		statuses, err := client.BulkLRPStatusV2(guids)
+		if err != nil {
+			return err
+		}
+		return output(out, statuses, func(w io.Writer) { writeBulkStatus(w, guids, statuses) })
+	default:
+		return errUsage
+	}
+}
+
+func status(client tps.Client, guid string, out io.Writer) error {
+	status, err := client.LRPStatusV2(guid)
+	if err != nil {
+		return err
+	}
+	return output(out, status, func(w io.Writer) { writeStatus(w, status) })
+}
+
+// prints the status until interrupted; with -json each refresh is one line
+func watch(client tps.Client, guid string, out io.Writer) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	ticker := time.NewTicker(*watchInterval)
+	defer ticker.Stop()
+
+	for {
+		if !*printJSON {
+			// clear the terminal between refreshes
+			fmt.Fprint(out, "\033[H\033[2J")
+			fmt.Fprintf(out, "%s  every %s\n\n", time.Now().Format(time.RFC3339), *watchInterval)
+		}
+
+		err := status(client, guid, out)
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+func bulkGuids(args []string) ([]string, error) {
+	guids := append([]string{}, args...)
+
+	if *guidsFile != "" {
+		var reader io.Reader = os.Stdin
+		if *guidsFile != "-" {
+			file, err := os.Open(*guidsFile)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			reader = file
+		}
+
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				guids = append(guids, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(guids) == 0 {
+		return nil, errors.New("bulk needs process guids as arguments or in -file")
+	}
+	return guids, nil
+}
+
+func output(out io.Writer, response interface{}, table func(io.Writer)) error {
+	if *printJSON {
+		return json.NewEncoder(out).Encode(response)
+	}
+	table(out)
+	return nil
+}
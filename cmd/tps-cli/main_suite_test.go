@@ -0,0 +1,29 @@
+package main_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+
+	"testing"
+)
+
+var cliPath string
+
+func TestTPSCLI(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TPS-CLI Suite")
+}
+
+var _ = SynchronizedBeforeSuite(func() []byte {
+	path, err := gexec.Build("github.com/cloudfoundry-incubator/tps/cmd/tps-cli")
+	Expect(err).NotTo(HaveOccurred())
+	return []byte(path)
+}, func(path []byte) {
+	cliPath = string(path)
+})
+
+var _ = SynchronizedAfterSuite(func() {
+}, func() {
+	gexec.CleanupBuildArtifacts()
+})
@@ -0,0 +1,159 @@
+package main_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("tps-cli", func() {
+	var (
+		fakeTPS *ghttp.Server
+		status  tps.LRPStatus
+	)
+
+	run := func(args ...string) *gexec.Session {
+		cmd := exec.Command(cliPath, append([]string{"-url", fakeTPS.URL()}, args...)...)
+		session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		return session
+	}
+
+	BeforeEach(func() {
+		fakeTPS = ghttp.NewServer()
+
+		status = tps.LRPStatus{
+			ProcessGuid: "some-guid",
+			Instances: []tps.LRPInstance{
+				{
+					LRPInstance: cc_messages.LRPInstance{
+						ProcessGuid: "some-guid",
+						Index:       0,
+						State:       cc_messages.LRPInstanceStateRunning,
+						Uptime:      90,
+					},
+					PodName:  "some-pod",
+					NodeName: "node-1",
+					Current:  true,
+				},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		fakeTPS.Close()
+	})
+
+	Describe("status", func() {
+		BeforeEach(func() {
+			fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/actual_lrps/some-guid"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, status),
+			))
+		})
+
+		It("prints the instances as a table", func() {
+			session := run("status", "some-guid")
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("INDEX\\s+STATE"))
+			Expect(session.Out).To(gbytes.Say("0\\s+RUNNING\\s+-\\s+1m30s\\s+0\\s+node-1\\s+some-pod"))
+		})
+
+		It("prints JSON with -json", func() {
+			session := run("-json", "status", "some-guid")
+			Eventually(session).Should(gexec.Exit(0))
+
+			var printed tps.LRPStatus
+			err := json.Unmarshal(session.Out.Contents(), &printed)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(printed).To(Equal(status))
+		})
+	})
+
+	Describe("stats", func() {
+		It("requires an authorization", func() {
+			session := run("stats", "some-guid")
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("stats requires -authorization"))
+		})
+
+		Context("with an authorization", func() {
+			BeforeEach(func() {
+				status.Instances[0].Stats = &cc_messages.LRPInstanceStats{CpuPercentage: 0.5, MemoryBytes: 2048}
+				fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/actual_lrps/some-guid/stats"),
+					ghttp.VerifyHeaderKV("Authorization", "bearer token"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, status),
+				))
+			})
+
+			It("prints the metrics", func() {
+				session := run("-authorization", "bearer token", "stats", "some-guid")
+				Eventually(session).Should(gexec.Exit(0))
+				Expect(session.Out).To(gbytes.Say("0\\s+RUNNING\\s+1m30s\\s+50.0%\\s+2.0KiB"))
+			})
+		})
+	})
+
+	Describe("bulk", func() {
+		var guidsFile string
+
+		BeforeEach(func() {
+			file, err := ioutil.TempFile("", "guids")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = file.WriteString("some-guid\n# a comment\n\nother-guid\n")
+			Expect(err).NotTo(HaveOccurred())
+			file.Close()
+			guidsFile = file.Name()
+
+			fakeTPS.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/bulk_actual_lrp_status", "guids=some-guid%2Cother-guid"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, map[string]tps.LRPStatus{"some-guid": status}),
+			))
+		})
+
+		AfterEach(func() {
+			os.Remove(guidsFile)
+		})
+
+		It("queries the guids in the file", func() {
+			session := run("-file", guidsFile, "bulk")
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("some-guid\\s+1\\s+1\\s+0\\s+0\\s+0"))
+			Expect(session.Out).To(gbytes.Say("other-guid\\s+not found"))
+		})
+	})
+
+	Context("when the listener responds with an error", func() {
+		BeforeEach(func() {
+			fakeTPS.AppendHandlers(ghttp.RespondWithJSONEncoded(http.StatusNotFound, tps.Error{
+				Code:    tps.ProcessNotFound,
+				Message: "no instances found for process guid some-guid",
+			}))
+		})
+
+		It("prints the error and exits non-zero", func() {
+			session := run("status", "some-guid")
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("ProcessNotFound: no instances found for process guid some-guid"))
+		})
+	})
+
+	Context("with an unknown command", func() {
+		It("prints the usage", func() {
+			session := run("frobnicate")
+			Eventually(session).Should(gexec.Exit(2))
+			Expect(session.Err).To(gbytes.Say("usage: tps-cli"))
+		})
+	})
+})
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps"
+)
+
+func newTable(out io.Writer) *tabwriter.Writer {
+	return tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+}
+
+func writeStatus(out io.Writer, status tps.LRPStatus) {
+	fmt.Fprintf(out, "process guid: %s\n", status.ProcessGuid)
+	if len(status.Versions) > 1 {
+		fmt.Fprintf(out, "versions: %s (current %s)\n", counts(status.Versions), status.CurrentVersion)
+	}
+	if len(status.Zones) > 0 {
+		fmt.Fprintf(out, "zones: %s\n", counts(status.Zones))
+	}
+	fmt.Fprintln(out)
+
+	table := newTable(out)
+	fmt.Fprintln(table, "INDEX\tSTATE\tSINCE\tUPTIME\tRESTARTS\tNODE\tZONE\tPOD\tDETAILS")
+	for _, instance := range status.Instances {
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			instance.Index,
+			state(instance),
+			since(instance.Since),
+			time.Duration(instance.Uptime)*time.Second,
+			instance.RestartCount,
+			instance.NodeName,
+			instance.Zone,
+			instance.PodName,
+			instance.Details,
+		)
+	}
+	table.Flush()
+}
+
+func writeStats(out io.Writer, status tps.LRPStatus) {
+	fmt.Fprintf(out, "process guid: %s\n\n", status.ProcessGuid)
+
+	table := newTable(out)
+	fmt.Fprintln(table, "INDEX\tSTATE\tUPTIME\tCPU\tMEMORY\tDISK")
+	for _, instance := range status.Instances {
+		cpu, memory, disk := "-", "-", "-"
+		if instance.Stats != nil {
+			cpu = fmt.Sprintf("%.1f%%", instance.Stats.CpuPercentage*100)
+			memory = formatBytes(instance.Stats.MemoryBytes)
+			disk = formatBytes(instance.Stats.DiskBytes)
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			instance.Index,
+			state(instance),
+			time.Duration(instance.Uptime)*time.Second,
+			cpu,
+			memory,
+			disk,
+		)
+	}
+	table.Flush()
+}
+
+func writeSummaries(out io.Writer, summaries map[string]tps.AppSummary) {
+	table := newTable(out)
+	fmt.Fprintln(table, "PROCESS GUID\tHEALTH\tRUNNING\tDESIRED\tSTARTING\tCRASHED\tDOWN\tRESTARTS")
+	for _, guid := range sortedKeys(summaries) {
+		summary := summaries[guid]
+		fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			guid,
+			summary.Health,
+			summary.Running,
+			summary.Desired,
+			summary.Starting,
+			summary.Crashed,
+			summary.Down,
+			summary.TotalRestarts,
+		)
+	}
+	table.Flush()
+}
+
+func writeBulkStatus(out io.Writer, guids []string, statuses map[string]tps.LRPStatus) {
+	table := newTable(out)
+	fmt.Fprintln(table, "PROCESS GUID\tINSTANCES\tRUNNING\tSTARTING\tDOWN\tCRASHED")
+	for _, guid := range guids {
+		status, ok := statuses[guid]
+		if !ok {
+			fmt.Fprintf(table, "%s\tnot found\t\t\t\t\n", guid)
+			continue
+		}
+
+		byState := map[string]int{}
+		for _, instance := range status.Instances {
+			if instance.Current {
+				byState[string(instance.State)]++
+			}
+		}
+		fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t%d\n",
+			guid,
+			len(status.Instances),
+			byState["RUNNING"],
+			byState["STARTING"],
+			byState["DOWN"],
+			byState["CRASHED"],
+		)
+	}
+	table.Flush()
+}
+
+// instances of other app versions are marked so they are not mistaken for
+// instances of the requested process
+func state(instance tps.LRPInstance) string {
+	if !instance.Current && instance.Version != "" {
+		return string(instance.State) + " (old)"
+	}
+	return string(instance.State)
+}
+
+func since(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).Format(time.RFC3339)
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func counts(byKey map[string]int) string {
+	s := ""
+	for _, key := range sortedKeys(byKey) {
+		if s != "" {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s=%d", key, byKey[key])
+	}
+	return s
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch m := m.(type) {
+	case map[string]int:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]tps.AppSummary:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
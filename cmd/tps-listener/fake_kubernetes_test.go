@@ -0,0 +1,294 @@
+package main_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/types"
+
+	"github.com/cloudfoundry/sonde-go/events"
+	"github.com/gogo/protobuf/proto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/rata"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/topology"
+)
+
+var _ = Describe("TPS-Listener against the fake kubernetes API", func() {
+	var (
+		httpClient       *http.Client
+		requestGenerator *rata.RequestGenerator
+
+		processGuid helpers.ProcessGuid
+		pods        []v1.Pod
+	)
+
+	BeforeEach(func() {
+		requestGenerator = rata.NewRequestGenerator(fmt.Sprintf("http://%s", listenerAddr), tps.Routes)
+		httpClient = &http.Client{
+			Transport: &http.Transport{},
+		}
+
+		var err error
+		processGuid, err = generateProcessGuid()
+		Expect(err).NotTo(HaveOccurred())
+
+		rc := generateReplicationController(processGuid)
+		fakeKube.AddReplicationController(*rc)
+
+		pods = nil
+		for i := 0; i < 3; i++ {
+			pod := runningPod(rc, i)
+			fakeKube.AddPod(pod)
+			pods = append(pods, pod)
+		}
+	})
+
+	JustBeforeEach(func() {
+		listener = ginkgomon.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		if listener != nil {
+			listener.Signal(os.Kill)
+			Eventually(listener.Wait()).Should(Receive())
+		}
+	})
+
+	Describe("GET actual LRP for a given guid", func() {
+		It("reports the state of the pods served by the fake", func() {
+			request, err := requestGenerator.CreateRequest(
+				tps.LRPStatus,
+				rata.Params{"guid": processGuid.String()},
+				nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			response, err := httpClient.Do(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+			var lrpInstances []cc_messages.LRPInstance
+			err = json.NewDecoder(response.Body).Decode(&lrpInstances)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lrpInstances).To(HaveLen(3))
+			for i, instance := range lrpInstances {
+				Expect(instance.ProcessGuid).To(Equal(processGuid.String()))
+				Expect(instance.Index).To(BeEquivalentTo(i))
+				Expect(instance.InstanceGuid).To(Equal(string(pods[i].ObjectMeta.UID)))
+				Expect(instance.State).To(Equal(cc_messages.LRPInstanceStateRunning))
+			}
+		})
+
+		Context("when the process is deployed with a replica set", func() {
+			BeforeEach(func() {
+				fakeKube.DeleteReplicationController("default", processGuid.ShortenedGuid())
+				fakeKube.AddReplicaSet(v1beta1.ReplicaSet{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      processGuid.ShortenedGuid(),
+						Namespace: "default",
+						Labels:    map[string]string{"cloudfoundry.org/process-guid": processGuid.ShortenedGuid()},
+					},
+					Spec: v1beta1.ReplicaSetSpec{Replicas: helpers.Int32Ptr(4)},
+				})
+			})
+
+			It("reports the instances the replica set desires", func() {
+				request, err := requestGenerator.CreateRequest(
+					tps.LRPStatus,
+					rata.Params{"guid": processGuid.String()},
+					nil,
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := httpClient.Do(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+				var lrpInstances []cc_messages.LRPInstance
+				err = json.NewDecoder(response.Body).Decode(&lrpInstances)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lrpInstances).To(HaveLen(4))
+				Expect(lrpInstances[3].State).To(Equal(cc_messages.LRPInstanceStateDown))
+			})
+		})
+
+		Context("when the process has no pods", func() {
+			BeforeEach(func() {
+				for _, pod := range pods {
+					fakeKube.DeletePod(pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
+				}
+				fakeKube.DeleteReplicationController("default", processGuid.ShortenedGuid())
+			})
+
+			It("responds with 404", func() {
+				request, err := requestGenerator.CreateRequest(
+					tps.LRPStatus,
+					rata.Params{"guid": processGuid.String()},
+					nil,
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := httpClient.Do(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Describe("GET actual LRP stats", func() {
+		BeforeEach(func() {
+			fakeTrafficController.SetContainerMetrics("the-log-guid",
+				&events.ContainerMetric{
+					ApplicationId: proto.String("the-log-guid"),
+					InstanceIndex: proto.Int32(1),
+					CpuPercentage: proto.Float64(50),
+					MemoryBytes:   proto.Uint64(1024),
+					DiskBytes:     proto.Uint64(2048),
+				},
+			)
+		})
+
+		It("reports the container metrics served by the fake traffic controller", func() {
+			request, err := requestGenerator.CreateRequest(
+				tps.LRPStats,
+				rata.Params{"guid": processGuid.String()},
+				nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Authorization", "bearer some-token")
+
+			response, err := httpClient.Do(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+			var lrpInstances []cc_messages.LRPInstance
+			err = json.NewDecoder(response.Body).Decode(&lrpInstances)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(lrpInstances).To(HaveLen(3))
+			Expect(lrpInstances[0].Stats).To(BeNil())
+			Expect(lrpInstances[1].Stats).NotTo(BeNil())
+			Expect(lrpInstances[1].Stats.CpuPercentage).To(Equal(0.5))
+			Expect(lrpInstances[1].Stats.MemoryBytes).To(BeEquivalentTo(1024))
+			Expect(lrpInstances[1].Stats.DiskBytes).To(BeEquivalentTo(2048))
+
+			Expect(fakeTrafficController.Authorizations()).To(ConsistOf("bearer some-token"))
+		})
+	})
+
+	Describe("DELETE an instance", func() {
+		It("deletes the instance's pod", func() {
+			request, err := requestGenerator.CreateRequest(
+				tps.RestartLRPInstance,
+				rata.Params{"guid": processGuid.String(), "index": "1"},
+				nil,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			request.Header.Set("Authorization", "bearer some-token")
+
+			response, err := httpClient.Do(request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.StatusCode).To(BeNumerically("<", 300))
+
+			podNames := []string{}
+			for _, pod := range fakeKube.Pods("default") {
+				podNames = append(podNames, pod.ObjectMeta.Name)
+			}
+			Expect(podNames).To(ConsistOf(pods[0].ObjectMeta.Name, pods[2].ObjectMeta.Name))
+		})
+	})
+
+	Describe("GET v2 actual LRP for a given guid", func() {
+		BeforeEach(func() {
+			fakeKube.AddNode(v1.Node{
+				ObjectMeta: v1.ObjectMeta{
+					Name: "node-1",
+					Labels: map[string]string{
+						topology.ZoneLabel:   "zone-a",
+						topology.RegionLabel: "region-1",
+					},
+				},
+			})
+		})
+
+		It("reports the zones of the nodes served by the fake", func() {
+			status := func() tps.LRPStatus {
+				request, err := requestGenerator.CreateRequest(
+					tps.LRPStatusV2,
+					rata.Params{"guid": processGuid.String()},
+					nil,
+				)
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := httpClient.Do(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
+
+				var status tps.LRPStatus
+				err = json.NewDecoder(response.Body).Decode(&status)
+				Expect(err).NotTo(HaveOccurred())
+				return status
+			}
+
+			Eventually(func() map[string]int { return status().Zones }, 5*time.Second).Should(Equal(map[string]int{"zone-a": 3}))
+
+			instances := status().Instances
+			Expect(instances).To(HaveLen(3))
+			for _, instance := range instances {
+				Expect(instance.NodeName).To(Equal("node-1"))
+				Expect(instance.Zone).To(Equal("zone-a"))
+				Expect(instance.Region).To(Equal("region-1"))
+			}
+		})
+	})
+})
+
+// a ready pod of the replication controller, running on node-1. The
+// listener orders instances by pod uid, so the uid carries the index.
+func runningPod(rc *v1.ReplicationController, index int) v1.Pod {
+	created := unversioned.NewTime(time.Now().Add(time.Duration(index-10) * time.Minute))
+	started := unversioned.NewTime(created.Add(time.Second))
+
+	return v1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%d", rc.ObjectMeta.Name, index),
+			UID:               types.UID(fmt.Sprintf("pod-%d", index)),
+			Namespace:         rc.ObjectMeta.Namespace,
+			Labels:            rc.Spec.Template.ObjectMeta.Labels,
+			Annotations:       rc.ObjectMeta.Annotations,
+			CreationTimestamp: created,
+		},
+		Spec: v1.PodSpec{
+			NodeName:   "node-1",
+			Containers: rc.Spec.Template.Spec.Containers,
+		},
+		Status: v1.PodStatus{
+			Phase:     v1.PodRunning,
+			StartTime: &started,
+			Conditions: []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			},
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "application",
+				Ready: true,
+				State: v1.ContainerState{
+					Running: &v1.ContainerStateRunning{StartedAt: started},
+				},
+			}},
+		},
+	}
+}
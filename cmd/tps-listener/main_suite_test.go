@@ -6,17 +6,13 @@ import (
 	"os"
 	"path/filepath"
 
-	"github.com/cloudfoundry-incubator/consuladapter/consulrunner"
 	"github.com/cloudfoundry-incubator/tps/cmd/tpsrunner"
 	. "github.com/onsi/ginkgo"
-	"github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gexec"
-	"github.com/onsi/gomega/ghttp"
 	"github.com/pivotal-golang/lager/lagertest"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
-	v1core "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1"
 
 	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
 
@@ -24,7 +20,7 @@ import (
 )
 
 var (
-	consulRunner *consulrunner.ClusterRunner
+	consul *tpsrunner.FakeConsul
 
 	listenerPort int
 	listenerAddr string
@@ -33,9 +29,8 @@ var (
 
 	listenerPath string
 
-	fakeCC                *ghttp.Server
-	k8sClient             v1core.CoreInterface
-	fakeTrafficController *ghttp.Server
+	fakeKube              *tpsrunner.FakeKubernetes
+	fakeTrafficController *tpsrunner.FakeTrafficController
 
 	logger *lagertest.TestLogger
 )
@@ -65,48 +60,60 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 
 	listenerPath = string(binaries["listener"])
 
-	consulRunner = consulrunner.NewClusterRunner(
-		9001+config.GinkgoConfig.ParallelNode*consulrunner.PortOffsetLength,
-		1,
-		"http",
-	)
-
 	logger = lagertest.NewTestLogger("test")
-
-	consulRunner.Start()
-	consulRunner.WaitUntilReady()
 })
 
 var _ = BeforeEach(func() {
-	consulRunner.Reset()
-	fakeCC = ghttp.NewServer()
-	fakeTrafficController = ghttp.NewTLSServer()
+	consul = tpsrunner.NewFakeConsul()
+	fakeKube = tpsrunner.NewFakeKubernetes()
+	fakeTrafficController = tpsrunner.NewFakeTrafficController()
 
 	listenerAddr = fmt.Sprintf("127.0.0.1:%d", uint16(listenerPort))
-	home := os.Getenv("HOME")
-	config, _ := clientcmd.LoadFromFile(filepath.Join(home, ".kube", "config"))
-
-	context := config.Contexts[config.CurrentContext]
 
 	runner = tpsrunner.NewListener(
 		string(listenerPath),
 		listenerAddr,
-		config.Clusters[context.Cluster].Server,
-		config.AuthInfos[context.AuthInfo].ClientCertificate,
-		config.AuthInfos[context.AuthInfo].ClientKey,
-		config.Clusters[context.Cluster].CertificateAuthority,
+		fakeKube.URL(),
+		"",
+		"",
+		"",
 		fakeTrafficController.URL(),
-		consulRunner.URL(),
+		consul.URL(),
 	)
 })
 
 var _ = AfterEach(func() {
-	fakeCC.Close()
+	fakeKube.Close()
 	fakeTrafficController.Close()
+	consul.Close()
 })
 
+// a listener talking to the cluster of the current kubeconfig context, for
+// the specs that need real pods; ok is false when there is no kubeconfig
+func clusterRunner() (*ginkgomon.Runner, bool) {
+	config, err := clientcmd.LoadFromFile(filepath.Join(os.Getenv("HOME"), ".kube", "config"))
+	if err != nil {
+		return nil, false
+	}
+
+	context, found := config.Contexts[config.CurrentContext]
+	if !found {
+		return nil, false
+	}
+
+	return tpsrunner.NewListener(
+		string(listenerPath),
+		listenerAddr,
+		config.Clusters[context.Cluster].Server,
+		config.AuthInfos[context.AuthInfo].ClientCertificate,
+		config.AuthInfos[context.AuthInfo].ClientKey,
+		config.Clusters[context.Cluster].CertificateAuthority,
+		fakeTrafficController.URL(),
+		consul.URL(),
+	), true
+}
+
 var _ = SynchronizedAfterSuite(func() {
-	consulRunner.Stop()
 }, func() {
 	gexec.CleanupBuildArtifacts()
 })
@@ -58,17 +58,6 @@ var _ = Describe("TPS-Listener", func() {
 		Expect(err).NotTo(HaveOccurred())
 		processGuid3, err = generateProcessGuid()
 		Expect(err).NotTo(HaveOccurred())
-
-		config := loadClientConfig()
-		k8sConfig, err := clientset.NewForConfig(config)
-		defaultNamespace = "default"
-
-		if err != nil {
-			logger.Fatal("Can't create kubernetes client", err)
-		}
-
-		k8sClient = k8sConfig.Core()
-
 	})
 
 	JustBeforeEach(func() {
@@ -80,16 +69,14 @@ var _ = Describe("TPS-Listener", func() {
 			listener.Signal(os.Kill)
 			Eventually(listener.Wait()).Should(Receive())
 		}
-		fmt.Printf("calling after each")
-
-		deleteReplicationController(k8sClient, defaultNamespace, processGuid1)
-		deleteReplicationController(k8sClient, defaultNamespace, processGuid2)
-		deleteReplicationController(k8sClient, defaultNamespace, processGuid3)
 	})
 
 	Describe("Initialization", func() {
 		It("registers itself with consul", func() {
-			services, err := consulRunner.NewClient().Agent().Services()
+			consulClient, err := consul.NewClient()
+			Expect(err).NotTo(HaveOccurred())
+
+			services, err := consulClient.Agent().Services()
 			Expect(err).NotTo(HaveOccurred())
 			Expect(services).Should(HaveKeyWithValue("tps",
 				&consulapi.AgentService{
@@ -100,7 +87,10 @@ var _ = Describe("TPS-Listener", func() {
 		})
 
 		It("registers a TTL healthcheck", func() {
-			checks, err := consulRunner.NewClient().Agent().Checks()
+			consulClient, err := consul.NewClient()
+			Expect(err).NotTo(HaveOccurred())
+
+			checks, err := consulClient.Agent().Checks()
 			Expect(err).NotTo(HaveOccurred())
 			Expect(checks).Should(HaveKeyWithValue("service:tps",
 				&consulapi.AgentCheck{
@@ -114,163 +104,188 @@ var _ = Describe("TPS-Listener", func() {
 		})
 	})
 
-	Describe("GET actual LRP for a given guid", func() {
-		Context("when the kubernetes is running", func() {
-			JustBeforeEach(func() {
-				newRC1 := generateReplicationController(processGuid1)
-				_, err := k8sClient.ReplicationControllers(newRC1.ObjectMeta.Namespace).Create(newRC1)
-				Expect(err).NotTo(HaveOccurred())
-
-				Eventually(replicationControllers(k8sClient, processGuid1.AppGuid.String()), 1*time.Minute).Should(HaveLen(1))
-				Eventually(pods(k8sClient, processGuid1.AppGuid.String()), 1*time.Minute).Should(HaveLen(3))
-
-				// wait till containers all reach running
-				time.Sleep(30 * time.Second)
-			})
-
-			It("reports the state of the given process guid's instances", func() {
-				getLRPs, err := requestGenerator.CreateRequest(
-					tps.LRPStatus,
-					rata.Params{"guid": processGuid1.String()},
-					nil,
-				)
-				Expect(err).NotTo(HaveOccurred())
-				response, err := httpClient.Do(getLRPs)
-				Expect(err).NotTo(HaveOccurred())
-				//time.Sleep(time.Second * 120)
-				var lrpInstances []cc_messages.LRPInstance
-				err = json.NewDecoder(response.Body).Decode(&lrpInstances)
-				Expect(err).NotTo(HaveOccurred())
-
-				podsList, err := k8sClient.Pods(defaultNamespace).List(api.ListOptions{
-					LabelSelector: labels.Set{"cloudfoundry.org/process-guid": processGuid1.ShortenedGuid()}.AsSelector(),
-				})
-
-				items := podsList.Items
-				items = tpshelpers.SortPods(items)
-				Expect(len(items)).To(Equal(3))
-
-				for _, pod := range items {
-					containerStatuses := pod.Status.ContainerStatuses
-					Eventually(containerStatuses).ShouldNot(BeNil())
-					Eventually(containerStatuses[0].State.Running).ShouldNot(BeNil())
-				}
-
-				Expect(lrpInstances).To(HaveLen(3))
-
-				for i, _ := range lrpInstances {
-					Expect(lrpInstances[i]).NotTo(BeZero())
-					lrpInstances[i].Since = 0
-
-					Eventually(lrpInstances[i]).ShouldNot(BeZero())
-					lrpInstances[i].Uptime = 0
-				}
+	Describe("against a kubernetes cluster", func() {
+		BeforeEach(func() {
+			var ok bool
+			runner, ok = clusterRunner()
+			if !ok {
+				Skip("no kubeconfig found")
+			}
 
-				Expect(lrpInstances).To(ContainElement(cc_messages.LRPInstance{
-					ProcessGuid:  processGuid1.String(),
-					InstanceGuid: string(items[0].ObjectMeta.UID),
-					Index:        0,
-					State:        cc_messages.LRPInstanceStateRunning,
-				}))
+			k8sConfig, err := clientset.NewForConfig(loadClientConfig())
+			defaultNamespace = "default"
 
-				Expect(lrpInstances).To(ContainElement(cc_messages.LRPInstance{
-					ProcessGuid:  processGuid1.String(),
-					InstanceGuid: string(items[1].ObjectMeta.UID),
-					Index:        1,
-					State:        cc_messages.LRPInstanceStateRunning,
-				}))
+			if err != nil {
+				logger.Fatal("Can't create kubernetes client", err)
+			}
 
-				Expect(lrpInstances).To(ContainElement(cc_messages.LRPInstance{
-					ProcessGuid:  processGuid1.String(),
-					InstanceGuid: string(items[2].ObjectMeta.UID),
-					Index:        2,
-					State:        cc_messages.LRPInstanceStateRunning,
-				}))
-			})
+			k8sClient = k8sConfig.Core()
 		})
-	})
 
-	Describe("get actual lrp for all guids when kubernetes is running", func() {
-		JustBeforeEach(func() {
-			newRC1 := generateReplicationController(processGuid2)
-			_, err = k8sClient.ReplicationControllers(newRC1.ObjectMeta.Namespace).Create(newRC1)
-			Expect(err).NotTo(HaveOccurred())
-			newRC2 := generateReplicationController(processGuid3)
-			_, err = k8sClient.ReplicationControllers(newRC2.ObjectMeta.Namespace).Create(newRC2)
-			Expect(err).NotTo(HaveOccurred())
-			Eventually(replicationControllers(k8sClient, processGuid2.AppGuid.String()), 1*time.Minute).Should(HaveLen(1))
-			Eventually(replicationControllers(k8sClient, processGuid3.AppGuid.String()), 1*time.Minute).Should(HaveLen(1))
-			Eventually(pods(k8sClient, processGuid2.AppGuid.String()), 1*time.Minute).Should(HaveLen(3))
-			Eventually(pods(k8sClient, processGuid3.AppGuid.String()), 1*time.Minute).Should(HaveLen(3))
-			// wait till containers all reach running
-			time.Sleep(60 * time.Second)
+		AfterEach(func() {
+			deleteReplicationController(k8sClient, defaultNamespace, processGuid1)
+			deleteReplicationController(k8sClient, defaultNamespace, processGuid2)
+			deleteReplicationController(k8sClient, defaultNamespace, processGuid3)
 		})
 
-		It("reports the status for all the process guids supplied", func() {
-			getLRPStatus, err := requestGenerator.CreateRequest(
-				tps.BulkLRPStatus,
-				nil,
-				nil,
-			)
-			Expect(err).NotTo(HaveOccurred())
-			getLRPStatus.Header.Add("Authorization", "I can do this.")
+		Describe("GET actual LRP for a given guid", func() {
+			Context("when the kubernetes is running", func() {
+				JustBeforeEach(func() {
+					newRC1 := generateReplicationController(processGuid1)
+					_, err := k8sClient.ReplicationControllers(newRC1.ObjectMeta.Namespace).Create(newRC1)
+					Expect(err).NotTo(HaveOccurred())
 
-			query := getLRPStatus.URL.Query()
-			query.Set("guids", processGuid2.String()+","+processGuid3.String())
-			getLRPStatus.URL.RawQuery = query.Encode()
+					Eventually(replicationControllers(k8sClient, processGuid1.AppGuid.String()), 1*time.Minute).Should(HaveLen(1))
+					Eventually(pods(k8sClient, processGuid1.AppGuid.String()), 1*time.Minute).Should(HaveLen(3))
 
-			response, err := httpClient.Do(getLRPStatus)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(response.StatusCode).To(Equal(http.StatusOK))
-
-			var lrpInstanceStatus map[string][]cc_messages.LRPInstance
-			err = json.NewDecoder(response.Body).Decode(&lrpInstanceStatus)
-			Expect(err).NotTo(HaveOccurred())
-
-			Expect(lrpInstanceStatus).To(HaveLen(2))
-			for guid, instances := range lrpInstanceStatus {
-				pg, err := helpers.NewProcessGuid(guid)
-				Expect(err).To(BeNil())
-				podsList, err := k8sClient.Pods(defaultNamespace).List(api.ListOptions{
-					LabelSelector: labels.Set{"cloudfoundry.org/process-guid": pg.ShortenedGuid()}.AsSelector(),
+					// wait till containers all reach running
+					time.Sleep(30 * time.Second)
 				})
-				Expect(err).To(BeNil())
 
-				items := podsList.Items
-				items = tpshelpers.SortPods(items)
+				It("reports the state of the given process guid's instances", func() {
+					getLRPs, err := requestGenerator.CreateRequest(
+						tps.LRPStatus,
+						rata.Params{"guid": processGuid1.String()},
+						nil,
+					)
+					Expect(err).NotTo(HaveOccurred())
+					response, err := httpClient.Do(getLRPs)
+					Expect(err).NotTo(HaveOccurred())
+					//time.Sleep(time.Second * 120)
+					var lrpInstances []cc_messages.LRPInstance
+					err = json.NewDecoder(response.Body).Decode(&lrpInstances)
+					Expect(err).NotTo(HaveOccurred())
+
+					podsList, err := k8sClient.Pods(defaultNamespace).List(api.ListOptions{
+						LabelSelector: labels.Set{"cloudfoundry.org/process-guid": processGuid1.ShortenedGuid()}.AsSelector(),
+					})
+
+					items := podsList.Items
+					items = tpshelpers.SortPods(items)
+					Expect(len(items)).To(Equal(3))
+
+					for _, pod := range items {
+						containerStatuses := pod.Status.ContainerStatuses
+						Eventually(containerStatuses).ShouldNot(BeNil())
+						Eventually(containerStatuses[0].State.Running).ShouldNot(BeNil())
+					}
+
+					Expect(lrpInstances).To(HaveLen(3))
+
+					for i, _ := range lrpInstances {
+						Expect(lrpInstances[i]).NotTo(BeZero())
+						lrpInstances[i].Since = 0
+
+						Eventually(lrpInstances[i]).ShouldNot(BeZero())
+						lrpInstances[i].Uptime = 0
+					}
+
+					Expect(lrpInstances).To(ContainElement(cc_messages.LRPInstance{
+						ProcessGuid:  processGuid1.String(),
+						InstanceGuid: string(items[0].ObjectMeta.UID),
+						Index:        0,
+						State:        cc_messages.LRPInstanceStateRunning,
+					}))
+
+					Expect(lrpInstances).To(ContainElement(cc_messages.LRPInstance{
+						ProcessGuid:  processGuid1.String(),
+						InstanceGuid: string(items[1].ObjectMeta.UID),
+						Index:        1,
+						State:        cc_messages.LRPInstanceStateRunning,
+					}))
+
+					Expect(lrpInstances).To(ContainElement(cc_messages.LRPInstance{
+						ProcessGuid:  processGuid1.String(),
+						InstanceGuid: string(items[2].ObjectMeta.UID),
+						Index:        2,
+						State:        cc_messages.LRPInstanceStateRunning,
+					}))
+				})
+			})
+		})
 
-				Expect(len(items)).To(Equal(3))
+		Describe("get actual lrp for all guids when kubernetes is running", func() {
+			JustBeforeEach(func() {
+				newRC1 := generateReplicationController(processGuid2)
+				_, err = k8sClient.ReplicationControllers(newRC1.ObjectMeta.Namespace).Create(newRC1)
+				Expect(err).NotTo(HaveOccurred())
+				newRC2 := generateReplicationController(processGuid3)
+				_, err = k8sClient.ReplicationControllers(newRC2.ObjectMeta.Namespace).Create(newRC2)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(replicationControllers(k8sClient, processGuid2.AppGuid.String()), 1*time.Minute).Should(HaveLen(1))
+				Eventually(replicationControllers(k8sClient, processGuid3.AppGuid.String()), 1*time.Minute).Should(HaveLen(1))
+				Eventually(pods(k8sClient, processGuid2.AppGuid.String()), 1*time.Minute).Should(HaveLen(3))
+				Eventually(pods(k8sClient, processGuid3.AppGuid.String()), 1*time.Minute).Should(HaveLen(3))
+				// wait till containers all reach running
+				time.Sleep(60 * time.Second)
+			})
 
-				for i, _ := range instances {
-					Expect(instances[i]).NotTo(BeZero())
-					instances[i].Since = 0
+			It("reports the status for all the process guids supplied", func() {
+				getLRPStatus, err := requestGenerator.CreateRequest(
+					tps.BulkLRPStatus,
+					nil,
+					nil,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				getLRPStatus.Header.Add("Authorization", "I can do this.")
 
-					Eventually(instances[i]).ShouldNot(BeZero())
-					instances[i].Uptime = 0
-				}
+				query := getLRPStatus.URL.Query()
+				query.Set("guids", processGuid2.String()+","+processGuid3.String())
+				getLRPStatus.URL.RawQuery = query.Encode()
 
-				Expect(instances).To(ContainElement(cc_messages.LRPInstance{
-					ProcessGuid:  guid,
-					InstanceGuid: string(items[0].ObjectMeta.UID),
-					Index:        0,
-					State:        cc_messages.LRPInstanceStateRunning,
-				}))
+				response, err := httpClient.Do(getLRPStatus)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
 
-				Expect(instances).To(ContainElement(cc_messages.LRPInstance{
-					ProcessGuid:  guid,
-					InstanceGuid: string(items[1].ObjectMeta.UID),
-					Index:        1,
-					//	NetInfo:      netInfo,
-					State: cc_messages.LRPInstanceStateRunning,
-				}))
+				var lrpInstanceStatus map[string][]cc_messages.LRPInstance
+				err = json.NewDecoder(response.Body).Decode(&lrpInstanceStatus)
+				Expect(err).NotTo(HaveOccurred())
 
-				Expect(instances).To(ContainElement(cc_messages.LRPInstance{
-					ProcessGuid:  guid,
-					InstanceGuid: string(items[2].ObjectMeta.UID),
-					Index:        2,
-					State:        cc_messages.LRPInstanceStateRunning,
-				}))
-			}
+				Expect(lrpInstanceStatus).To(HaveLen(2))
+				for guid, instances := range lrpInstanceStatus {
+					pg, err := helpers.NewProcessGuid(guid)
+					Expect(err).To(BeNil())
+					podsList, err := k8sClient.Pods(defaultNamespace).List(api.ListOptions{
+						LabelSelector: labels.Set{"cloudfoundry.org/process-guid": pg.ShortenedGuid()}.AsSelector(),
+					})
+					Expect(err).To(BeNil())
+
+					items := podsList.Items
+					items = tpshelpers.SortPods(items)
+
+					Expect(len(items)).To(Equal(3))
+
+					for i, _ := range instances {
+						Expect(instances[i]).NotTo(BeZero())
+						instances[i].Since = 0
+
+						Eventually(instances[i]).ShouldNot(BeZero())
+						instances[i].Uptime = 0
+					}
+
+					Expect(instances).To(ContainElement(cc_messages.LRPInstance{
+						ProcessGuid:  guid,
+						InstanceGuid: string(items[0].ObjectMeta.UID),
+						Index:        0,
+						State:        cc_messages.LRPInstanceStateRunning,
+					}))
+
+					Expect(instances).To(ContainElement(cc_messages.LRPInstance{
+						ProcessGuid:  guid,
+						InstanceGuid: string(items[1].ObjectMeta.UID),
+						Index:        1,
+						//	NetInfo:      netInfo,
+						State: cc_messages.LRPInstanceStateRunning,
+					}))
+
+					Expect(instances).To(ContainElement(cc_messages.LRPInstance{
+						ProcessGuid:  guid,
+						InstanceGuid: string(items[2].ObjectMeta.UID),
+						Index:        2,
+						State:        cc_messages.LRPInstanceStateRunning,
+					}))
+				}
+			})
 		})
 	})
 })
@@ -0,0 +1,135 @@
+package main_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps/cmd/tpsrunner"
+)
+
+var _ = Describe("TPS-Watcher configuration", func() {
+	var configPath string
+
+	writeConfig := func(contents string) {
+		Expect(ioutil.WriteFile(configPath, []byte(contents), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		file, err := ioutil.TempFile("", "watcher-config")
+		Expect(err).NotTo(HaveOccurred())
+		file.Close()
+		configPath = file.Name()
+	})
+
+	AfterEach(func() {
+		os.Remove(configPath)
+	})
+
+	Describe("-validate-config", func() {
+		validate := func() *gexec.Session {
+			command := exec.Command(string(watcherPath), "-validate-config", "-config", configPath)
+			session, err := gexec.Start(command, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			return session
+		}
+
+		It("exits 0 for valid settings", func() {
+			writeConfig(`{"bbsAddress": "http://bbs:8889", "ccBaseURL": "http://cc", "eventHandlingWorkers": 10}`)
+
+			session := validate()
+			Eventually(session).Should(gexec.Exit(0))
+			Expect(session.Out).To(gbytes.Say("config is valid"))
+		})
+
+		It("exits 1 for settings that are not flags", func() {
+			writeConfig(`{"eventWorkers": 10}`)
+
+			session := validate()
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("unknown setting eventWorkers"))
+		})
+
+		It("exits 1 for invalid values", func() {
+			writeConfig(`{"eventHandlingWorkers": 0}`)
+
+			session := validate()
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say("eventHandlingWorkers must be positive"))
+		})
+
+		It("exits 1 for an unknown overflow policy", func() {
+			writeConfig(`{"bbsAddress": "http://bbs:8889", "crashQueueOverflow": "spill"}`)
+
+			session := validate()
+			Eventually(session).Should(gexec.Exit(1))
+			Expect(session.Err).To(gbytes.Say(`invalid crashQueueOverflow: unknown overflow policy "spill"`))
+		})
+	})
+
+	Describe("reloading on SIGHUP", func() {
+		var (
+			bbs *tpsrunner.FakeBBS
+			cc  *tpsrunner.FakeCC
+		)
+
+		emitCrash := func(index int32) {
+			lrpKey := models.NewActualLRPKey("some-process-guid", index, cc_messages.AppLRPDomain)
+			instanceKey := models.NewActualLRPInstanceKey("some-instance-guid", "cell-id")
+			actualLRP := *models.NewRunningActualLRP(lrpKey, instanceKey, models.NewActualLRPNetInfo("1.2.3.4"), 0)
+			actualLRP.State = models.ActualLRPStateCrashed
+			bbs.Emit(models.NewActualLRPCrashedEvent(&actualLRP))
+		}
+
+		BeforeEach(func() {
+			bbs = tpsrunner.NewFakeBBS()
+			cc = tpsrunner.NewFakeCC()
+			writeConfig(`{"ccUsername": "old-user", "ccPassword": "old-password"}`)
+
+			runner = tpsrunner.NewWatcher(
+				string(watcherPath),
+				bbs.URL(),
+				cc.URL(),
+				consul.URL(),
+				"-config", configPath,
+			)
+
+			watcher = ginkgomon.Invoke(runner)
+			Eventually(bbs.SubscriptionCount, 5*time.Second).Should(Equal(1))
+		})
+
+		AfterEach(func() {
+			watcher.Signal(os.Kill)
+			Eventually(watcher.Wait()).Should(Receive())
+			watcher = nil
+
+			bbs.Close()
+			cc.Close()
+		})
+
+		It("posts crashes with the reloaded CC credentials", func() {
+			emitCrash(1)
+			Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(1))
+			Expect(cc.CrashedApps()[0].Username).To(Equal("old-user"))
+
+			writeConfig(`{"ccUsername": "new-user", "ccPassword": "new-password"}`)
+			watcher.Signal(syscall.SIGHUP)
+			Eventually(runner).Should(gbytes.Say("config-reloader.reloaded"))
+
+			emitCrash(2)
+			Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(2))
+			Expect(cc.CrashedApps()[1].Username).To(Equal("new-user"))
+			Expect(cc.CrashedApps()[1].Password).To(Equal("new-password"))
+		})
+	})
+})
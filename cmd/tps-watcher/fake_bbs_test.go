@@ -0,0 +1,107 @@
+package main_test
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps/cmd/tpsrunner"
+)
+
+var _ = Describe("TPS-Watcher against the fake BBS and CC", func() {
+	var (
+		bbs *tpsrunner.FakeBBS
+		cc  *tpsrunner.FakeCC
+	)
+
+	crashedEvent := func(processGuid string, index int32, domain string) models.Event {
+		lrpKey := models.NewActualLRPKey(processGuid, index, domain)
+		instanceKey := models.NewActualLRPInstanceKey("some-instance-guid", "cell-id")
+		netInfo := models.NewActualLRPNetInfo("1.2.3.4", models.NewPortMapping(65100, 8080))
+		actualLRP := *models.NewRunningActualLRP(lrpKey, instanceKey, netInfo, 0)
+		actualLRP.State = models.ActualLRPStateCrashed
+		actualLRP.Since = int64(1)
+		actualLRP.CrashCount = 2
+		actualLRP.CrashReason = "out of memory"
+
+		return models.NewActualLRPCrashedEvent(&actualLRP)
+	}
+
+	BeforeEach(func() {
+		bbs = tpsrunner.NewFakeBBS()
+		cc = tpsrunner.NewFakeCC()
+
+		runner = tpsrunner.NewWatcher(
+			string(watcherPath),
+			bbs.URL(),
+			cc.URL(),
+			consul.URL(),
+		)
+
+		watcher = ginkgomon.Invoke(runner)
+		Eventually(bbs.SubscriptionCount, 5*time.Second).Should(Equal(1))
+	})
+
+	AfterEach(func() {
+		watcher.Signal(os.Kill)
+		Eventually(watcher.Wait()).Should(Receive())
+		watcher = nil
+
+		bbs.Close()
+		cc.Close()
+	})
+
+	It("POSTs the crashes of apps to the CC", func() {
+		bbs.Emit(crashedEvent("some-process-guid", 1, cc_messages.AppLRPDomain))
+
+		Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(1))
+
+		crashed := cc.CrashedApps()[0]
+		Expect(crashed.ProcessGuid).To(Equal("some-process-guid"))
+		Expect(crashed.Request).To(Equal(cc_messages.AppCrashedRequest{
+			Instance:        "some-instance-guid",
+			Index:           1,
+			Reason:          "CRASHED",
+			ExitDescription: "out of memory",
+			CrashCount:      2,
+			CrashTimestamp:  1,
+		}))
+	})
+
+	It("ignores the crashes of LRPs outside the app domain", func() {
+		bbs.Emit(crashedEvent("some-process-guid", 1, "some-other-domain"))
+
+		Consistently(cc.CrashedApps).Should(BeEmpty())
+	})
+
+	Context("when the CC fails to record a crash", func() {
+		BeforeEach(func() {
+			cc.SetStatusCode(http.StatusInternalServerError)
+		})
+
+		It("keeps posting later crashes", func() {
+			bbs.Emit(crashedEvent("some-process-guid", 1, cc_messages.AppLRPDomain))
+			Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(1))
+
+			cc.SetStatusCode(http.StatusOK)
+			bbs.Emit(crashedEvent("some-process-guid", 2, cc_messages.AppLRPDomain))
+			Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(2))
+		})
+	})
+
+	Context("when the BBS closes the event stream", func() {
+		It("subscribes again and keeps posting crashes", func() {
+			bbs.CloseSubscriptions()
+			Eventually(bbs.SubscriptionCount, 5*time.Second).Should(Equal(1))
+
+			bbs.Emit(crashedEvent("some-process-guid", 1, cc_messages.AppLRPDomain))
+			Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(1))
+		})
+	})
+})
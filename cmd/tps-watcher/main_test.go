@@ -1,9 +1,6 @@
 package main_test
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
 	"os"
 	"time"
 
@@ -14,15 +11,20 @@ import (
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 
-	"github.com/cloudfoundry-incubator/bbs/events"
 	"github.com/cloudfoundry-incubator/bbs/models"
 	"github.com/cloudfoundry-incubator/locket"
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps/cmd/tpsrunner"
 )
 
 const watcherLockName = "tps_watcher_lock"
 
 var _ = Describe("TPS", func() {
+	var (
+		bbs *tpsrunner.FakeBBS
+		cc  *tpsrunner.FakeCC
+	)
+
 	startWatcher := func(check bool) (ifrit.Process, *ginkgomon.Runner) {
 		if !check {
 			runner.StartCheck = ""
@@ -31,118 +33,71 @@ var _ = Describe("TPS", func() {
 		return ginkgomon.Invoke(runner), runner
 	}
 
-	var (
-		domain string
-	)
-
 	BeforeEach(func() {
-		domain = cc_messages.AppLRPDomain
+		bbs = tpsrunner.NewFakeBBS()
+		cc = tpsrunner.NewFakeCC()
+
+		runner = tpsrunner.NewWatcher(
+			string(watcherPath),
+			bbs.URL(),
+			cc.URL(),
+			consul.URL(),
+		)
 	})
 
 	AfterEach(func() {
 		if watcher != nil {
 			watcher.Signal(os.Kill)
 			Eventually(watcher.Wait()).Should(Receive())
+			watcher = nil
 		}
+
+		bbs.Close()
+		cc.Close()
 	})
 
 	Describe("Crashed Apps", func() {
-		var (
-			ready chan struct{}
-		)
-
 		BeforeEach(func() {
-			ready = make(chan struct{})
-			fakeCC.RouteToHandler("POST", "/internal/apps/some-process-guid/crashed", func(res http.ResponseWriter, req *http.Request) {
-				var appCrashed cc_messages.AppCrashedRequest
-
-				bytes, err := ioutil.ReadAll(req.Body)
-				Expect(err).NotTo(HaveOccurred())
-				req.Body.Close()
-
-				err = json.Unmarshal(bytes, &appCrashed)
-				Expect(err).NotTo(HaveOccurred())
-
-				Expect(appCrashed.CrashTimestamp).NotTo(BeZero())
-				appCrashed.CrashTimestamp = 0
-
-				Expect(appCrashed).To(Equal(cc_messages.AppCrashedRequest{
-					Instance:        "some-instance-guid-1",
-					Index:           1,
-					Reason:          "CRASHED",
-					ExitDescription: "out of memory",
-					CrashCount:      1,
-				}))
-
-				close(ready)
-			})
+			watcher, _ = startWatcher(true)
+			Eventually(bbs.SubscriptionCount, 5*time.Second).Should(Equal(1))
 
-			lrpKey := models.NewActualLRPKey("some-process-guid", 1, domain)
+			lrpKey := models.NewActualLRPKey("some-process-guid", 1, cc_messages.AppLRPDomain)
 			instanceKey := models.NewActualLRPInstanceKey("some-instance-guid-1", "cell-id")
 			netInfo := models.NewActualLRPNetInfo("1.2.3.4", models.NewPortMapping(65100, 8080))
-			beforeActualLRP := *models.NewRunningActualLRP(lrpKey, instanceKey, netInfo, 0)
-			afterActualLRP := beforeActualLRP
-			afterActualLRP.State = models.ActualLRPStateCrashed
-			afterActualLRP.Since = int64(1)
-			afterActualLRP.CrashCount = 1
-			afterActualLRP.CrashReason = "out of memory"
+			actualLRP := *models.NewRunningActualLRP(lrpKey, instanceKey, netInfo, 0)
+			actualLRP.State = models.ActualLRPStateCrashed
+			actualLRP.Since = int64(1)
+			actualLRP.CrashCount = 1
+			actualLRP.CrashReason = "out of memory"
 
-			fakeBBS.RouteToHandler("GET", "/v1/events",
-				func(w http.ResponseWriter, _ *http.Request) {
-					w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
-					w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate")
-					w.Header().Add("Connection", "keep-alive")
-
-					w.WriteHeader(http.StatusOK)
-
-					flusher := w.(http.Flusher)
-					flusher.Flush()
-					closeNotifier := w.(http.CloseNotifier).CloseNotify()
-					event := models.NewActualLRPCrashedEvent(&afterActualLRP)
-
-					sseEvent, err := events.NewEventFromModelEvent(0, event)
-					Expect(err).NotTo(HaveOccurred())
-
-					err = sseEvent.Write(w)
-					Expect(err).NotTo(HaveOccurred())
-
-					flusher.Flush()
-
-					<-closeNotifier
-				},
-			)
-		})
-
-		JustBeforeEach(func() {
-			watcher, _ = startWatcher(true)
+			bbs.Emit(models.NewActualLRPCrashedEvent(&actualLRP))
 		})
 
 		It("POSTs to the CC that the application has crashed", func() {
-			Eventually(ready, 5*time.Second).Should(BeClosed())
+			Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(1))
+
+			crashed := cc.CrashedApps()[0]
+			Expect(crashed.ProcessGuid).To(Equal("some-process-guid"))
+			Expect(crashed.Request.CrashTimestamp).NotTo(BeZero())
+			crashed.Request.CrashTimestamp = 0
+
+			Expect(crashed.Request).To(Equal(cc_messages.AppCrashedRequest{
+				Instance:        "some-instance-guid-1",
+				Index:           1,
+				Reason:          "CRASHED",
+				ExitDescription: "out of memory",
+				CrashCount:      1,
+			}))
 		})
 	})
 
 	Context("when the watcher loses the lock", func() {
 		BeforeEach(func() {
-			fakeBBS.RouteToHandler("GET", "/v1/events",
-				func(w http.ResponseWriter, _ *http.Request) {
-					w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
-					w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate")
-					w.Header().Add("Connection", "keep-alive")
-
-					w.WriteHeader(http.StatusOK)
-
-					closeNotifier := w.(http.CloseNotifier).CloseNotify()
-
-					<-closeNotifier
-				},
-			)
-
 			watcher, _ = startWatcher(true)
 		})
 
 		JustBeforeEach(func() {
-			consulRunner.Reset()
+			consul.Reset()
 		})
 
 		AfterEach(func() {
@@ -159,21 +114,10 @@ var _ = Describe("TPS", func() {
 		var competingWatcherProcess ifrit.Process
 
 		BeforeEach(func() {
-			fakeBBS.RouteToHandler("GET", "/v1/events",
-				func(w http.ResponseWriter, _ *http.Request) {
-					w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
-					w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate")
-					w.Header().Add("Connection", "keep-alive")
-
-					w.WriteHeader(http.StatusOK)
-
-					closeNotifier := w.(http.CloseNotifier).CloseNotify()
-
-					<-closeNotifier
-				},
-			)
+			consulClient, err := consul.NewClient()
+			Expect(err).NotTo(HaveOccurred())
 
-			competingWatcher := locket.NewLock(logger, consulRunner.NewClient(), locket.LockSchemaPath(watcherLockName), []byte("something-else"), clock.NewClock(), locket.RetryInterval, locket.LockTTL)
+			competingWatcher := locket.NewLock(logger, consulClient, locket.LockSchemaPath(watcherLockName), []byte("something-else"), clock.NewClock(), locket.RetryInterval, locket.LockTTL)
 			competingWatcherProcess = ifrit.Invoke(competingWatcher)
 		})
 
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/tps/cmd/tpsapp"
+)
+
+const usage = `usage: tps <command> [flags]
+
+commands:
+  listener   serve the tps API, as tps-listener does
+  watcher    post app crashes to the CC, as tps-watcher does
+  all        run both in one process; the watcher still only runs while
+             it holds the watcher lock, the listener serves regardless
+
+run tps <command> -h for the flags of a command
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	flagSet := flag.NewFlagSet("tps "+command, flag.ExitOnError)
+
+	switch command {
+	case "listener":
+		tpsapp.Run("tps-listener", "tps_listener", flagSet, args, tpsapp.NewListener(flagSet))
+	case "watcher":
+		tpsapp.Run("tps-watcher", "tps_watcher", flagSet, args, tpsapp.NewWatcher(flagSet))
+	case "all":
+		// the listener first, so that it serves while the watcher waits
+		// for the lock
+		tpsapp.Run("tps", "tps", flagSet, args, tpsapp.NewListener(flagSet), tpsapp.NewWatcher(flagSet))
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,43 @@
+package main_test
+
+import (
+	"github.com/cloudfoundry-incubator/tps/cmd/tpsrunner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+
+	"testing"
+)
+
+var (
+	consul *tpsrunner.FakeConsul
+
+	tpsPath string
+)
+
+func TestTPS(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TPS Suite")
+}
+
+var _ = SynchronizedBeforeSuite(func() []byte {
+	tps, err := gexec.Build("github.com/cloudfoundry-incubator/tps/cmd/tps", "-race")
+	Expect(err).NotTo(HaveOccurred())
+
+	return []byte(tps)
+}, func(payload []byte) {
+	tpsPath = string(payload)
+})
+
+var _ = BeforeEach(func() {
+	consul = tpsrunner.NewFakeConsul()
+})
+
+var _ = AfterEach(func() {
+	consul.Close()
+})
+
+var _ = SynchronizedAfterSuite(func() {
+}, func() {
+	gexec.CleanupBuildArtifacts()
+})
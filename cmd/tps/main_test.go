@@ -0,0 +1,144 @@
+package main_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps/cmd/tpsrunner"
+)
+
+var _ = Describe("tps", func() {
+	run := func(args ...string) *gexec.Session {
+		session, err := gexec.Start(exec.Command(tpsPath, args...), GinkgoWriter, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		return session
+	}
+
+	It("prints its usage without a command", func() {
+		session := run()
+		Eventually(session).Should(gexec.Exit(2))
+		Expect(session.Err).To(gbytes.Say("usage: tps <command>"))
+	})
+
+	It("rejects unknown commands", func() {
+		session := run("emitter")
+		Eventually(session).Should(gexec.Exit(2))
+		Expect(session.Err).To(gbytes.Say(`unknown command "emitter"`))
+	})
+
+	It("validates the settings of both components for all", func() {
+		session := run("all", "-validate-config", "-kubeCluster", "http://kube", "-eventHandlingWorkers", "0")
+		Eventually(session).Should(gexec.Exit(1))
+		Expect(session.Err).To(gbytes.Say("eventHandlingWorkers must be positive"))
+	})
+
+	Describe("all", func() {
+		var (
+			fakeKube              *tpsrunner.FakeKubernetes
+			fakeTrafficController *tpsrunner.FakeTrafficController
+			bbs                   *tpsrunner.FakeBBS
+			cc                    *tpsrunner.FakeCC
+
+			listenAddr string
+			process    ifrit.Process
+		)
+
+		statusCode := func(addr string) func() (int, error) {
+			return func() (int, error) {
+				response, err := http.Get(fmt.Sprintf("http://%s/v1/actual_lrps/some-process-guid", addr))
+				if err != nil {
+					return 0, err
+				}
+				response.Body.Close()
+				return response.StatusCode, nil
+			}
+		}
+
+		BeforeEach(func() {
+			fakeKube = tpsrunner.NewFakeKubernetes()
+			fakeTrafficController = tpsrunner.NewFakeTrafficController()
+			bbs = tpsrunner.NewFakeBBS()
+			cc = tpsrunner.NewFakeCC()
+
+			listenAddr = fmt.Sprintf("127.0.0.1:%d", 1600+GinkgoParallelNode())
+			process = ginkgomon.Invoke(tpsrunner.NewCombined(
+				tpsPath,
+				listenAddr,
+				fakeKube.URL(),
+				fakeTrafficController.URL(),
+				bbs.URL(),
+				cc.URL(),
+				consul.URL(),
+			))
+		})
+
+		AfterEach(func() {
+			ginkgomon.Kill(process)
+
+			fakeKube.Close()
+			fakeTrafficController.Close()
+			bbs.Close()
+			cc.Close()
+		})
+
+		It("serves the tps API", func() {
+			Eventually(statusCode(listenAddr)).Should(Equal(http.StatusBadRequest))
+		})
+
+		It("posts crashes to the CC", func() {
+			Eventually(bbs.SubscriptionCount, 5*time.Second).Should(Equal(1))
+
+			lrpKey := models.NewActualLRPKey("some-process-guid", 1, cc_messages.AppLRPDomain)
+			instanceKey := models.NewActualLRPInstanceKey("some-instance-guid", "cell-id")
+			actualLRP := *models.NewRunningActualLRP(lrpKey, instanceKey, models.NewActualLRPNetInfo("1.2.3.4"), 0)
+			actualLRP.State = models.ActualLRPStateCrashed
+			bbs.Emit(models.NewActualLRPCrashedEvent(&actualLRP))
+
+			Eventually(cc.CrashedApps, 5*time.Second).Should(HaveLen(1))
+		})
+
+		Context("when another process holds the watcher lock", func() {
+			var (
+				standbyAddr string
+				standby     ifrit.Process
+			)
+
+			BeforeEach(func() {
+				Eventually(bbs.SubscriptionCount, 5*time.Second).Should(Equal(1))
+
+				standbyAddr = fmt.Sprintf("127.0.0.1:%d", 1650+GinkgoParallelNode())
+				standby = ginkgomon.Invoke(tpsrunner.NewCombinedStandby(
+					tpsPath,
+					standbyAddr,
+					fakeKube.URL(),
+					fakeTrafficController.URL(),
+					bbs.URL(),
+					cc.URL(),
+					consul.URL(),
+				))
+			})
+
+			AfterEach(func() {
+				standby.Signal(os.Kill)
+				Eventually(standby.Wait()).Should(Receive())
+			})
+
+			It("serves the API but does not watch", func() {
+				Eventually(statusCode(standbyAddr)).Should(Equal(http.StatusBadRequest))
+				Consistently(bbs.SubscriptionCount).Should(Equal(1))
+			})
+		})
+	})
+})
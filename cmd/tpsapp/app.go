@@ -0,0 +1,214 @@
+package tpsapp
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/cf-debug-server"
+	"github.com/cloudfoundry-incubator/cf-lager"
+	"github.com/cloudfoundry-incubator/consuladapter"
+	"github.com/cloudfoundry-incubator/tps/config"
+	"github.com/cloudfoundry/dropsonde"
+	"github.com/pivotal-golang/lager"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
+	"github.com/tedsuo/ifrit/http_server"
+	"github.com/tedsuo/ifrit/sigmon"
+)
+
+// Component is a part of tps, the listener or the watcher, that a binary
+// runs. Components register their flags on the flag set they are built
+// with; the dropsonde, lager, debug server, consul, drain and config flags
+// are shared and registered by Run.
+type Component interface {
+	// checks the settings that would otherwise only fail while starting up
+	Validate() error
+
+	// the members that run the component, in the order they start
+	Members(logger lager.Logger, shared Shared) grouper.Members
+
+	// the settings a SIGHUP reloads; Reload is called on every SIGHUP with
+	// those that changed, if any
+	ReloadableSettings() []string
+	Reload(logger lager.Logger, changed []string)
+}
+
+// DebugComponent is a Component that serves more than the shared debug
+// server does. DebugHandlers is called after Members.
+type DebugComponent interface {
+	Component
+
+	// the handlers to serve on the debug server, by path
+	DebugHandlers() map[string]http.Handler
+}
+
+// Shared is what Run builds from the shared flags for the components
+type Shared struct {
+	ConsulClient consuladapter.Client
+
+	// how long a component waits for its in-flight work when stopping
+	DrainTimeout time.Duration
+}
+
+type flags struct {
+	dropsondePort *int
+	consulCluster *string
+	drainTimeout  *time.Duration
+	config        *config.Flags
+}
+
+func addFlags(flagSet *flag.FlagSet) flags {
+	cf_debug_server.AddFlags(flagSet)
+	cf_lager.AddFlags(flagSet)
+
+	return flags{
+		dropsondePort: flagSet.Int(
+			"dropsondePort",
+			3457,
+			"port the local metron agent is listening on",
+		),
+		consulCluster: flagSet.String(
+			"consulCluster",
+			"",
+			"Consul Agent URL",
+		),
+		drainTimeout: flagSet.Duration(
+			"drainTimeout",
+			10*time.Second,
+			"how long to wait for in-flight requests and crash posts when stopping",
+		),
+		config: config.AddFlags(flagSet),
+	}
+}
+
+// Run parses args, then runs the members of the components in one ordered
+// group until it is signalled, and exits. name is the lager component and
+// origin the dropsonde origin of the process.
+func Run(name, origin string, flagSet *flag.FlagSet, args []string, components ...Component) {
+	flags := addFlags(flagSet)
+	flagSet.Parse(args)
+
+	settings := config.New(flagSet, flags.config.Path())
+	err := settings.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	validate := func() error {
+		if _, err := config.FlagLogLevel(flagSet); err != nil {
+			return err
+		}
+		for _, component := range components {
+			if err := component.Validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if flags.config.Validate() {
+		err := validate()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("config is valid")
+		os.Exit(0)
+	}
+
+	logger, reconfigurableSink := cf_lager.New(name)
+	initializeDropsonde(logger, *flags.dropsondePort, origin)
+
+	consulClient, err := consuladapter.NewClientFromUrl(*flags.consulCluster)
+	if err != nil {
+		logger.Fatal("new-client-failed", err)
+	}
+
+	shared := Shared{
+		ConsulClient: consulClient,
+		DrainTimeout: *flags.drainTimeout,
+	}
+
+	reloadable := []string{"logLevel"}
+	members := grouper.Members{}
+	for _, component := range components {
+		reloadable = append(reloadable, component.ReloadableSettings()...)
+		members = append(members, component.Members(logger, shared)...)
+	}
+
+	reloader := config.NewReloader(settings, reloadable, validate, func(changed []string) {
+		level, err := config.FlagLogLevel(flagSet)
+		if err != nil {
+			logger.Error("invalid-log-level", err)
+		} else {
+			reconfigurableSink.SetMinLevel(level)
+		}
+
+		for _, component := range components {
+			component.Reload(logger, changed)
+		}
+	}, logger)
+
+	// first, so that a SIGHUP never finds the process without a handler
+	members = append(grouper.Members{
+		{"config-reloader", reloader},
+	}, members...)
+
+	if dbgAddr := cf_debug_server.DebugAddress(flagSet); dbgAddr != "" {
+		members = append(grouper.Members{
+			{"debug-server", http_server.New(dbgAddr, debugHandler(reconfigurableSink, components))},
+		}, members...)
+	}
+
+	group := grouper.NewOrdered(os.Interrupt, members)
+
+	monitor := ifrit.Invoke(sigmon.New(group))
+
+	logger.Info("started")
+
+	err = <-monitor.Wait()
+	if err != nil {
+		logger.Error("exited-with-failure", err)
+		os.Exit(1)
+	}
+
+	logger.Info("exited")
+}
+
+// the shared debug handler, with the handlers of the components added to it
+func debugHandler(sink *lager.ReconfigurableSink, components []Component) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", cf_debug_server.Handler(sink))
+	for _, component := range components {
+		if debug, ok := component.(DebugComponent); ok {
+			for path, handler := range debug.DebugHandlers() {
+				mux.Handle(path, handler)
+			}
+		}
+	}
+	return mux
+}
+
+func initializeDropsonde(logger lager.Logger, port int, origin string) {
+	dropsondeDestination := fmt.Sprint("localhost:", port)
+	err := dropsonde.Initialize(dropsondeDestination, origin)
+	if err != nil {
+		logger.Error("failed to initialize dropsonde: %v", err)
+	}
+}
+
+// changed reports whether any of names is among the changed settings
+func changed(settings []string, names ...string) bool {
+	for _, setting := range settings {
+		for _, name := range names {
+			if setting == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+package tpsapp
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/consuladapter"
+	"github.com/cloudfoundry-incubator/locket"
+	"github.com/cloudfoundry-incubator/tps/auth"
+	"github.com/cloudfoundry-incubator/tps/handler"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/kubeclient"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/cloudfoundry-incubator/tps/server"
+	"github.com/cloudfoundry-incubator/tps/tc_client"
+	"github.com/cloudfoundry-incubator/tps/topology"
+	"github.com/hashicorp/consul/api"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
+
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3"
+)
+
+// Listener serves the tps API from the pods of one or more kubernetes
+// clusters and registers it with consul
+type Listener struct {
+	listenAddr              *string
+	trafficControllerURL    *string
+	ccAPIURL                *string
+	skipSSLVerification     *bool
+	maxInFlightRequests     *int
+	kubeFlags               *kubeclient.Flags
+	kubeClusters            *string
+	bulkLRPStatusWorkers    *int
+	kubeRequestTimeout      *time.Duration
+	containerMetricsTimeout *time.Duration
+	ignoreReadiness         *bool
+	terminatingInstances    *string
+	reportZones             *bool
+	nodeResyncInterval      *time.Duration
+	cachePods               *bool
+	podResyncInterval       *time.Duration
+
+	// built by Members and rebuilt into a new handler on reload
+	noaaClient *tc_client.TcClient
+	authorizer auth.Authorizer
+	nodes      topology.NodeListers
+	source     podsource.PodSource
+	apiHandler *handler.SwappableHandler
+	inFlight   *handler.InFlightLimit
+}
+
+func NewListener(flagSet *flag.FlagSet) *Listener {
+	return &Listener{
+		listenAddr: flagSet.String(
+			"listenAddr",
+			"0.0.0.0:1518", // p and s's offset in the alphabet, do not change
+			"listening address of api server",
+		),
+		trafficControllerURL: flagSet.String(
+			"trafficControllerURL",
+			"",
+			"URL of TrafficController",
+		),
+		ccAPIURL: flagSet.String(
+			"ccAPIURL",
+			"",
+			"URL of the Cloud Controller API that checks the tokens of requests restarting instances or listing every app; without it those requests are forbidden",
+		),
+		skipSSLVerification: flagSet.Bool(
+			"skipSSLVerification",
+			true,
+			"Skip SSL verification",
+		),
+		maxInFlightRequests: flagSet.Int(
+			"maxInFlightRequests",
+			200,
+			"number of requests to handle at a time; any more will receive 503",
+		),
+		kubeFlags: kubeclient.AddFlags(flagSet),
+		kubeClusters: flagSet.String(
+			"kubeClusters",
+			"",
+			"path to a JSON file naming several kubernetes clusters to serve instances from, with their credentials and which clusters each app is in; replaces the -kubeCluster flags",
+		),
+		bulkLRPStatusWorkers: flagSet.Int(
+			"bulkLRPStatusWorkers",
+			15,
+			"Max concurrency for fetching bulk lrps",
+		),
+		kubeRequestTimeout: flagSet.Duration(
+			"kubeRequestTimeout",
+			10*time.Second,
+			"time allowed for kubernetes API calls made while serving a request; exceeding it cancels the calls and responds with 504",
+		),
+		containerMetricsTimeout: flagSet.Duration(
+			"containerMetricsTimeout",
+			5*time.Second,
+			"time allowed for fetching container metrics; the request is cancelled and instances are reported without stats when it is exceeded",
+		),
+		ignoreReadiness: flagSet.Bool(
+			"ignoreReadiness",
+			false,
+			"report instances whose application container is running as RUNNING even while their readiness probe fails",
+		),
+		terminatingInstances: flagSet.String(
+			"terminatingInstances",
+			lrpstatus.TerminatingInstancesOmit,
+			"how to report pods that are being deleted: omit leaves them out, report lists them as DOWN with a stopping detail",
+		),
+		reportZones: flagSet.Bool(
+			"reportZones",
+			true,
+			"report the zone and region of each instance from the labels of its node; needs permission to list and watch nodes",
+		),
+		nodeResyncInterval: flagSet.Duration(
+			"nodeResyncInterval",
+			10*time.Minute,
+			"how often the cached nodes used to report instance zones are resynced",
+		),
+		cachePods: flagSet.Bool(
+			"cachePods",
+			false,
+			"serve pods, replication controllers and replica sets from a cache kept up to date by watching the cluster instead of listing them per request",
+		),
+		podResyncInterval: flagSet.Duration(
+			"podResyncInterval",
+			10*time.Minute,
+			"how often the cached pods, replication controllers and replica sets are resynced when -cachePods is set",
+		),
+	}
+}
+
+func (l *Listener) Validate() error {
+	if _, _, err := net.SplitHostPort(*l.listenAddr); err != nil {
+		return fmt.Errorf("invalid listenAddr: %s", err)
+	}
+
+	if *l.maxInFlightRequests <= 0 {
+		return errors.New("maxInFlightRequests must be positive")
+	}
+
+	if *l.bulkLRPStatusWorkers <= 0 {
+		return errors.New("bulkLRPStatusWorkers must be positive")
+	}
+
+	if err := l.stateConfig().Validate(); err != nil {
+		return err
+	}
+
+	if *l.kubeClusters != "" {
+		_, err := kubeclient.LoadFederation(*l.kubeClusters)
+		return err
+	}
+	return l.kubeFlags.Cluster().Validate()
+}
+
+func (l *Listener) Members(logger lager.Logger, shared Shared) grouper.Members {
+	l.noaaClient = tc_client.NewTcClient(*l.trafficControllerURL, *l.skipSSLVerification, *l.containerMetricsTimeout, logger)
+	l.authorizer = auth.NewDenyingAuthorizer()
+	if *l.ccAPIURL != "" {
+		l.authorizer = auth.NewCCAuthorizer(*l.ccAPIURL, *l.skipSSLVerification, *l.kubeRequestTimeout)
+	}
+	federation := l.federation(logger)
+
+	members := grouper.Members{}
+	clusters := []podsource.Cluster{}
+	nodes := topology.NodeListers{}
+	for _, cluster := range federation.Clusters {
+		// the caches watch with a clientset whose requests stay open, while
+		// the calls made serving requests time out with them
+		clientSet := initializeK8sClient(logger, cluster, 0)
+		requestClientSet := initializeK8sClient(logger, cluster, *l.kubeRequestTimeout)
+		suffix := ""
+		if cluster.Name != "" {
+			suffix = "-" + cluster.Name
+		}
+
+		if *l.reportZones {
+			nodeCache := topology.NewNodeCache(clientSet.Core(), *l.nodeResyncInterval, logger)
+			members = append(members, grouper.Members{
+				{"node-cache" + suffix, nodeCache},
+			}...)
+			nodes[cluster.Name] = nodeCache
+		}
+
+		var clusterSource podsource.PodSource = podsource.NewKubeSource(requestClientSet)
+		if *l.cachePods {
+			podCache := podsource.NewInformerSource(clientSet, requestClientSet, *l.podResyncInterval, logger)
+			members = append(members, grouper.Members{
+				{"pod-cache" + suffix, podCache},
+			}...)
+			clusterSource = podCache
+		}
+		clusters = append(clusters, podsource.Cluster{Name: cluster.Name, Source: clusterSource})
+	}
+
+	l.nodes = nodes
+	l.source = clusters[0].Source
+	if *l.kubeClusters != "" {
+		l.source = podsource.NewFederatedSource(clusters, federation.AppClusters)
+	}
+
+	l.inFlight = handler.NewInFlightLimit(*l.maxInFlightRequests)
+	l.apiHandler = handler.NewSwappableHandler(l.initializeHandler(logger))
+
+	registrationRunner := initializeRegistrationRunner(logger, shared.ConsulClient, *l.listenAddr, clock.NewClock())
+
+	// the registration runner stops first, so the router stops sending
+	// requests before the api drains the ones it has
+	return append(members, grouper.Members{
+		{"api", server.New(*l.listenAddr, l.apiHandler, shared.DrainTimeout, logger)},
+		{"registration-runner", registrationRunner},
+	}...)
+}
+
+func (l *Listener) ReloadableSettings() []string {
+	return []string{
+		"maxInFlightRequests",
+		"bulkLRPStatusWorkers",
+	}
+}
+
+// the in-flight limit is resized in place rather than rebuilt with the
+// handler, so requests the old handler is serving still count against it
+func (l *Listener) Reload(logger lager.Logger, changedSettings []string) {
+	if changed(changedSettings, "maxInFlightRequests") {
+		l.inFlight.SetMax(*l.maxInFlightRequests)
+	}
+	if changed(changedSettings, "bulkLRPStatusWorkers") {
+		l.apiHandler.Swap(l.initializeHandler(logger))
+	}
+}
+
+func (l *Listener) stateConfig() lrpstatus.StateConfig {
+	return lrpstatus.StateConfig{
+		IgnoreReadiness:      *l.ignoreReadiness,
+		TerminatingInstances: *l.terminatingInstances,
+	}
+}
+
+func (l *Listener) initializeHandler(logger lager.Logger) http.Handler {
+	stateConfig := l.stateConfig()
+	if err := stateConfig.Validate(); err != nil {
+		logger.Fatal("invalid-state-config", err)
+	}
+
+	apiHandler, err := handler.New(l.source, l.noaaClient, l.nodes, l.authorizer, l.inFlight, *l.bulkLRPStatusWorkers, *l.kubeRequestTimeout, *l.containerMetricsTimeout, stateConfig, logger)
+	if err != nil {
+		logger.Fatal("initialize-handler.failed", err)
+	}
+
+	return apiHandler
+}
+
+// the clusters named by -kubeClusters, or else the single unnamed cluster
+// given by the kubernetes flags
+func (l *Listener) federation(logger lager.Logger) kubeclient.Federation {
+	if *l.kubeClusters == "" {
+		return kubeclient.Federation{
+			Clusters: []kubeclient.Cluster{l.kubeFlags.Cluster()},
+		}
+	}
+
+	federation, err := kubeclient.LoadFederation(*l.kubeClusters)
+	if err != nil {
+		logger.Fatal("invalid-kube-clusters", err, lager.Data{"path": *l.kubeClusters})
+	}
+	for i := range federation.Clusters {
+		federation.Clusters[i] = l.kubeFlags.Defaults(federation.Clusters[i])
+	}
+	return federation
+}
+
+func initializeRegistrationRunner(logger lager.Logger, consulClient consuladapter.Client, listenAddress string, clock clock.Clock) ifrit.Runner {
+	_, portString, err := net.SplitHostPort(listenAddress)
+	if err != nil {
+		logger.Fatal("failed-invalid-listen-address", err)
+	}
+	portNum, err := net.LookupPort("tcp", portString)
+	if err != nil {
+		logger.Fatal("failed-invalid-listen-port", err)
+	}
+
+	registration := &api.AgentServiceRegistration{
+		Name: "tps",
+		Port: portNum,
+		Check: &api.AgentServiceCheck{
+			TTL: "3s",
+		},
+	}
+
+	return locket.NewRegistrationRunner(logger, registration, consulClient, locket.RetryInterval, clock)
+}
+
+func initializeK8sClient(logger lager.Logger, cluster kubeclient.Cluster, timeout time.Duration) clientset.Interface {
+	k8sClient, err := kubeclient.NewClientsetWithTimeout(cluster, timeout)
+	if err != nil {
+		logger.Fatal("Can't create Kubernetes Client", err, lager.Data{"cluster": cluster.Name, "address": cluster.API})
+	}
+
+	return k8sClient
+}
@@ -0,0 +1,290 @@
+package tpsapp
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cloudfoundry-incubator/bbs"
+	"github.com/cloudfoundry-incubator/consuladapter"
+	"github.com/cloudfoundry-incubator/locket"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/cc_client"
+	"github.com/cloudfoundry-incubator/tps/watcher"
+	"github.com/nu7hatch/gouuid"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+// Watcher posts the crashes of apps from the BBS event stream to the CC,
+// while it holds the watcher lock in consul
+type Watcher struct {
+	bbsAddress                *string
+	lockTTL                   *time.Duration
+	lockRetryInterval         *time.Duration
+	ccBaseURL                 *string
+	ccUsername                *string
+	ccPassword                *string
+	skipCertVerify            *bool
+	bbsCACert                 *string
+	bbsClientCert             *string
+	bbsClientKey              *string
+	bbsClientSessionCacheSize *int
+	bbsMaxIdleConnsPerHost    *int
+	eventHandlingWorkers      *int
+	crashQueueCapacity        *int
+	crashQueueOverflow        *string
+
+	// built by Members and updated on reload
+	watcher  *watcher.Watcher
+	ccClient *cc_client.SwappableClient
+	bbsCerts []byte
+}
+
+func NewWatcher(flagSet *flag.FlagSet) *Watcher {
+	return &Watcher{
+		bbsAddress: flagSet.String(
+			"bbsAddress",
+			"",
+			"Address to the BBS Server",
+		),
+		lockTTL: flagSet.Duration(
+			"lockTTL",
+			locket.LockTTL,
+			"TTL for service lock",
+		),
+		lockRetryInterval: flagSet.Duration(
+			"lockRetryInterval",
+			locket.RetryInterval,
+			"interval to wait before retrying a failed lock acquisition",
+		),
+		ccBaseURL: flagSet.String(
+			"ccBaseURL",
+			"",
+			"URI to acccess the Cloud Controller",
+		),
+		ccUsername: flagSet.String(
+			"ccUsername",
+			"",
+			"Basic auth username for CC internal API",
+		),
+		ccPassword: flagSet.String(
+			"ccPassword",
+			"",
+			"Basic auth password for CC internal API",
+		),
+		skipCertVerify: flagSet.Bool(
+			"skipCertVerify",
+			false,
+			"skip SSL certificate verification",
+		),
+		bbsCACert: flagSet.String(
+			"bbsCACert",
+			"",
+			"path to certificate authority cert used for mutually authenticated TLS BBS communication",
+		),
+		bbsClientCert: flagSet.String(
+			"bbsClientCert",
+			"",
+			"path to client cert used for mutually authenticated TLS BBS communication",
+		),
+		bbsClientKey: flagSet.String(
+			"bbsClientKey",
+			"",
+			"path to client key used for mutually authenticated TLS BBS communication",
+		),
+		bbsClientSessionCacheSize: flagSet.Int(
+			"bbsClientSessionCacheSize",
+			0,
+			"Capacity of the ClientSessionCache option on the TLS configuration. If zero, golang's default will be used",
+		),
+		bbsMaxIdleConnsPerHost: flagSet.Int(
+			"bbsMaxIdleConnsPerHost",
+			0,
+			"Controls the maximum number of idle (keep-alive) connctions per host. If zero, golang's default will be used",
+		),
+		eventHandlingWorkers: flagSet.Int(
+			"eventHandlingWorkers",
+			500,
+			"Max concurrency for handling lrp events",
+		),
+		crashQueueCapacity: flagSet.Int(
+			"crashQueueCapacity",
+			10000,
+			"how many crashes can wait to be posted before crashQueueOverflow applies, or 0 for no limit",
+		),
+		crashQueueOverflow: flagSet.String(
+			"crashQueueOverflow",
+			string(watcher.OverflowBlock),
+			"what to do with crashes when the queue is full: block reading events, drop-oldest, or merge with a waiting crash of the same process",
+		),
+	}
+}
+
+func (w *Watcher) Validate() error {
+	if *w.eventHandlingWorkers <= 0 {
+		return errors.New("eventHandlingWorkers must be positive")
+	}
+
+	if *w.crashQueueCapacity < 0 {
+		return errors.New("crashQueueCapacity must not be negative")
+	}
+
+	if _, err := watcher.ParseOverflowPolicy(*w.crashQueueOverflow); err != nil {
+		return fmt.Errorf("invalid crashQueueOverflow: %s", err)
+	}
+
+	if _, err := url.Parse(*w.ccBaseURL); err != nil {
+		return fmt.Errorf("invalid ccBaseURL: %s", err)
+	}
+
+	_, err := w.newBBSClient()
+	return err
+}
+
+// the watcher only starts once the lock is held, so the members before it
+// keep running while another process is the watcher. It stops before the
+// lock is released, so the next watcher finds the crashes it saved.
+func (w *Watcher) Members(logger lager.Logger, shared Shared) grouper.Members {
+	lockMaintainer := initializeLockMaintainer(logger, shared.ConsulClient, *w.lockRetryInterval, *w.lockTTL)
+
+	w.ccClient = cc_client.NewSwappableClient(w.newCcClient())
+
+	bbsClient, err := w.newBBSClient()
+	if err != nil {
+		logger.Fatal("Failed to configure BBS client", err)
+	}
+
+	w.watcher, err = watcher.NewWatcher(logger,
+		*w.eventHandlingWorkers,
+		*w.crashQueueCapacity,
+		watcher.OverflowPolicy(*w.crashQueueOverflow),
+		watcher.DefaultRetryPauseInterval,
+		shared.DrainTimeout,
+		watcher.NewConsulPendingStore(shared.ConsulClient.KV()),
+		bbsClient, w.ccClient)
+	if err != nil {
+		logger.Fatal("Failed to create watcher", err)
+	}
+
+	return grouper.Members{
+		{"lock-maintainer", lockMaintainer},
+		{"watcher", w.watcher},
+	}
+}
+
+func (w *Watcher) DebugHandlers() map[string]http.Handler {
+	return map[string]http.Handler{
+		watcher.QueueDebugPath: watcher.NewQueueHandler(w.watcher),
+	}
+}
+
+func (w *Watcher) ReloadableSettings() []string {
+	return []string{
+		"eventHandlingWorkers",
+		"ccUsername",
+		"ccPassword",
+		"skipCertVerify",
+		"bbsCACert",
+		"bbsClientCert",
+		"bbsClientKey",
+	}
+}
+
+// applies the reloaded settings to the parts of the watcher they configure.
+// It runs on every reload, so the BBS client is also rebuilt when its
+// certificate files were rotated.
+func (w *Watcher) Reload(logger lager.Logger, changedSettings []string) {
+	if changed(changedSettings, "eventHandlingWorkers") {
+		err := w.watcher.SetWorkers(*w.eventHandlingWorkers)
+		if err != nil {
+			logger.Error("failed-to-resize-work-pool", err)
+		}
+	}
+
+	if changed(changedSettings, "ccUsername", "ccPassword", "skipCertVerify") {
+		w.ccClient.Swap(w.newCcClient())
+	}
+
+	if changed(changedSettings, "bbsCACert", "bbsClientCert", "bbsClientKey") || w.bbsCertsRotated(logger) {
+		bbsClient, err := w.newBBSClient()
+		if err != nil {
+			logger.Error("failed-to-configure-bbs-client", err)
+			return
+		}
+		w.watcher.SetBBSClient(bbsClient)
+	}
+}
+
+func (w *Watcher) newCcClient() cc_client.CcClient {
+	return cc_client.NewCcClient(*w.ccBaseURL, *w.ccUsername, *w.ccPassword, *w.skipCertVerify)
+}
+
+func (w *Watcher) newBBSClient() (bbs.Client, error) {
+	bbsURL, err := url.Parse(*w.bbsAddress)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bbsAddress: %s", err)
+	}
+
+	if bbsURL.Scheme != "https" {
+		return bbs.NewClient(*w.bbsAddress), nil
+	}
+
+	certs, err := w.readBBSCerts()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := bbs.NewSecureClient(*w.bbsAddress, *w.bbsCACert, *w.bbsClientCert, *w.bbsClientKey, *w.bbsClientSessionCacheSize, *w.bbsMaxIdleConnsPerHost)
+	if err != nil {
+		return nil, err
+	}
+	w.bbsCerts = certs
+	return client, nil
+}
+
+// bbsCertsRotated reports whether the files the TLS settings name were
+// replaced since the BBS client was built. Certificates are rotated in
+// place, so the settings themselves do not change.
+func (w *Watcher) bbsCertsRotated(logger lager.Logger) bool {
+	if w.bbsCerts == nil {
+		return false
+	}
+
+	certs, err := w.readBBSCerts()
+	if err != nil {
+		logger.Error("failed-to-read-bbs-certs", err)
+		return false
+	}
+	return !bytes.Equal(certs, w.bbsCerts)
+}
+
+func (w *Watcher) readBBSCerts() ([]byte, error) {
+	var certs []byte
+	for _, path := range []string{*w.bbsCACert, *w.bbsClientCert, *w.bbsClientKey} {
+		contents, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, contents...)
+	}
+	return certs, nil
+}
+
+func initializeLockMaintainer(logger lager.Logger, consulClient consuladapter.Client, retryInterval, lockTTL time.Duration) ifrit.Runner {
+	serviceClient := tps.NewServiceClient(consulClient, clock.NewClock())
+
+	uuid, err := uuid.NewV4()
+	if err != nil {
+		logger.Fatal("Couldn't generate uuid", err)
+	}
+
+	return serviceClient.NewTPSWatcherLockRunner(logger, uuid.String(), retryInterval, lockTTL)
+}
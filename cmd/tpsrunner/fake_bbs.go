@@ -0,0 +1,133 @@
+package tpsrunner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/bbs"
+	"github.com/cloudfoundry-incubator/bbs/events"
+	"github.com/cloudfoundry-incubator/bbs/models"
+)
+
+// FakeBBS serves the BBS event stream. Events passed to Emit are sent to
+// every open subscription, dropping them for a subscriber more than 100
+// events behind; any other BBS request is answered with 404.
+type FakeBBS struct {
+	server *httptest.Server
+
+	lock          sync.Mutex
+	nextID        int
+	subscriptions map[chan models.Event]struct{}
+	done          chan struct{}
+}
+
+func NewFakeBBS() *FakeBBS {
+	fake := &FakeBBS{
+		subscriptions: map[chan models.Event]struct{}{},
+		done:          make(chan struct{}),
+	}
+
+	eventStreamRoute, _ := bbs.Routes.FindRouteByName(bbs.EventStreamRoute_r0)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(eventStreamRoute.Path, fake.serveEvents)
+	fake.server = httptest.NewServer(mux)
+
+	return fake
+}
+
+func (fake *FakeBBS) URL() string {
+	return fake.server.URL
+}
+
+func (fake *FakeBBS) Close() {
+	fake.CloseSubscriptions()
+	fake.server.Close()
+}
+
+// the number of clients currently subscribed to the event stream; tests
+// wait for it before emitting so that no event is missed
+func (fake *FakeBBS) SubscriptionCount() int {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	return len(fake.subscriptions)
+}
+
+func (fake *FakeBBS) Emit(event models.Event) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	for subscription := range fake.subscriptions {
+		select {
+		case subscription <- event:
+		default:
+		}
+	}
+}
+
+// CloseSubscriptions ends every open event stream, as the BBS does when it
+// loses its lock; subscribers are expected to subscribe again. The closed
+// streams stop counting as subscriptions straight away.
+func (fake *FakeBBS) CloseSubscriptions() {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	close(fake.done)
+	fake.done = make(chan struct{})
+	fake.subscriptions = map[chan models.Event]struct{}{}
+}
+
+func (fake *FakeBBS) serveEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	subscription := make(chan models.Event, 100)
+
+	fake.lock.Lock()
+	fake.subscriptions[subscription] = struct{}{}
+	done := fake.done
+	fake.lock.Unlock()
+
+	defer func() {
+		fake.lock.Lock()
+		delete(fake.subscriptions, subscription)
+		fake.lock.Unlock()
+	}()
+
+	w.Header().Add("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Add("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Add("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	flusher := w.(http.Flusher)
+	flusher.Flush()
+
+	closeNotifier := w.(http.CloseNotifier).CloseNotify()
+
+	for {
+		select {
+		case event := <-subscription:
+			fake.lock.Lock()
+			id := fake.nextID
+			fake.nextID++
+			fake.lock.Unlock()
+
+			sseEvent, err := events.NewEventFromModelEvent(id, event)
+			if err != nil {
+				continue
+			}
+			if err := sseEvent.Write(w); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-closeNotifier:
+			return
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package tpsrunner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+)
+
+const appCrashedPathPrefix = "/internal/apps/"
+
+// an app crashed request as posted by the watcher
+type CrashedApp struct {
+	ProcessGuid string
+	Username    string
+	Password    string
+	Request     cc_messages.AppCrashedRequest
+}
+
+// FakeCC records the app crashed requests posted to
+// /internal/apps/:guid/crashed. Any other request is answered with 404.
+type FakeCC struct {
+	server *httptest.Server
+
+	lock       sync.Mutex
+	crashed    []CrashedApp
+	statusCode int
+}
+
+func NewFakeCC() *FakeCC {
+	fake := &FakeCC{statusCode: http.StatusOK}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serveHTTP))
+	return fake
+}
+
+func (fake *FakeCC) URL() string {
+	return fake.server.URL
+}
+
+func (fake *FakeCC) Close() {
+	fake.server.Close()
+}
+
+// the requests received so far, in the order they arrived
+func (fake *FakeCC) CrashedApps() []CrashedApp {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	crashed := make([]CrashedApp, len(fake.crashed))
+	copy(crashed, fake.crashed)
+	return crashed
+}
+
+// SetStatusCode makes the fake answer later app crashed requests with the
+// given status; the requests are still recorded
+func (fake *FakeCC) SetStatusCode(statusCode int) {
+	fake.lock.Lock()
+	fake.statusCode = statusCode
+	fake.lock.Unlock()
+}
+
+func (fake *FakeCC) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || !strings.HasPrefix(r.URL.Path, appCrashedPathPrefix) || !strings.HasSuffix(r.URL.Path, "/crashed") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	guid := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, appCrashedPathPrefix), "/crashed")
+	if guid == "" || strings.Contains(guid, "/") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	var request cc_messages.AppCrashedRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	username, password, _ := r.BasicAuth()
+
+	fake.lock.Lock()
+	fake.crashed = append(fake.crashed, CrashedApp{
+		ProcessGuid: guid,
+		Username:    username,
+		Password:    password,
+		Request:     request,
+	})
+	statusCode := fake.statusCode
+	fake.lock.Unlock()
+
+	w.WriteHeader(statusCode)
+}
@@ -0,0 +1,521 @@
+package tpsrunner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/consuladapter"
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/nu7hatch/gouuid"
+)
+
+const (
+	kvPathPrefix         = "/v1/kv/"
+	checkUpdatePrefix    = "/v1/agent/check/"
+	deregisterPathPrefix = "/v1/agent/service/deregister/"
+	sessionPathPrefix    = "/v1/session/"
+
+	// how often sessions are checked for a missed renewal
+	sessionExpiryInterval = 100 * time.Millisecond
+)
+
+// FakeConsul serves the parts of the consul HTTP API the tps uses from
+// memory: sessions with their TTLs, the key/value store with locks and
+// blocking queries, and the agent's service registrations with their TTL
+// checks. It stands in for a consul cluster, so the tests do not need the
+// consul binary.
+type FakeConsul struct {
+	server *httptest.Server
+
+	lock     sync.Mutex
+	index    uint64
+	changed  chan struct{}
+	sessions map[string]*fakeSession
+	kv       map[string]*consulapi.KVPair
+	services map[string]*consulapi.AgentService
+	checks   map[string]*consulapi.AgentCheck
+	done     chan struct{}
+}
+
+type fakeSession struct {
+	entry   consulapi.SessionEntry
+	ttl     time.Duration
+	renewed time.Time
+}
+
+func NewFakeConsul() *FakeConsul {
+	fake := &FakeConsul{
+		index:    1,
+		changed:  make(chan struct{}),
+		sessions: map[string]*fakeSession{},
+		kv:       map[string]*consulapi.KVPair{},
+		services: map[string]*consulapi.AgentService{},
+		checks:   map[string]*consulapi.AgentCheck{},
+		done:     make(chan struct{}),
+	}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serveHTTP))
+	go fake.expireSessions()
+	return fake
+}
+
+func (fake *FakeConsul) URL() string {
+	return fake.server.URL
+}
+
+// NewClient returns a client of the fake, as the tps builds one from
+// -consulCluster
+func (fake *FakeConsul) NewClient() (consuladapter.Client, error) {
+	return consuladapter.NewClientFromUrl(fake.URL())
+}
+
+func (fake *FakeConsul) Close() {
+	close(fake.done)
+	fake.server.Close()
+}
+
+// Reset forgets every session, key and registration, as if the cluster was
+// replaced. Whoever held a lock loses it.
+func (fake *FakeConsul) Reset() {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	fake.sessions = map[string]*fakeSession{}
+	fake.kv = map[string]*consulapi.KVPair{}
+	fake.services = map[string]*consulapi.AgentService{}
+	fake.checks = map[string]*consulapi.AgentCheck{}
+	fake.bump()
+}
+
+func (fake *FakeConsul) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+
+	switch {
+	case strings.HasPrefix(path, kvPathPrefix):
+		fake.serveKV(w, r, strings.TrimPrefix(path, kvPathPrefix))
+	case strings.HasPrefix(path, sessionPathPrefix):
+		fake.serveSession(w, r, strings.TrimPrefix(path, sessionPathPrefix))
+	case path == "/v1/agent/service/register":
+		fake.registerService(w, r)
+	case strings.HasPrefix(path, deregisterPathPrefix):
+		fake.deregisterService(w, strings.TrimPrefix(path, deregisterPathPrefix))
+	case strings.HasPrefix(path, checkUpdatePrefix):
+		fake.updateCheck(w, r, strings.TrimPrefix(path, checkUpdatePrefix))
+	case path == "/v1/agent/services":
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+		writeConsulJSON(w, fake.services)
+	case path == "/v1/agent/checks":
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+		writeConsulJSON(w, fake.checks)
+	case path == "/v1/status/leader":
+		writeConsulJSON(w, "127.0.0.1:8300")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (fake *FakeConsul) serveKV(w http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+
+	switch r.Method {
+	case "GET":
+		fake.block(query)
+		fake.getKV(w, key, query)
+	case "PUT":
+		value, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fake.putKV(w, key, value, query)
+	case "DELETE":
+		fake.deleteKV(w, key, query)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// block holds up a blocking query, one given an index, until something
+// changes after that index or its wait runs out
+func (fake *FakeConsul) block(query url.Values) {
+	index, err := strconv.ParseUint(query.Get("index"), 10, 64)
+	if err != nil || index == 0 {
+		return
+	}
+
+	wait := 5 * time.Minute
+	if duration, err := time.ParseDuration(query.Get("wait")); err == nil {
+		wait = duration
+	}
+	timeout := time.After(wait)
+
+	for {
+		fake.lock.Lock()
+		current, changed := fake.index, fake.changed
+		fake.lock.Unlock()
+
+		if current > index {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timeout:
+			return
+		case <-fake.done:
+			return
+		}
+	}
+}
+
+func (fake *FakeConsul) getKV(w http.ResponseWriter, key string, query url.Values) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(fake.index, 10))
+	w.Header().Set("X-Consul-KnownLeader", "true")
+	w.Header().Set("X-Consul-LastContact", "0")
+
+	_, recurse := query["recurse"]
+	_, keysOnly := query["keys"]
+
+	pairs := consulapi.KVPairs{}
+	for _, pair := range fake.kv {
+		if pair.Key == key || ((recurse || keysOnly) && strings.HasPrefix(pair.Key, key)) {
+			copied := *pair
+			pairs = append(pairs, &copied)
+		}
+	}
+	sort.Sort(kvPairsByKey(pairs))
+
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if keysOnly {
+		keys := []string{}
+		for _, pair := range pairs {
+			keys = append(keys, pair.Key)
+		}
+		writeConsulJSON(w, keys)
+		return
+	}
+
+	writeConsulJSON(w, pairs)
+}
+
+// putKV stores the value, unless a check-and-set index, a lock acquisition
+// or a lock release the request asks for fails, and answers whether it did
+func (fake *FakeConsul) putKV(w http.ResponseWriter, key string, value []byte, query url.Values) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	flags, _ := strconv.ParseUint(query.Get("flags"), 10, 64)
+	existing := fake.kv[key]
+
+	if cas := query.Get("cas"); cas != "" {
+		index, err := strconv.ParseUint(cas, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid cas index", http.StatusBadRequest)
+			return
+		}
+		if (index == 0 && existing != nil) || (index != 0 && (existing == nil || existing.ModifyIndex != index)) {
+			writeConsulJSON(w, false)
+			return
+		}
+	}
+
+	pair := &consulapi.KVPair{Key: key}
+	if existing != nil {
+		copied := *existing
+		pair = &copied
+	}
+
+	if session := query.Get("acquire"); session != "" {
+		if _, found := fake.sessions[session]; !found {
+			http.Error(w, fmt.Sprintf("invalid session %q", session), http.StatusInternalServerError)
+			return
+		}
+		if pair.Session != "" && pair.Session != session {
+			writeConsulJSON(w, false)
+			return
+		}
+		if pair.Session != session {
+			pair.LockIndex++
+		}
+		pair.Session = session
+	}
+
+	if session := query.Get("release"); session != "" {
+		if pair.Session != session {
+			writeConsulJSON(w, false)
+			return
+		}
+		pair.Session = ""
+	}
+
+	fake.bump()
+	pair.Value = value
+	pair.Flags = flags
+	pair.ModifyIndex = fake.index
+	if existing == nil {
+		pair.CreateIndex = fake.index
+	}
+	fake.kv[key] = pair
+
+	writeConsulJSON(w, true)
+}
+
+func (fake *FakeConsul) deleteKV(w http.ResponseWriter, key string, query url.Values) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	_, recurse := query["recurse"]
+	for existing := range fake.kv {
+		if existing == key || (recurse && strings.HasPrefix(existing, key)) {
+			delete(fake.kv, existing)
+		}
+	}
+	fake.bump()
+
+	writeConsulJSON(w, true)
+}
+
+func (fake *FakeConsul) serveSession(w http.ResponseWriter, r *http.Request, path string) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	parts := strings.SplitN(path, "/", 2)
+	id := ""
+	if len(parts) == 2 {
+		id = parts[1]
+	}
+
+	switch parts[0] {
+	case "create":
+		// the lock delay is sent as a duration string by some clients and
+		// in nanoseconds by others, and the fake does not apply it anyway
+		request := struct {
+			Name     string
+			Behavior string
+			TTL      string
+		}{}
+		body, err := ioutil.ReadAll(r.Body)
+		if err == nil && len(body) > 0 {
+			err = json.Unmarshal(body, &request)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entry := consulapi.SessionEntry{Name: request.Name, Behavior: request.Behavior, TTL: request.TTL}
+		var ttl time.Duration
+		if entry.TTL != "" {
+			ttl, err = time.ParseDuration(entry.TTL)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		guid, err := uuid.NewV4()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		entry.ID = guid.String()
+		entry.Node = "0"
+		if entry.Behavior == "" {
+			entry.Behavior = consulapi.SessionBehaviorRelease
+		}
+		fake.sessions[entry.ID] = &fakeSession{entry: entry, ttl: ttl, renewed: time.Now()}
+		writeConsulJSON(w, map[string]string{"ID": entry.ID})
+
+	case "renew":
+		session, found := fake.sessions[id]
+		if !found {
+			http.Error(w, fmt.Sprintf("Session id '%s' not found", id), http.StatusNotFound)
+			return
+		}
+		session.renewed = time.Now()
+		writeConsulJSON(w, []consulapi.SessionEntry{session.entry})
+
+	case "info":
+		entries := []consulapi.SessionEntry{}
+		if session, found := fake.sessions[id]; found {
+			entries = append(entries, session.entry)
+		}
+		writeConsulJSON(w, entries)
+
+	case "destroy":
+		fake.invalidate(id)
+		writeConsulJSON(w, true)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// sessions that are not renewed within their TTL are invalidated, as
+// consul does once a process holding a lock dies
+func (fake *FakeConsul) expireSessions() {
+	ticker := time.NewTicker(sessionExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			fake.lock.Lock()
+			for id, session := range fake.sessions {
+				if session.ttl > 0 && time.Since(session.renewed) > session.ttl {
+					fake.invalidate(id)
+				}
+			}
+			fake.lock.Unlock()
+		case <-fake.done:
+			return
+		}
+	}
+}
+
+// invalidate forgets the session and releases or deletes, depending on its
+// behavior, the keys it holds. Must be called with the lock held.
+func (fake *FakeConsul) invalidate(id string) {
+	session, found := fake.sessions[id]
+	if !found {
+		return
+	}
+	delete(fake.sessions, id)
+
+	fake.bump()
+	for key, pair := range fake.kv {
+		if pair.Session != id {
+			continue
+		}
+		if session.entry.Behavior == consulapi.SessionBehaviorDelete {
+			delete(fake.kv, key)
+			continue
+		}
+		pair.Session = ""
+		pair.ModifyIndex = fake.index
+	}
+}
+
+func (fake *FakeConsul) registerService(w http.ResponseWriter, r *http.Request) {
+	registration := consulapi.AgentServiceRegistration{}
+	if err := json.NewDecoder(r.Body).Decode(&registration); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := registration.ID
+	if id == "" {
+		id = registration.Name
+	}
+
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	fake.services[id] = &consulapi.AgentService{
+		ID:      id,
+		Service: registration.Name,
+		Tags:    registration.Tags,
+		Port:    registration.Port,
+		Address: registration.Address,
+	}
+	if registration.Check != nil && registration.Check.TTL != "" {
+		checkID := "service:" + id
+		fake.checks[checkID] = &consulapi.AgentCheck{
+			Node:        "0",
+			CheckID:     checkID,
+			Name:        fmt.Sprintf("Service '%s' check", registration.Name),
+			Status:      "critical",
+			ServiceID:   id,
+			ServiceName: registration.Name,
+		}
+	}
+}
+
+func (fake *FakeConsul) deregisterService(w http.ResponseWriter, id string) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	delete(fake.services, id)
+	delete(fake.checks, "service:"+id)
+}
+
+// updateCheck serves the pass, warn, fail and update calls that keep a TTL
+// check alive
+func (fake *FakeConsul) updateCheck(w http.ResponseWriter, r *http.Request, path string) {
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+
+	status, output := "", r.URL.Query().Get("note")
+	switch parts[0] {
+	case "pass":
+		status = "passing"
+	case "warn":
+		status = "warning"
+	case "fail":
+		status = "critical"
+	case "update":
+		update := struct {
+			Status string
+			Output string
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		status, output = update.Status, update.Output
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	check, found := fake.checks[parts[1]]
+	if !found {
+		http.Error(w, fmt.Sprintf("CheckID %q does not have associated TTL", parts[1]), http.StatusInternalServerError)
+		return
+	}
+	check.Status = status
+	check.Output = output
+}
+
+// bump moves the index on and wakes the blocking queries. Must be called
+// with the lock held.
+func (fake *FakeConsul) bump() {
+	fake.index++
+	close(fake.changed)
+	fake.changed = make(chan struct{})
+}
+
+func writeConsulJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+type kvPairsByKey consulapi.KVPairs
+
+func (pairs kvPairsByKey) Len() int           { return len(pairs) }
+func (pairs kvPairsByKey) Less(i, j int) bool { return pairs[i].Key < pairs[j].Key }
+func (pairs kvPairsByKey) Swap(i, j int)      { pairs[i], pairs[j] = pairs[j], pairs[i] }
@@ -0,0 +1,470 @@
+package tpsrunner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/types"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+const (
+	podsResource                   = "pods"
+	nodesResource                  = "nodes"
+	replicationControllersResource = "replicationcontrollers"
+	replicaSetsResource            = "replicasets"
+	eventsResource                 = "events"
+)
+
+// FakeKubernetes serves the parts of the kubernetes v1 and extensions APIs
+// the listener uses from memory: listing pods, replication controllers,
+// replica sets, nodes and events, watching them, and deleting pods.
+// Objects are added by the test, nothing is scheduled or started on its own.
+type FakeKubernetes struct {
+	server *httptest.Server
+
+	lock            sync.Mutex
+	resourceVersion int
+	objects         map[string]map[string]kubeObject
+	watchers        map[*kubeWatcher]struct{}
+	done            chan struct{}
+}
+
+type kubeObject struct {
+	namespace string
+	labels    map[string]string
+	fields    fields.Set
+	object    interface{}
+}
+
+type kubeWatcher struct {
+	resource  string
+	namespace string
+	selector  labels.Selector
+	events    chan kubeWatchEvent
+}
+
+type kubeWatchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Object interface{}     `json:"object"`
+}
+
+func NewFakeKubernetes() *FakeKubernetes {
+	fake := &FakeKubernetes{
+		objects: map[string]map[string]kubeObject{
+			podsResource:                   {},
+			nodesResource:                  {},
+			replicationControllersResource: {},
+			replicaSetsResource:            {},
+			eventsResource:                 {},
+		},
+		watchers: map[*kubeWatcher]struct{}{},
+		done:     make(chan struct{}),
+	}
+	fake.server = httptest.NewServer(http.HandlerFunc(fake.serveHTTP))
+	return fake
+}
+
+func (fake *FakeKubernetes) URL() string {
+	return fake.server.URL
+}
+
+func (fake *FakeKubernetes) Close() {
+	close(fake.done)
+	fake.server.Close()
+}
+
+// AddPod creates the pod, or replaces it if one with the same namespace
+// and name exists, and notifies any watchers
+func (fake *FakeKubernetes) AddPod(pod v1.Pod) {
+	pod.TypeMeta = unversioned.TypeMeta{Kind: "Pod", APIVersion: "v1"}
+	fake.put(podsResource, &pod.ObjectMeta, func() kubeObject {
+		return kubeObject{
+			namespace: pod.ObjectMeta.Namespace,
+			labels:    pod.ObjectMeta.Labels,
+			fields: fields.Set{
+				"metadata.name":      pod.ObjectMeta.Name,
+				"metadata.namespace": pod.ObjectMeta.Namespace,
+				"spec.nodeName":      pod.Spec.NodeName,
+			},
+			object: pod,
+		}
+	})
+}
+
+func (fake *FakeKubernetes) DeletePod(namespace, name string) bool {
+	return fake.delete(podsResource, namespace, name)
+}
+
+// Pods returns the pods in the namespace, or in all of them when the
+// namespace is empty
+func (fake *FakeKubernetes) Pods(namespace string) []v1.Pod {
+	pods := []v1.Pod{}
+	for _, object := range fake.list(podsResource, namespace, labels.Everything(), fields.Everything()) {
+		pods = append(pods, object.(v1.Pod))
+	}
+	return pods
+}
+
+func (fake *FakeKubernetes) AddReplicationController(rc v1.ReplicationController) {
+	rc.TypeMeta = unversioned.TypeMeta{Kind: "ReplicationController", APIVersion: "v1"}
+	fake.put(replicationControllersResource, &rc.ObjectMeta, func() kubeObject {
+		return kubeObject{
+			namespace: rc.ObjectMeta.Namespace,
+			labels:    rc.ObjectMeta.Labels,
+			fields: fields.Set{
+				"metadata.name":      rc.ObjectMeta.Name,
+				"metadata.namespace": rc.ObjectMeta.Namespace,
+			},
+			object: rc,
+		}
+	})
+}
+
+func (fake *FakeKubernetes) DeleteReplicationController(namespace, name string) bool {
+	return fake.delete(replicationControllersResource, namespace, name)
+}
+
+func (fake *FakeKubernetes) AddReplicaSet(rs v1beta1.ReplicaSet) {
+	rs.TypeMeta = unversioned.TypeMeta{Kind: "ReplicaSet", APIVersion: "extensions/v1beta1"}
+	fake.put(replicaSetsResource, &rs.ObjectMeta, func() kubeObject {
+		return kubeObject{
+			namespace: rs.ObjectMeta.Namespace,
+			labels:    rs.ObjectMeta.Labels,
+			fields: fields.Set{
+				"metadata.name":      rs.ObjectMeta.Name,
+				"metadata.namespace": rs.ObjectMeta.Namespace,
+			},
+			object: rs,
+		}
+	})
+}
+
+func (fake *FakeKubernetes) DeleteReplicaSet(namespace, name string) bool {
+	return fake.delete(replicaSetsResource, namespace, name)
+}
+
+func (fake *FakeKubernetes) AddNode(node v1.Node) {
+	node.TypeMeta = unversioned.TypeMeta{Kind: "Node", APIVersion: "v1"}
+	fake.put(nodesResource, &node.ObjectMeta, func() kubeObject {
+		return kubeObject{
+			labels: node.ObjectMeta.Labels,
+			fields: fields.Set{"metadata.name": node.ObjectMeta.Name},
+			object: node,
+		}
+	})
+}
+
+func (fake *FakeKubernetes) DeleteNode(name string) bool {
+	return fake.delete(nodesResource, "", name)
+}
+
+func (fake *FakeKubernetes) AddEvent(event v1.Event) {
+	event.TypeMeta = unversioned.TypeMeta{Kind: "Event", APIVersion: "v1"}
+	fake.put(eventsResource, &event.ObjectMeta, func() kubeObject {
+		return kubeObject{
+			namespace: event.ObjectMeta.Namespace,
+			labels:    event.ObjectMeta.Labels,
+			fields: fields.Set{
+				"metadata.name":            event.ObjectMeta.Name,
+				"metadata.namespace":       event.ObjectMeta.Namespace,
+				"involvedObject.kind":      event.InvolvedObject.Kind,
+				"involvedObject.name":      event.InvolvedObject.Name,
+				"involvedObject.uid":       string(event.InvolvedObject.UID),
+				"involvedObject.fieldPath": event.InvolvedObject.FieldPath,
+				"reason":                   event.Reason,
+				"type":                     event.Type,
+			},
+			object: event,
+		}
+	})
+}
+
+// the object is built after its metadata is filled in so that the stored
+// copy carries the uid and resource version handed out here
+func (fake *FakeKubernetes) put(resource string, meta *v1.ObjectMeta, build func() kubeObject) {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	key := meta.Namespace + "/" + meta.Name
+	existing, found := fake.objects[resource][key]
+
+	if meta.UID == "" {
+		if found {
+			meta.UID = existingUID(existing.object)
+		} else {
+			meta.UID = types.UID(fmt.Sprintf("%s-%s-%s", resource, meta.Namespace, meta.Name))
+		}
+	}
+	fake.resourceVersion++
+	meta.ResourceVersion = strconv.Itoa(fake.resourceVersion)
+
+	object := build()
+	fake.objects[resource][key] = object
+
+	eventType := watch.Added
+	if found {
+		eventType = watch.Modified
+	}
+	fake.notify(resource, object, eventType)
+}
+
+func (fake *FakeKubernetes) delete(resource, namespace, name string) bool {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	key := namespace + "/" + name
+	object, found := fake.objects[resource][key]
+	if !found {
+		return false
+	}
+
+	delete(fake.objects[resource], key)
+	fake.resourceVersion++
+	fake.notify(resource, object, watch.Deleted)
+	return true
+}
+
+func (fake *FakeKubernetes) list(resource, namespace string, labelSelector labels.Selector, fieldSelector fields.Selector) []interface{} {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	keys := []string{}
+	for key, object := range fake.objects[resource] {
+		if namespace != "" && object.namespace != namespace {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(object.labels)) || !fieldSelector.Matches(object.fields) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	objects := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, fake.objects[resource][key].object)
+	}
+	return objects
+}
+
+// must be called with the lock held; a watcher that is not keeping up
+// misses the event rather than blocking the test
+func (fake *FakeKubernetes) notify(resource string, object kubeObject, eventType watch.EventType) {
+	for watcher := range fake.watchers {
+		if watcher.resource != resource {
+			continue
+		}
+		if watcher.namespace != "" && watcher.namespace != object.namespace {
+			continue
+		}
+		if !watcher.selector.Matches(labels.Set(object.labels)) {
+			continue
+		}
+
+		select {
+		case watcher.events <- kubeWatchEvent{Type: eventType, Object: object.object}:
+		default:
+		}
+	}
+}
+
+func (fake *FakeKubernetes) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// replica sets are the only extensions resource served
+	group := "/api/v1"
+	if strings.HasPrefix(r.URL.Path, "/apis/extensions/v1beta1") {
+		group = "/apis/extensions/v1beta1"
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, group), "/")
+	segments := strings.Split(path, "/")
+
+	watching := r.URL.Query().Get("watch") == "true"
+	if len(segments) > 0 && segments[0] == "watch" {
+		watching = true
+		segments = segments[1:]
+	}
+
+	var namespace, name string
+	if len(segments) >= 2 && segments[0] == "namespaces" {
+		namespace = segments[1]
+		segments = segments[2:]
+	}
+	if len(segments) == 0 || len(segments) > 2 {
+		writeKubeStatus(w, http.StatusNotFound, unversioned.StatusReasonNotFound, "the server could not find the requested resource")
+		return
+	}
+	resource := segments[0]
+	if len(segments) == 2 {
+		name = segments[1]
+	}
+
+	_, known := fake.objects[resource]
+	inGroup := (resource == replicaSetsResource) == (group != "/api/v1")
+	if !known || !inGroup {
+		writeKubeStatus(w, http.StatusNotFound, unversioned.StatusReasonNotFound, "the server could not find the requested resource")
+		return
+	}
+
+	labelSelector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeKubeStatus(w, http.StatusBadRequest, unversioned.StatusReasonBadRequest, err.Error())
+		return
+	}
+	fieldSelector, err := fields.ParseSelector(r.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		writeKubeStatus(w, http.StatusBadRequest, unversioned.StatusReasonBadRequest, err.Error())
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && name == "" && watching:
+		fake.serveWatch(w, resource, namespace, labelSelector)
+	case r.Method == "GET" && name == "":
+		fake.serveList(w, resource, namespace, labelSelector, fieldSelector)
+	case r.Method == "DELETE" && name != "" && resource == podsResource:
+		if !fake.DeletePod(namespace, name) {
+			writeKubeStatus(w, http.StatusNotFound, unversioned.StatusReasonNotFound, fmt.Sprintf("pods %q not found", name))
+			return
+		}
+		writeKubeStatus(w, http.StatusOK, "", "")
+	default:
+		writeKubeStatus(w, http.StatusMethodNotAllowed, unversioned.StatusReasonMethodNotAllowed, "the server does not allow this method on the requested resource")
+	}
+}
+
+func (fake *FakeKubernetes) serveList(w http.ResponseWriter, resource, namespace string, labelSelector labels.Selector, fieldSelector fields.Selector) {
+	objects := fake.list(resource, namespace, labelSelector, fieldSelector)
+
+	fake.lock.Lock()
+	resourceVersion := strconv.Itoa(fake.resourceVersion)
+	fake.lock.Unlock()
+
+	var list interface{}
+	listMeta := unversioned.ListMeta{ResourceVersion: resourceVersion}
+	switch resource {
+	case podsResource:
+		podList := v1.PodList{TypeMeta: unversioned.TypeMeta{Kind: "PodList", APIVersion: "v1"}, ListMeta: listMeta, Items: []v1.Pod{}}
+		for _, object := range objects {
+			podList.Items = append(podList.Items, object.(v1.Pod))
+		}
+		list = podList
+	case replicationControllersResource:
+		rcList := v1.ReplicationControllerList{TypeMeta: unversioned.TypeMeta{Kind: "ReplicationControllerList", APIVersion: "v1"}, ListMeta: listMeta, Items: []v1.ReplicationController{}}
+		for _, object := range objects {
+			rcList.Items = append(rcList.Items, object.(v1.ReplicationController))
+		}
+		list = rcList
+	case replicaSetsResource:
+		rsList := v1beta1.ReplicaSetList{TypeMeta: unversioned.TypeMeta{Kind: "ReplicaSetList", APIVersion: "extensions/v1beta1"}, ListMeta: listMeta, Items: []v1beta1.ReplicaSet{}}
+		for _, object := range objects {
+			rsList.Items = append(rsList.Items, object.(v1beta1.ReplicaSet))
+		}
+		list = rsList
+	case nodesResource:
+		nodeList := v1.NodeList{TypeMeta: unversioned.TypeMeta{Kind: "NodeList", APIVersion: "v1"}, ListMeta: listMeta, Items: []v1.Node{}}
+		for _, object := range objects {
+			nodeList.Items = append(nodeList.Items, object.(v1.Node))
+		}
+		list = nodeList
+	case eventsResource:
+		eventList := v1.EventList{TypeMeta: unversioned.TypeMeta{Kind: "EventList", APIVersion: "v1"}, ListMeta: listMeta, Items: []v1.Event{}}
+		for _, object := range objects {
+			eventList.Items = append(eventList.Items, object.(v1.Event))
+		}
+		list = eventList
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(list)
+}
+
+// streams watch events until the client goes away or the fake is closed.
+// Only changes made after the watch starts are sent; resourceVersion is
+// not used to replay older ones.
+func (fake *FakeKubernetes) serveWatch(w http.ResponseWriter, resource, namespace string, selector labels.Selector) {
+	watcher := &kubeWatcher{
+		resource:  resource,
+		namespace: namespace,
+		selector:  selector,
+		events:    make(chan kubeWatchEvent, 100),
+	}
+
+	fake.lock.Lock()
+	fake.watchers[watcher] = struct{}{}
+	fake.lock.Unlock()
+
+	defer func() {
+		fake.lock.Lock()
+		delete(fake.watchers, watcher)
+		fake.lock.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Transfer-Encoding", "chunked")
+	w.WriteHeader(http.StatusOK)
+
+	flusher := w.(http.Flusher)
+	flusher.Flush()
+
+	closeNotifier := w.(http.CloseNotifier).CloseNotify()
+	encoder := json.NewEncoder(w)
+
+	for {
+		select {
+		case event := <-watcher.events:
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-closeNotifier:
+			return
+		case <-fake.done:
+			return
+		}
+	}
+}
+
+func writeKubeStatus(w http.ResponseWriter, code int, reason unversioned.StatusReason, message string) {
+	status := unversioned.Status{
+		TypeMeta: unversioned.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   unversioned.StatusSuccess,
+		Code:     int32(code),
+	}
+	if code >= http.StatusBadRequest {
+		status.Status = unversioned.StatusFailure
+		status.Reason = reason
+		status.Message = message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+func existingUID(object interface{}) types.UID {
+	switch object := object.(type) {
+	case v1.Pod:
+		return object.ObjectMeta.UID
+	case v1.ReplicationController:
+		return object.ObjectMeta.UID
+	case v1beta1.ReplicaSet:
+		return object.ObjectMeta.UID
+	case v1.Node:
+		return object.ObjectMeta.UID
+	case v1.Event:
+		return object.ObjectMeta.UID
+	}
+	return ""
+}
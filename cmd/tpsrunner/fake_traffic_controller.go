@@ -0,0 +1,106 @@
+package tpsrunner
+
+import (
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/sonde-go/events"
+	"github.com/gogo/protobuf/proto"
+)
+
+const containerMetricsOrigin = "fake-traffic-controller"
+
+// FakeTrafficController answers the noaa container metrics request,
+// GET /apps/:guid/containermetrics, with the metrics set for the app. It
+// serves TLS with a self signed certificate, so the listener needs
+// -skipSSLVerification.
+type FakeTrafficController struct {
+	server *httptest.Server
+
+	lock           sync.Mutex
+	metrics        map[string][]*events.ContainerMetric
+	authorizations []string
+}
+
+func NewFakeTrafficController() *FakeTrafficController {
+	fake := &FakeTrafficController{
+		metrics: map[string][]*events.ContainerMetric{},
+	}
+	fake.server = httptest.NewTLSServer(http.HandlerFunc(fake.serveHTTP))
+	return fake
+}
+
+func (fake *FakeTrafficController) URL() string {
+	return fake.server.URL
+}
+
+func (fake *FakeTrafficController) Close() {
+	fake.server.Close()
+}
+
+// SetContainerMetrics replaces the metrics reported for the app's log guid
+func (fake *FakeTrafficController) SetContainerMetrics(appGuid string, metrics ...*events.ContainerMetric) {
+	fake.lock.Lock()
+	fake.metrics[appGuid] = metrics
+	fake.lock.Unlock()
+}
+
+// the authorization headers of the requests received so far
+func (fake *FakeTrafficController) Authorizations() []string {
+	fake.lock.Lock()
+	defer fake.lock.Unlock()
+
+	authorizations := make([]string, len(fake.authorizations))
+	copy(authorizations, fake.authorizations)
+	return authorizations
+}
+
+func (fake *FakeTrafficController) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if r.Method != "GET" || len(segments) != 3 || segments[0] != "apps" || segments[2] != "containermetrics" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	authorization := r.Header.Get("Authorization")
+
+	fake.lock.Lock()
+	fake.authorizations = append(fake.authorizations, authorization)
+	metrics := fake.metrics[segments[1]]
+	fake.lock.Unlock()
+
+	if authorization == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	writer := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/x-protobuf; boundary="+writer.Boundary())
+	w.WriteHeader(http.StatusOK)
+
+	for _, metric := range metrics {
+		envelope := &events.Envelope{
+			Origin:          proto.String(containerMetricsOrigin),
+			EventType:       events.Envelope_ContainerMetric.Enum(),
+			Timestamp:       proto.Int64(time.Now().UnixNano()),
+			ContainerMetric: metric,
+		}
+
+		data, err := proto.Marshal(envelope)
+		if err != nil {
+			return
+		}
+
+		part, err := writer.CreatePart(nil)
+		if err != nil {
+			return
+		}
+		part.Write(data)
+	}
+
+	writer.Close()
+}
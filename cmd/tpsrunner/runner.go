@@ -24,16 +24,51 @@ func NewListener(bin, listenAddr, kubeCluster, kubeClientCert, kubeClientKey, ku
 	})
 }
 
-func NewWatcher(bin, bbsAddress, ccBaseURL, consulCluster string) *ginkgomon.Runner {
+// extra args, such as -config, are passed after the required flags
+func NewWatcher(bin, bbsAddress, ccBaseURL, consulCluster string, args ...string) *ginkgomon.Runner {
 	return ginkgomon.New(ginkgomon.Config{
 		Name: "tps-watcher",
 		Command: exec.Command(
 			bin,
-			"-bbsAddress", bbsAddress,
-			"-ccBaseURL", ccBaseURL,
-			"-lockRetryInterval", "1s",
-			"-consulCluster", consulCluster,
+			append([]string{
+				"-bbsAddress", bbsAddress,
+				"-ccBaseURL", ccBaseURL,
+				"-lockRetryInterval", "1s",
+				"-consulCluster", consulCluster,
+			}, args...)...,
 		),
 		StartCheck: "tps-watcher.started",
 	})
 }
+
+// runs both the listener and the watcher in one tps process
+func NewCombined(bin, listenAddr, kubeCluster, trafficControllerURL, bbsAddress, ccBaseURL, consulCluster string) *ginkgomon.Runner {
+	return ginkgomon.New(ginkgomon.Config{
+		Name:       "tps",
+		Command:    combinedCommand(bin, listenAddr, kubeCluster, trafficControllerURL, bbsAddress, ccBaseURL, consulCluster),
+		StartCheck: "tps.started",
+	})
+}
+
+// a combined process that is not expected to get the watcher lock, so is
+// considered started straight away
+func NewCombinedStandby(bin, listenAddr, kubeCluster, trafficControllerURL, bbsAddress, ccBaseURL, consulCluster string) *ginkgomon.Runner {
+	return ginkgomon.New(ginkgomon.Config{
+		Name:    "tps-standby",
+		Command: combinedCommand(bin, listenAddr, kubeCluster, trafficControllerURL, bbsAddress, ccBaseURL, consulCluster),
+	})
+}
+
+func combinedCommand(bin, listenAddr, kubeCluster, trafficControllerURL, bbsAddress, ccBaseURL, consulCluster string) *exec.Cmd {
+	return exec.Command(
+		bin, "all",
+		"-listenAddr", listenAddr,
+		"-kubeCluster", kubeCluster,
+		"-trafficControllerURL", trafficControllerURL,
+		"-skipSSLVerification",
+		"-bbsAddress", bbsAddress,
+		"-ccBaseURL", ccBaseURL,
+		"-lockRetryInterval", "1s",
+		"-consulCluster", consulCluster,
+	)
+}
@@ -0,0 +1,242 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/ghodss/yaml"
+)
+
+const EnvPrefix = "TPS_"
+
+// Config sets the flags of a binary from a JSON or YAML file whose keys are
+// the flag names, and from environment variables named by EnvName. Flags
+// given on the command line win over the environment, which wins over the
+// file.
+type Config struct {
+	flagSet     *flag.FlagSet
+	path        string
+	commandLine map[string]bool
+}
+
+// New must be called once flagSet has been parsed, to tell the flags given
+// on the command line from those it sets itself. An empty path means only
+// the environment is read.
+func New(flagSet *flag.FlagSet, path string) *Config {
+	commandLine := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) {
+		commandLine[f.Name] = true
+	})
+
+	return &Config{
+		flagSet:     flagSet,
+		path:        path,
+		commandLine: commandLine,
+	}
+}
+
+// the environment variable overriding a flag: maxInFlightRequests is set by
+// TPS_MAX_IN_FLIGHT_REQUESTS
+func EnvName(flagName string) string {
+	name := []rune{}
+	previous := rune(0)
+	for _, r := range flagName {
+		switch {
+		case r == '-' || r == '.':
+			r = '_'
+		case unicode.IsUpper(r) && (unicode.IsLower(previous) || unicode.IsDigit(previous)):
+			name = append(name, '_')
+		}
+		name = append(name, unicode.ToUpper(r))
+		previous = r
+	}
+	return EnvPrefix + string(name)
+}
+
+// Load sets every flag not given on the command line that the file or the
+// environment has a value for
+func (c *Config) Load() error {
+	values, err := c.values()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(values) {
+		err := c.flagSet.Set(name, values[name])
+		if err != nil {
+			return fmt.Errorf("invalid value %q for %s: %s", values[name], name, err)
+		}
+	}
+	return nil
+}
+
+// Reload reads the file and environment again and sets those of the safe
+// flags whose values changed. Flags neither has a value for any more go back
+// to their defaults. changed lists the flags that were set, ignored those
+// that changed but need a restart to take effect. When a value does not
+// parse or validate fails, the flags are left as they were.
+func (c *Config) Reload(safe []string, validate func() error) (changed []string, ignored []string, err error) {
+	values, err := c.values()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	settings := c.defaults()
+	for name, value := range values {
+		settings[name] = value
+	}
+	values = settings
+
+	isSafe := map[string]bool{}
+	for _, name := range safe {
+		isSafe[name] = true
+	}
+
+	previous := map[string]string{}
+	restore := func() {
+		for name, value := range previous {
+			c.flagSet.Set(name, value)
+		}
+	}
+
+	for _, name := range sortedKeys(values) {
+		current := c.flagSet.Lookup(name).Value.String()
+		if current == values[name] {
+			continue
+		}
+
+		if !isSafe[name] {
+			ignored = append(ignored, name)
+			continue
+		}
+
+		err := c.flagSet.Set(name, values[name])
+		if err != nil {
+			restore()
+			return nil, nil, fmt.Errorf("invalid value %q for %s: %s", values[name], name, err)
+		}
+		previous[name] = current
+		changed = append(changed, name)
+	}
+
+	if validate != nil && len(changed) > 0 {
+		if err := validate(); err != nil {
+			restore()
+			return nil, nil, err
+		}
+	}
+
+	return changed, ignored, nil
+}
+
+// the defaults of the flags not given on the command line
+func (c *Config) defaults() map[string]string {
+	defaults := map[string]string{}
+	c.flagSet.VisitAll(func(f *flag.Flag) {
+		if !c.commandLine[f.Name] {
+			defaults[f.Name] = f.DefValue
+		}
+	})
+	return defaults
+}
+
+// the values of the flags not given on the command line, from the file
+// overridden by the environment
+func (c *Config) values() (map[string]string, error) {
+	values := map[string]string{}
+
+	if c.path != "" {
+		fileValues, err := readFile(c.path)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, value := range fileValues {
+			if c.flagSet.Lookup(name) == nil {
+				return nil, fmt.Errorf("unknown setting %s in %s", name, c.path)
+			}
+			values[name] = value
+		}
+	}
+
+	c.flagSet.VisitAll(func(f *flag.Flag) {
+		if value, ok := os.LookupEnv(EnvName(f.Name)); ok {
+			values[f.Name] = value
+		}
+	})
+
+	for name := range c.commandLine {
+		delete(values, name)
+	}
+
+	return values, nil
+}
+
+// parses a JSON or YAML file of settings. Numbers and booleans are turned
+// into their flag form, lists into comma-separated values.
+func readFile(path string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var settings map[string]interface{}
+	err = yaml.Unmarshal(contents, &settings)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %s", path, err)
+	}
+
+	values := map[string]string{}
+	for name, setting := range settings {
+		value, err := flagValue(setting)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s in %s: %s", name, path, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+func flagValue(setting interface{}) (string, error) {
+	switch v := setting.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case nil:
+		return "", nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			if _, isList := item.([]interface{}); isList {
+				return "", errors.New("lists cannot be nested")
+			}
+			value, err := flagValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = value
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", errors.New("must be a string, number, boolean or list")
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,255 @@
+package config_test
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/config"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	var (
+		flagSet     *flag.FlagSet
+		maxInFlight *int
+		listenAddr  *string
+		skipVerify  *bool
+		timeout     *time.Duration
+		groups      *string
+		path        string
+	)
+
+	writeConfig := func(contents string) {
+		Expect(ioutil.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+	}
+
+	parse := func(args ...string) *config.Config {
+		Expect(flagSet.Parse(args)).To(Succeed())
+		return config.New(flagSet, path)
+	}
+
+	BeforeEach(func() {
+		flagSet = flag.NewFlagSet("test", flag.ContinueOnError)
+		maxInFlight = flagSet.Int("maxInFlightRequests", 200, "")
+		listenAddr = flagSet.String("listenAddr", "0.0.0.0:1518", "")
+		skipVerify = flagSet.Bool("skipSSLVerification", false, "")
+		timeout = flagSet.Duration("kubeRequestTimeout", 10*time.Second, "")
+		groups = flagSet.String("kubeImpersonateGroups", "", "")
+
+		file, err := ioutil.TempFile("", "config")
+		Expect(err).NotTo(HaveOccurred())
+		file.Close()
+		path = file.Name()
+	})
+
+	AfterEach(func() {
+		os.Remove(path)
+		os.Unsetenv("TPS_MAX_IN_FLIGHT_REQUESTS")
+	})
+
+	Describe("EnvName", func() {
+		It("upper snake cases the flag name", func() {
+			Expect(config.EnvName("maxInFlightRequests")).To(Equal("TPS_MAX_IN_FLIGHT_REQUESTS"))
+			Expect(config.EnvName("kubeCACert")).To(Equal("TPS_KUBE_CACERT"))
+			Expect(config.EnvName("validate-config")).To(Equal("TPS_VALIDATE_CONFIG"))
+		})
+	})
+
+	Describe("Load", func() {
+		It("sets the flags from a JSON file", func() {
+			writeConfig(`{"maxInFlightRequests": 50, "skipSSLVerification": true, "kubeRequestTimeout": "30s", "kubeImpersonateGroups": ["readers", "auditors"]}`)
+
+			Expect(parse().Load()).To(Succeed())
+			Expect(*maxInFlight).To(Equal(50))
+			Expect(*skipVerify).To(BeTrue())
+			Expect(*timeout).To(Equal(30 * time.Second))
+			Expect(*groups).To(Equal("readers,auditors"))
+		})
+
+		It("sets the flags from a YAML file", func() {
+			writeConfig("maxInFlightRequests: 50\nlistenAddr: 127.0.0.1:1518\n")
+
+			Expect(parse().Load()).To(Succeed())
+			Expect(*maxInFlight).To(Equal(50))
+			Expect(*listenAddr).To(Equal("127.0.0.1:1518"))
+		})
+
+		It("lets the environment override the file", func() {
+			writeConfig(`{"maxInFlightRequests": 50}`)
+			os.Setenv("TPS_MAX_IN_FLIGHT_REQUESTS", "75")
+
+			Expect(parse().Load()).To(Succeed())
+			Expect(*maxInFlight).To(Equal(75))
+		})
+
+		It("lets the command line override the environment and the file", func() {
+			writeConfig(`{"maxInFlightRequests": 50}`)
+			os.Setenv("TPS_MAX_IN_FLIGHT_REQUESTS", "75")
+
+			Expect(parse("-maxInFlightRequests", "100").Load()).To(Succeed())
+			Expect(*maxInFlight).To(Equal(100))
+		})
+
+		It("reads only the environment without a file", func() {
+			path = ""
+			os.Setenv("TPS_MAX_IN_FLIGHT_REQUESTS", "75")
+
+			Expect(parse().Load()).To(Succeed())
+			Expect(*maxInFlight).To(Equal(75))
+		})
+
+		It("rejects settings that are not flags", func() {
+			writeConfig(`{"maxInFlight": 50}`)
+			Expect(parse().Load()).To(MatchError("unknown setting maxInFlight in " + path))
+		})
+
+		It("rejects values the flag cannot parse", func() {
+			writeConfig(`{"kubeRequestTimeout": "soon"}`)
+			Expect(parse().Load()).To(MatchError(ContainSubstring(`invalid value "soon" for kubeRequestTimeout`)))
+		})
+
+		It("rejects nested settings", func() {
+			writeConfig(`{"listenAddr": {"host": "0.0.0.0"}}`)
+			Expect(parse().Load()).To(HaveOccurred())
+		})
+
+		It("fails when the file cannot be parsed", func() {
+			writeConfig(`{"maxInFlightRequests": `)
+			Expect(parse().Load()).To(HaveOccurred())
+		})
+	})
+
+	Describe("Reload", func() {
+		var settings *config.Config
+
+		BeforeEach(func() {
+			writeConfig(`{"maxInFlightRequests": 50, "listenAddr": "0.0.0.0:1518"}`)
+			settings = parse()
+			Expect(settings.Load()).To(Succeed())
+		})
+
+		It("sets the safe flags that changed and reports the others", func() {
+			writeConfig(`{"maxInFlightRequests": 60, "listenAddr": "0.0.0.0:1519"}`)
+
+			changed, ignored, err := settings.Reload([]string{"maxInFlightRequests"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(Equal([]string{"maxInFlightRequests"}))
+			Expect(ignored).To(Equal([]string{"listenAddr"}))
+			Expect(*maxInFlight).To(Equal(60))
+			Expect(*listenAddr).To(Equal("0.0.0.0:1518"))
+		})
+
+		It("leaves the flags as they were when the new settings are invalid", func() {
+			writeConfig(`{"maxInFlightRequests": -1, "skipSSLVerification": true}`)
+
+			safe := []string{"maxInFlightRequests", "skipSSLVerification"}
+			_, _, err := settings.Reload(safe, func() error {
+				if *maxInFlight <= 0 {
+					return errors.New("maxInFlightRequests must be positive")
+				}
+				return nil
+			})
+			Expect(err).To(MatchError("maxInFlightRequests must be positive"))
+			Expect(*maxInFlight).To(Equal(50))
+			Expect(*skipVerify).To(BeFalse())
+		})
+
+		It("puts the settings no longer in the file back to their defaults", func() {
+			writeConfig(`{"listenAddr": "0.0.0.0:1518"}`)
+
+			changed, _, err := settings.Reload([]string{"maxInFlightRequests"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(Equal([]string{"maxInFlightRequests"}))
+			Expect(*maxInFlight).To(Equal(200))
+		})
+
+		It("puts the settings no longer in the environment back to what the file says", func() {
+			os.Setenv("TPS_MAX_IN_FLIGHT_REQUESTS", "70")
+			_, _, err := settings.Reload([]string{"maxInFlightRequests"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*maxInFlight).To(Equal(70))
+
+			os.Unsetenv("TPS_MAX_IN_FLIGHT_REQUESTS")
+			_, _, err = settings.Reload([]string{"maxInFlightRequests"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*maxInFlight).To(Equal(50))
+		})
+
+		It("reports nothing when nothing changed", func() {
+			changed, ignored, err := settings.Reload([]string{"maxInFlightRequests"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeEmpty())
+			Expect(ignored).To(BeEmpty())
+		})
+	})
+
+	Describe("Reloader", func() {
+		var (
+			logger   *lagertest.TestLogger
+			reloaded chan []string
+			process  ifrit.Process
+		)
+
+		BeforeEach(func() {
+			writeConfig(`{"maxInFlightRequests": 50}`)
+			settings := parse()
+			Expect(settings.Load()).To(Succeed())
+
+			logger = lagertest.NewTestLogger("test")
+			reloaded = make(chan []string, 1)
+			reloader := config.NewReloader(settings, []string{"maxInFlightRequests"}, nil, func(changed []string) {
+				reloaded <- changed
+			}, logger)
+
+			process = ifrit.Invoke(reloader)
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("reloads on SIGHUP", func() {
+			writeConfig(`{"maxInFlightRequests": 60}`)
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGHUP)).To(Succeed())
+
+			Eventually(reloaded).Should(Receive(Equal([]string{"maxInFlightRequests"})))
+			Expect(*maxInFlight).To(Equal(60))
+		})
+
+		It("calls onReload when nothing changed", func() {
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGHUP)).To(Succeed())
+
+			Eventually(reloaded).Should(Receive(BeEmpty()))
+		})
+
+		It("logs reloads that fail", func() {
+			writeConfig(`{"maxInFlightRequests": "many"}`)
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGHUP)).To(Succeed())
+
+			Eventually(logger.LogMessages).Should(ContainElement("test.config-reloader.failed-to-reload"))
+			Consistently(reloaded).ShouldNot(Receive())
+		})
+	})
+
+	Describe("LogLevel", func() {
+		It("maps the cf_lager level names", func() {
+			Expect(config.LogLevel("debug")).To(Equal(lager.DEBUG))
+			Expect(config.LogLevel("fatal")).To(Equal(lager.FATAL))
+		})
+
+		It("rejects unknown names", func() {
+			_, err := config.LogLevel("verbose")
+			Expect(err).To(MatchError(`unknown log level "verbose"`))
+		})
+	})
+})
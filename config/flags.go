@@ -0,0 +1,39 @@
+package config
+
+import (
+	"flag"
+	"os"
+)
+
+// the flags every binary registers for its config file
+type Flags struct {
+	path     *string
+	validate *bool
+}
+
+func AddFlags(flagSet *flag.FlagSet) *Flags {
+	return &Flags{
+		path: flagSet.String(
+			"config",
+			"",
+			"path to a JSON or YAML file of settings named like the flags; flags and "+EnvPrefix+" environment variables override it",
+		),
+		validate: flagSet.Bool(
+			"validate-config",
+			false,
+			"check the settings and exit",
+		),
+	}
+}
+
+// the -config flag, or else the environment variable for it
+func (f *Flags) Path() string {
+	if *f.path == "" {
+		return os.Getenv(EnvName("config"))
+	}
+	return *f.path
+}
+
+func (f *Flags) Validate() bool {
+	return *f.validate
+}
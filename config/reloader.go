@@ -0,0 +1,93 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// Reloader reloads the safe settings of a Config on SIGHUP and, once
+// validate accepts them, calls onReload with the flags that changed.
+// onReload is called even when none did, so files the settings name can be
+// read again. It listens for the signal itself as the process group stops
+// on any signal it is sent.
+type Reloader struct {
+	config   *Config
+	safe     []string
+	validate func() error
+	onReload func(changed []string)
+	logger   lager.Logger
+}
+
+func NewReloader(config *Config, safe []string, validate func() error, onReload func(changed []string), logger lager.Logger) *Reloader {
+	return &Reloader{
+		config:   config,
+		safe:     safe,
+		validate: validate,
+		onReload: onReload,
+		logger:   logger.Session("config-reloader"),
+	}
+}
+
+func (r *Reloader) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	close(ready)
+
+	for {
+		select {
+		case <-hangups:
+			r.Reload()
+		case <-signals:
+			return nil
+		}
+	}
+}
+
+// a failed reload is logged and leaves every setting as it was
+func (r *Reloader) Reload() {
+	changed, ignored, err := r.config.Reload(r.safe, r.validate)
+	if err != nil {
+		r.logger.Error("failed-to-reload", err)
+		return
+	}
+
+	if len(ignored) > 0 {
+		r.logger.Info("settings-need-restart", lager.Data{"settings": ignored})
+	}
+	if len(changed) > 0 {
+		r.logger.Info("reloaded", lager.Data{"settings": changed})
+	}
+	r.onReload(changed)
+}
+
+// the lager level of the logLevel flag cf_lager registers
+func FlagLogLevel(flagSet *flag.FlagSet) (lager.LogLevel, error) {
+	f := flagSet.Lookup("logLevel")
+	if f == nil {
+		return lager.INFO, errors.New("no logLevel flag")
+	}
+	return LogLevel(f.Value.String())
+}
+
+func LogLevel(name string) (lager.LogLevel, error) {
+	switch name {
+	case "debug":
+		return lager.DEBUG, nil
+	case "info":
+		return lager.INFO, nil
+	case "error":
+		return lager.ERROR, nil
+	case "fatal":
+		return lager.FATAL, nil
+	default:
+		return lager.INFO, fmt.Errorf("unknown log level %q", name)
+	}
+}
@@ -0,0 +1,28 @@
+package tps
+
+import "fmt"
+
+// codes carried in the body of unsuccessful responses
+const (
+	InvalidRequest      = "InvalidRequest"
+	InvalidProcessGuid  = "InvalidProcessGuid"
+	Unauthorized        = "Unauthorized"
+	Forbidden           = "Forbidden"
+	ProcessNotFound     = "ProcessNotFound"
+	InstanceNotFound    = "InstanceNotFound"
+	UpstreamError       = "UpstreamError"
+	UpstreamUnavailable = "UpstreamUnavailable"
+	UpstreamTimeout     = "UpstreamTimeout"
+	TooManyRequests     = "TooManyRequests"
+	InternalError       = "InternalError"
+)
+
+// the JSON body returned with every 4xx and 5xx response
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
@@ -0,0 +1,574 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+)
+
+type FakeClient struct {
+	LRPStatusStub        func(processGuid string) ([]cc_messages.LRPInstance, error)
+	lrpStatusMutex       sync.RWMutex
+	lrpStatusArgsForCall []struct {
+		processGuid string
+	}
+	lrpStatusReturns struct {
+		result1 []cc_messages.LRPInstance
+		result2 error
+	}
+	LRPStatsStub        func(processGuid string, authorization string) ([]cc_messages.LRPInstance, error)
+	lrpStatsMutex       sync.RWMutex
+	lrpStatsArgsForCall []struct {
+		processGuid   string
+		authorization string
+	}
+	lrpStatsReturns struct {
+		result1 []cc_messages.LRPInstance
+		result2 error
+	}
+	BulkLRPStatusStub        func(processGuids []string) (map[string][]cc_messages.LRPInstance, error)
+	bulkLRPStatusMutex       sync.RWMutex
+	bulkLRPStatusArgsForCall []struct {
+		processGuids []string
+	}
+	bulkLRPStatusReturns struct {
+		result1 map[string][]cc_messages.LRPInstance
+		result2 error
+	}
+	LRPEventsStub        func(processGuid string, page int, perPage int) (tps.LRPEvents, error)
+	lrpEventsMutex       sync.RWMutex
+	lrpEventsArgsForCall []struct {
+		processGuid string
+		page        int
+		perPage     int
+	}
+	lrpEventsReturns struct {
+		result1 tps.LRPEvents
+		result2 error
+	}
+	LRPInstanceStatusStub        func(processGuid string, index uint) (cc_messages.LRPInstance, error)
+	lrpInstanceStatusMutex       sync.RWMutex
+	lrpInstanceStatusArgsForCall []struct {
+		processGuid string
+		index       uint
+	}
+	lrpInstanceStatusReturns struct {
+		result1 cc_messages.LRPInstance
+		result2 error
+	}
+	RestartLRPInstanceStub        func(processGuid string, index uint, authorization string) error
+	restartLRPInstanceMutex       sync.RWMutex
+	restartLRPInstanceArgsForCall []struct {
+		processGuid   string
+		index         uint
+		authorization string
+	}
+	restartLRPInstanceReturns struct {
+		result1 error
+	}
+	NamespaceLRPsStub        func(namespace string, opts tps.ListLRPsOptions) (tps.LRPList, error)
+	namespaceLRPsMutex       sync.RWMutex
+	namespaceLRPsArgsForCall []struct {
+		namespace string
+		opts      tps.ListLRPsOptions
+	}
+	namespaceLRPsReturns struct {
+		result1 tps.LRPList
+		result2 error
+	}
+	AllLRPsStub        func(opts tps.ListLRPsOptions) (tps.LRPList, error)
+	allLRPsMutex       sync.RWMutex
+	allLRPsArgsForCall []struct {
+		opts tps.ListLRPsOptions
+	}
+	allLRPsReturns struct {
+		result1 tps.LRPList
+		result2 error
+	}
+	AppSummaryStub        func(processGuid string) (tps.AppSummary, error)
+	appSummaryMutex       sync.RWMutex
+	appSummaryArgsForCall []struct {
+		processGuid string
+	}
+	appSummaryReturns struct {
+		result1 tps.AppSummary
+		result2 error
+	}
+	BulkAppSummaryStub        func(processGuids []string) (map[string]tps.AppSummary, error)
+	bulkAppSummaryMutex       sync.RWMutex
+	bulkAppSummaryArgsForCall []struct {
+		processGuids []string
+	}
+	bulkAppSummaryReturns struct {
+		result1 map[string]tps.AppSummary
+		result2 error
+	}
+	LRPStatusV2Stub        func(processGuid string) (tps.LRPStatus, error)
+	lrpStatusV2Mutex       sync.RWMutex
+	lrpStatusV2ArgsForCall []struct {
+		processGuid string
+	}
+	lrpStatusV2Returns struct {
+		result1 tps.LRPStatus
+		result2 error
+	}
+	LRPStatsV2Stub        func(processGuid string, authorization string) (tps.LRPStatus, error)
+	lrpStatsV2Mutex       sync.RWMutex
+	lrpStatsV2ArgsForCall []struct {
+		processGuid   string
+		authorization string
+	}
+	lrpStatsV2Returns struct {
+		result1 tps.LRPStatus
+		result2 error
+	}
+	BulkLRPStatusV2Stub        func(processGuids []string) (map[string]tps.LRPStatus, error)
+	bulkLRPStatusV2Mutex       sync.RWMutex
+	bulkLRPStatusV2ArgsForCall []struct {
+		processGuids []string
+	}
+	bulkLRPStatusV2Returns struct {
+		result1 map[string]tps.LRPStatus
+		result2 error
+	}
+}
+
+func (fake *FakeClient) LRPStatus(processGuid string) ([]cc_messages.LRPInstance, error) {
+	fake.lrpStatusMutex.Lock()
+	fake.lrpStatusArgsForCall = append(fake.lrpStatusArgsForCall, struct {
+		processGuid string
+	}{processGuid})
+	fake.lrpStatusMutex.Unlock()
+	if fake.LRPStatusStub != nil {
+		return fake.LRPStatusStub(processGuid)
+	} else {
+		return fake.lrpStatusReturns.result1, fake.lrpStatusReturns.result2
+	}
+}
+
+func (fake *FakeClient) LRPStatusCallCount() int {
+	fake.lrpStatusMutex.RLock()
+	defer fake.lrpStatusMutex.RUnlock()
+	return len(fake.lrpStatusArgsForCall)
+}
+
+func (fake *FakeClient) LRPStatusArgsForCall(i int) string {
+	fake.lrpStatusMutex.RLock()
+	defer fake.lrpStatusMutex.RUnlock()
+	return fake.lrpStatusArgsForCall[i].processGuid
+}
+
+func (fake *FakeClient) LRPStatusReturns(result1 []cc_messages.LRPInstance, result2 error) {
+	fake.LRPStatusStub = nil
+	fake.lrpStatusReturns = struct {
+		result1 []cc_messages.LRPInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) LRPStats(processGuid string, authorization string) ([]cc_messages.LRPInstance, error) {
+	fake.lrpStatsMutex.Lock()
+	fake.lrpStatsArgsForCall = append(fake.lrpStatsArgsForCall, struct {
+		processGuid   string
+		authorization string
+	}{processGuid, authorization})
+	fake.lrpStatsMutex.Unlock()
+	if fake.LRPStatsStub != nil {
+		return fake.LRPStatsStub(processGuid, authorization)
+	} else {
+		return fake.lrpStatsReturns.result1, fake.lrpStatsReturns.result2
+	}
+}
+
+func (fake *FakeClient) LRPStatsCallCount() int {
+	fake.lrpStatsMutex.RLock()
+	defer fake.lrpStatsMutex.RUnlock()
+	return len(fake.lrpStatsArgsForCall)
+}
+
+func (fake *FakeClient) LRPStatsArgsForCall(i int) (string, string) {
+	fake.lrpStatsMutex.RLock()
+	defer fake.lrpStatsMutex.RUnlock()
+	return fake.lrpStatsArgsForCall[i].processGuid, fake.lrpStatsArgsForCall[i].authorization
+}
+
+func (fake *FakeClient) LRPStatsReturns(result1 []cc_messages.LRPInstance, result2 error) {
+	fake.LRPStatsStub = nil
+	fake.lrpStatsReturns = struct {
+		result1 []cc_messages.LRPInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) BulkLRPStatus(processGuids []string) (map[string][]cc_messages.LRPInstance, error) {
+	fake.bulkLRPStatusMutex.Lock()
+	fake.bulkLRPStatusArgsForCall = append(fake.bulkLRPStatusArgsForCall, struct {
+		processGuids []string
+	}{processGuids})
+	fake.bulkLRPStatusMutex.Unlock()
+	if fake.BulkLRPStatusStub != nil {
+		return fake.BulkLRPStatusStub(processGuids)
+	} else {
+		return fake.bulkLRPStatusReturns.result1, fake.bulkLRPStatusReturns.result2
+	}
+}
+
+func (fake *FakeClient) BulkLRPStatusCallCount() int {
+	fake.bulkLRPStatusMutex.RLock()
+	defer fake.bulkLRPStatusMutex.RUnlock()
+	return len(fake.bulkLRPStatusArgsForCall)
+}
+
+func (fake *FakeClient) BulkLRPStatusArgsForCall(i int) []string {
+	fake.bulkLRPStatusMutex.RLock()
+	defer fake.bulkLRPStatusMutex.RUnlock()
+	return fake.bulkLRPStatusArgsForCall[i].processGuids
+}
+
+func (fake *FakeClient) BulkLRPStatusReturns(result1 map[string][]cc_messages.LRPInstance, result2 error) {
+	fake.BulkLRPStatusStub = nil
+	fake.bulkLRPStatusReturns = struct {
+		result1 map[string][]cc_messages.LRPInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) LRPEvents(processGuid string, page int, perPage int) (tps.LRPEvents, error) {
+	fake.lrpEventsMutex.Lock()
+	fake.lrpEventsArgsForCall = append(fake.lrpEventsArgsForCall, struct {
+		processGuid string
+		page        int
+		perPage     int
+	}{processGuid, page, perPage})
+	fake.lrpEventsMutex.Unlock()
+	if fake.LRPEventsStub != nil {
+		return fake.LRPEventsStub(processGuid, page, perPage)
+	} else {
+		return fake.lrpEventsReturns.result1, fake.lrpEventsReturns.result2
+	}
+}
+
+func (fake *FakeClient) LRPEventsCallCount() int {
+	fake.lrpEventsMutex.RLock()
+	defer fake.lrpEventsMutex.RUnlock()
+	return len(fake.lrpEventsArgsForCall)
+}
+
+func (fake *FakeClient) LRPEventsArgsForCall(i int) (string, int, int) {
+	fake.lrpEventsMutex.RLock()
+	defer fake.lrpEventsMutex.RUnlock()
+	return fake.lrpEventsArgsForCall[i].processGuid, fake.lrpEventsArgsForCall[i].page, fake.lrpEventsArgsForCall[i].perPage
+}
+
+func (fake *FakeClient) LRPEventsReturns(result1 tps.LRPEvents, result2 error) {
+	fake.LRPEventsStub = nil
+	fake.lrpEventsReturns = struct {
+		result1 tps.LRPEvents
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) LRPInstanceStatus(processGuid string, index uint) (cc_messages.LRPInstance, error) {
+	fake.lrpInstanceStatusMutex.Lock()
+	fake.lrpInstanceStatusArgsForCall = append(fake.lrpInstanceStatusArgsForCall, struct {
+		processGuid string
+		index       uint
+	}{processGuid, index})
+	fake.lrpInstanceStatusMutex.Unlock()
+	if fake.LRPInstanceStatusStub != nil {
+		return fake.LRPInstanceStatusStub(processGuid, index)
+	} else {
+		return fake.lrpInstanceStatusReturns.result1, fake.lrpInstanceStatusReturns.result2
+	}
+}
+
+func (fake *FakeClient) LRPInstanceStatusCallCount() int {
+	fake.lrpInstanceStatusMutex.RLock()
+	defer fake.lrpInstanceStatusMutex.RUnlock()
+	return len(fake.lrpInstanceStatusArgsForCall)
+}
+
+func (fake *FakeClient) LRPInstanceStatusArgsForCall(i int) (string, uint) {
+	fake.lrpInstanceStatusMutex.RLock()
+	defer fake.lrpInstanceStatusMutex.RUnlock()
+	return fake.lrpInstanceStatusArgsForCall[i].processGuid, fake.lrpInstanceStatusArgsForCall[i].index
+}
+
+func (fake *FakeClient) LRPInstanceStatusReturns(result1 cc_messages.LRPInstance, result2 error) {
+	fake.LRPInstanceStatusStub = nil
+	fake.lrpInstanceStatusReturns = struct {
+		result1 cc_messages.LRPInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) RestartLRPInstance(processGuid string, index uint, authorization string) error {
+	fake.restartLRPInstanceMutex.Lock()
+	fake.restartLRPInstanceArgsForCall = append(fake.restartLRPInstanceArgsForCall, struct {
+		processGuid   string
+		index         uint
+		authorization string
+	}{processGuid, index, authorization})
+	fake.restartLRPInstanceMutex.Unlock()
+	if fake.RestartLRPInstanceStub != nil {
+		return fake.RestartLRPInstanceStub(processGuid, index, authorization)
+	} else {
+		return fake.restartLRPInstanceReturns.result1
+	}
+}
+
+func (fake *FakeClient) RestartLRPInstanceCallCount() int {
+	fake.restartLRPInstanceMutex.RLock()
+	defer fake.restartLRPInstanceMutex.RUnlock()
+	return len(fake.restartLRPInstanceArgsForCall)
+}
+
+func (fake *FakeClient) RestartLRPInstanceArgsForCall(i int) (string, uint, string) {
+	fake.restartLRPInstanceMutex.RLock()
+	defer fake.restartLRPInstanceMutex.RUnlock()
+	return fake.restartLRPInstanceArgsForCall[i].processGuid, fake.restartLRPInstanceArgsForCall[i].index, fake.restartLRPInstanceArgsForCall[i].authorization
+}
+
+func (fake *FakeClient) RestartLRPInstanceReturns(result1 error) {
+	fake.RestartLRPInstanceStub = nil
+	fake.restartLRPInstanceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) NamespaceLRPs(namespace string, opts tps.ListLRPsOptions) (tps.LRPList, error) {
+	fake.namespaceLRPsMutex.Lock()
+	fake.namespaceLRPsArgsForCall = append(fake.namespaceLRPsArgsForCall, struct {
+		namespace string
+		opts      tps.ListLRPsOptions
+	}{namespace, opts})
+	fake.namespaceLRPsMutex.Unlock()
+	if fake.NamespaceLRPsStub != nil {
+		return fake.NamespaceLRPsStub(namespace, opts)
+	} else {
+		return fake.namespaceLRPsReturns.result1, fake.namespaceLRPsReturns.result2
+	}
+}
+
+func (fake *FakeClient) NamespaceLRPsCallCount() int {
+	fake.namespaceLRPsMutex.RLock()
+	defer fake.namespaceLRPsMutex.RUnlock()
+	return len(fake.namespaceLRPsArgsForCall)
+}
+
+func (fake *FakeClient) NamespaceLRPsArgsForCall(i int) (string, tps.ListLRPsOptions) {
+	fake.namespaceLRPsMutex.RLock()
+	defer fake.namespaceLRPsMutex.RUnlock()
+	return fake.namespaceLRPsArgsForCall[i].namespace, fake.namespaceLRPsArgsForCall[i].opts
+}
+
+func (fake *FakeClient) NamespaceLRPsReturns(result1 tps.LRPList, result2 error) {
+	fake.NamespaceLRPsStub = nil
+	fake.namespaceLRPsReturns = struct {
+		result1 tps.LRPList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AllLRPs(opts tps.ListLRPsOptions) (tps.LRPList, error) {
+	fake.allLRPsMutex.Lock()
+	fake.allLRPsArgsForCall = append(fake.allLRPsArgsForCall, struct {
+		opts tps.ListLRPsOptions
+	}{opts})
+	fake.allLRPsMutex.Unlock()
+	if fake.AllLRPsStub != nil {
+		return fake.AllLRPsStub(opts)
+	} else {
+		return fake.allLRPsReturns.result1, fake.allLRPsReturns.result2
+	}
+}
+
+func (fake *FakeClient) AllLRPsCallCount() int {
+	fake.allLRPsMutex.RLock()
+	defer fake.allLRPsMutex.RUnlock()
+	return len(fake.allLRPsArgsForCall)
+}
+
+func (fake *FakeClient) AllLRPsArgsForCall(i int) tps.ListLRPsOptions {
+	fake.allLRPsMutex.RLock()
+	defer fake.allLRPsMutex.RUnlock()
+	return fake.allLRPsArgsForCall[i].opts
+}
+
+func (fake *FakeClient) AllLRPsReturns(result1 tps.LRPList, result2 error) {
+	fake.AllLRPsStub = nil
+	fake.allLRPsReturns = struct {
+		result1 tps.LRPList
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) AppSummary(processGuid string) (tps.AppSummary, error) {
+	fake.appSummaryMutex.Lock()
+	fake.appSummaryArgsForCall = append(fake.appSummaryArgsForCall, struct {
+		processGuid string
+	}{processGuid})
+	fake.appSummaryMutex.Unlock()
+	if fake.AppSummaryStub != nil {
+		return fake.AppSummaryStub(processGuid)
+	} else {
+		return fake.appSummaryReturns.result1, fake.appSummaryReturns.result2
+	}
+}
+
+func (fake *FakeClient) AppSummaryCallCount() int {
+	fake.appSummaryMutex.RLock()
+	defer fake.appSummaryMutex.RUnlock()
+	return len(fake.appSummaryArgsForCall)
+}
+
+func (fake *FakeClient) AppSummaryArgsForCall(i int) string {
+	fake.appSummaryMutex.RLock()
+	defer fake.appSummaryMutex.RUnlock()
+	return fake.appSummaryArgsForCall[i].processGuid
+}
+
+func (fake *FakeClient) AppSummaryReturns(result1 tps.AppSummary, result2 error) {
+	fake.AppSummaryStub = nil
+	fake.appSummaryReturns = struct {
+		result1 tps.AppSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) BulkAppSummary(processGuids []string) (map[string]tps.AppSummary, error) {
+	fake.bulkAppSummaryMutex.Lock()
+	fake.bulkAppSummaryArgsForCall = append(fake.bulkAppSummaryArgsForCall, struct {
+		processGuids []string
+	}{processGuids})
+	fake.bulkAppSummaryMutex.Unlock()
+	if fake.BulkAppSummaryStub != nil {
+		return fake.BulkAppSummaryStub(processGuids)
+	} else {
+		return fake.bulkAppSummaryReturns.result1, fake.bulkAppSummaryReturns.result2
+	}
+}
+
+func (fake *FakeClient) BulkAppSummaryCallCount() int {
+	fake.bulkAppSummaryMutex.RLock()
+	defer fake.bulkAppSummaryMutex.RUnlock()
+	return len(fake.bulkAppSummaryArgsForCall)
+}
+
+func (fake *FakeClient) BulkAppSummaryArgsForCall(i int) []string {
+	fake.bulkAppSummaryMutex.RLock()
+	defer fake.bulkAppSummaryMutex.RUnlock()
+	return fake.bulkAppSummaryArgsForCall[i].processGuids
+}
+
+func (fake *FakeClient) BulkAppSummaryReturns(result1 map[string]tps.AppSummary, result2 error) {
+	fake.BulkAppSummaryStub = nil
+	fake.bulkAppSummaryReturns = struct {
+		result1 map[string]tps.AppSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) LRPStatusV2(processGuid string) (tps.LRPStatus, error) {
+	fake.lrpStatusV2Mutex.Lock()
+	fake.lrpStatusV2ArgsForCall = append(fake.lrpStatusV2ArgsForCall, struct {
+		processGuid string
+	}{processGuid})
+	fake.lrpStatusV2Mutex.Unlock()
+	if fake.LRPStatusV2Stub != nil {
+		return fake.LRPStatusV2Stub(processGuid)
+	} else {
+		return fake.lrpStatusV2Returns.result1, fake.lrpStatusV2Returns.result2
+	}
+}
+
+func (fake *FakeClient) LRPStatusV2CallCount() int {
+	fake.lrpStatusV2Mutex.RLock()
+	defer fake.lrpStatusV2Mutex.RUnlock()
+	return len(fake.lrpStatusV2ArgsForCall)
+}
+
+func (fake *FakeClient) LRPStatusV2ArgsForCall(i int) string {
+	fake.lrpStatusV2Mutex.RLock()
+	defer fake.lrpStatusV2Mutex.RUnlock()
+	return fake.lrpStatusV2ArgsForCall[i].processGuid
+}
+
+func (fake *FakeClient) LRPStatusV2Returns(result1 tps.LRPStatus, result2 error) {
+	fake.LRPStatusV2Stub = nil
+	fake.lrpStatusV2Returns = struct {
+		result1 tps.LRPStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) LRPStatsV2(processGuid string, authorization string) (tps.LRPStatus, error) {
+	fake.lrpStatsV2Mutex.Lock()
+	fake.lrpStatsV2ArgsForCall = append(fake.lrpStatsV2ArgsForCall, struct {
+		processGuid   string
+		authorization string
+	}{processGuid, authorization})
+	fake.lrpStatsV2Mutex.Unlock()
+	if fake.LRPStatsV2Stub != nil {
+		return fake.LRPStatsV2Stub(processGuid, authorization)
+	} else {
+		return fake.lrpStatsV2Returns.result1, fake.lrpStatsV2Returns.result2
+	}
+}
+
+func (fake *FakeClient) LRPStatsV2CallCount() int {
+	fake.lrpStatsV2Mutex.RLock()
+	defer fake.lrpStatsV2Mutex.RUnlock()
+	return len(fake.lrpStatsV2ArgsForCall)
+}
+
+func (fake *FakeClient) LRPStatsV2ArgsForCall(i int) (string, string) {
+	fake.lrpStatsV2Mutex.RLock()
+	defer fake.lrpStatsV2Mutex.RUnlock()
+	return fake.lrpStatsV2ArgsForCall[i].processGuid, fake.lrpStatsV2ArgsForCall[i].authorization
+}
+
+func (fake *FakeClient) LRPStatsV2Returns(result1 tps.LRPStatus, result2 error) {
+	fake.LRPStatsV2Stub = nil
+	fake.lrpStatsV2Returns = struct {
+		result1 tps.LRPStatus
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) BulkLRPStatusV2(processGuids []string) (map[string]tps.LRPStatus, error) {
+	fake.bulkLRPStatusV2Mutex.Lock()
+	fake.bulkLRPStatusV2ArgsForCall = append(fake.bulkLRPStatusV2ArgsForCall, struct {
+		processGuids []string
+	}{processGuids})
+	fake.bulkLRPStatusV2Mutex.Unlock()
+	if fake.BulkLRPStatusV2Stub != nil {
+		return fake.BulkLRPStatusV2Stub(processGuids)
+	} else {
+		return fake.bulkLRPStatusV2Returns.result1, fake.bulkLRPStatusV2Returns.result2
+	}
+}
+
+func (fake *FakeClient) BulkLRPStatusV2CallCount() int {
+	fake.bulkLRPStatusV2Mutex.RLock()
+	defer fake.bulkLRPStatusV2Mutex.RUnlock()
+	return len(fake.bulkLRPStatusV2ArgsForCall)
+}
+
+func (fake *FakeClient) BulkLRPStatusV2ArgsForCall(i int) []string {
+	fake.bulkLRPStatusV2Mutex.RLock()
+	defer fake.bulkLRPStatusV2Mutex.RUnlock()
+	return fake.bulkLRPStatusV2ArgsForCall[i].processGuids
+}
+
+func (fake *FakeClient) BulkLRPStatusV2Returns(result1 map[string]tps.LRPStatus, result2 error) {
+	fake.BulkLRPStatusV2Stub = nil
+	fake.bulkLRPStatusV2Returns = struct {
+		result1 map[string]tps.LRPStatus
+		result2 error
+	}{result1, result2}
+}
+
+var _ tps.Client = new(FakeClient)
@@ -0,0 +1,170 @@
+package appsummary
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/cloudfoundry/gunk/workpool"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+type handler struct {
+	podSource      podsource.PodSource
+	clock          clock.Clock
+	stateConfig    lrpstatus.StateConfig
+	requestTimeout time.Duration
+	logger         lager.Logger
+}
+
+type bulkHandler struct {
+	podSource      podsource.PodSource
+	clock          clock.Clock
+	stateConfig    lrpstatus.StateConfig
+	workPoolSize   int
+	requestTimeout time.Duration
+	logger         lager.Logger
+}
+
+// serves the tps.AppSummary of a single process guid
+func NewHandler(podSource podsource.PodSource, clk clock.Clock, stateConfig lrpstatus.StateConfig, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:      podSource,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+	}
+}
+
+// serves a tps.AppSummary per process guid in the guids query parameter,
+// leaving out the guids whose processes cannot be fetched
+func NewBulkHandler(podSource podsource.PodSource, clk clock.Clock, stateConfig lrpstatus.StateConfig, workPoolSize int, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &bulkHandler{
+		podSource:      podSource,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		workPoolSize:   workPoolSize,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+	}
+}
+
+func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	guid := r.FormValue(":guid")
+	logger := handler.logger.Session("app-summary", lager.Data{"process-guid": guid})
+
+	pg, err := helpers.NewProcessGuid(guid)
+	if err != nil {
+		logger.Error("invalid-process-guid", err)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidProcessGuid, err.Error())
+		return
+	}
+
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
+	process, err := tpshelpers.FetchProcess(ctx, handler.podSource, pg.ShortenedGuid())
+	switch err {
+	case nil:
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	default:
+		logger.Error("failed-fetching-process", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
+
+	if !process.Exists() {
+		logger.Info("process-not-found")
+		tpshelpers.WriteError(w, http.StatusNotFound, tps.ProcessNotFound, "no instances found for process guid "+guid)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(Summarize(pg, process, handler.clock, handler.stateConfig))
+	if err != nil {
+		logger.Error("stream-response-failed", err)
+	}
+}
+
+func (handler *bulkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := handler.logger.Session("bulk-app-summary")
+
+	guidParameter := r.FormValue("guids")
+	if !tpshelpers.ProcessGuidsPattern.MatchString(guidParameter) {
+		logger.Error("failed-parsing-guids", nil, lager.Data{"guid-parameter": guidParameter})
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidRequest, "guids must be a comma separated list of process guids")
+		return
+	}
+
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
+	summaries := map[string]tps.AppSummary{}
+	summariesLock := sync.Mutex{}
+
+	works := []func(){}
+	for _, guid := range strings.Split(guidParameter, ",") {
+		guid := guid
+		works = append(works, func() {
+			logger := logger.Session("fetching-process", lager.Data{"process-guid": guid})
+
+			pg, err := helpers.NewProcessGuid(guid)
+			if err != nil {
+				logger.Error("invalid-process-guid", err)
+				return
+			}
+
+			process, err := tpshelpers.FetchProcess(ctx, handler.podSource, pg.ShortenedGuid())
+			if err != nil {
+				logger.Error("failed-fetching-process", err)
+				return
+			}
+			if !process.Exists() {
+				return
+			}
+
+			summary := Summarize(pg, process, handler.clock, handler.stateConfig)
+
+			summariesLock.Lock()
+			summaries[guid] = summary
+			summariesLock.Unlock()
+		})
+	}
+
+	throttler, err := workpool.NewThrottler(handler.workPoolSize, works)
+	if err != nil {
+		logger.Error("failed-constructing-throttler", err, lager.Data{"max-workers": handler.workPoolSize, "num-works": len(works)})
+		tpshelpers.WriteError(w, http.StatusInternalServerError, tps.InternalError, err.Error())
+		return
+	}
+
+	throttler.Work()
+
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		logger.Error("fetching-processes-timed-out", tpshelpers.ErrTimedOut)
+		tpshelpers.WriteUpstreamError(w, tpshelpers.ErrTimedOut)
+		return
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(summaries)
+	if err != nil {
+		logger.Error("stream-response-failed", err)
+	}
+}
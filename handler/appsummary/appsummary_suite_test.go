@@ -0,0 +1,13 @@
+package appsummary_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAppsummary(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Appsummary Suite")
+}
@@ -0,0 +1,244 @@
+package appsummary_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/handler/appsummary"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	podsourcefakes "github.com/cloudfoundry-incubator/tps/podsource/fakes"
+	"github.com/nu7hatch/gouuid"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AppSummary", func() {
+	var (
+		source      *podsource.MemorySource
+		fakeClock   *fakeclock.FakeClock
+		handler     http.Handler
+		response    *httptest.ResponseRecorder
+		request     *http.Request
+		processGuid helpers.ProcessGuid
+		logger      *lagertest.TestLogger
+	)
+
+	newPod := func(uid string, age time.Duration, restarts int32, state v1.ContainerState) v1.Pod {
+		startTime := unversioned.NewTime(fakeClock.Now().Add(-age))
+		return v1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "pod-" + uid,
+				Namespace: "namespace",
+				UID:       v1.UID(uid),
+				Labels: map[string]string{
+					"cloudfoundry.org/process-guid": processGuid.ShortenedGuid(),
+				},
+			},
+			Status: v1.PodStatus{
+				StartTime: &startTime,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:         "application",
+					State:        state,
+					Ready:        true,
+					RestartCount: restarts,
+				}},
+			},
+		}
+	}
+
+	running := v1.ContainerState{Running: &v1.ContainerStateRunning{}}
+
+	desire := func(replicas int32) {
+		source.AddReplicationController(v1.ReplicationController{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "rc",
+				Namespace: "namespace",
+				Labels: map[string]string{
+					"cloudfoundry.org/process-guid": processGuid.ShortenedGuid(),
+				},
+			},
+			Spec: v1.ReplicationControllerSpec{Replicas: helpers.Int32Ptr(replicas)},
+		})
+	}
+
+	BeforeEach(func() {
+		var err error
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		appGuid, _ := uuid.NewV4()
+		appVersion, _ := uuid.NewV4()
+		processGuid, err = helpers.NewProcessGuid(appGuid.String() + "-" + appVersion.String())
+		Expect(err).NotTo(HaveOccurred())
+
+		source = podsource.NewMemorySource()
+
+		response = httptest.NewRecorder()
+	})
+
+	JustBeforeEach(func() {
+		handler.ServeHTTP(response, request)
+	})
+
+	Describe("the summary of a single process", func() {
+		BeforeEach(func() {
+			handler = appsummary.NewHandler(source, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+
+			var err error
+			request, err = http.NewRequest("GET", "", nil)
+			Expect(err).NotTo(HaveOccurred())
+			request.Form = url.Values{":guid": []string{processGuid.String()}}
+		})
+
+		Context("when some desired instances are not running", func() {
+			BeforeEach(func() {
+				desire(4)
+				source.AddPod(
+					newPod("1", time.Hour, 0, running),
+					newPod("2", 10*time.Minute, 2, running),
+					newPod("3", time.Minute, 5, v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+					}),
+				)
+			})
+
+			It("counts the instances by state", func() {
+				var summary tps.AppSummary
+				Expect(response.Code).To(Equal(http.StatusOK))
+				err := json.NewDecoder(response.Body).Decode(&summary)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(summary).To(Equal(tps.AppSummary{
+					ProcessGuid:       processGuid.String(),
+					Desired:           4,
+					Running:           2,
+					Crashed:           1,
+					Down:              1,
+					TotalRestarts:     7,
+					OldestInstanceAge: 3600,
+					NewestInstanceAge: 60,
+					Health:            tps.AppDegraded,
+				}))
+			})
+		})
+
+		Context("when every desired instance is running", func() {
+			BeforeEach(func() {
+				desire(1)
+				source.AddPod(newPod("1", time.Hour, 0, running))
+			})
+
+			It("reports the app as healthy", func() {
+				var summary tps.AppSummary
+				err := json.NewDecoder(response.Body).Decode(&summary)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(summary.Health).To(Equal(tps.AppHealthy))
+			})
+		})
+
+		Context("when no instance is running", func() {
+			BeforeEach(func() {
+				desire(2)
+			})
+
+			It("reports the app as down", func() {
+				var summary tps.AppSummary
+				err := json.NewDecoder(response.Body).Decode(&summary)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(summary.Down).To(Equal(2))
+				Expect(summary.Health).To(Equal(tps.AppDown))
+			})
+		})
+
+		Context("when the process does not exist", func() {
+			It("responds with a 404", func() {
+				Expect(response.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+
+		Context("when the process guid is malformed", func() {
+			BeforeEach(func() {
+				request.Form = url.Values{":guid": []string{"not-a-process-guid"}}
+			})
+
+			It("responds with a 400", func() {
+				Expect(response.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("when kubernetes cannot be reached", func() {
+			BeforeEach(func() {
+				source.FailWith(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			})
+
+			It("responds with a 503", func() {
+				Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+	})
+
+	Describe("the bulk summary", func() {
+		var otherProcessGuid helpers.ProcessGuid
+
+		BeforeEach(func() {
+
+			appGuid, _ := uuid.NewV4()
+			appVersion, _ := uuid.NewV4()
+			var err error
+			otherProcessGuid, err = helpers.NewProcessGuid(appGuid.String() + "-" + appVersion.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			request, err = http.NewRequest("GET", "/v1/bulk_app_summary?guids="+processGuid.String()+","+otherProcessGuid.String(), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			source.AddPod(newPod("1", time.Hour, 1, running))
+			failingSource := &podsourcefakes.FakePodSource{
+				PodsStub: func(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+					if selector.Matches(labels.Set{"cloudfoundry.org/process-guid": otherProcessGuid.ShortenedGuid()}) {
+						return nil, errors.New("boom")
+					}
+					return source.Pods(namespace, selector)
+				},
+				ReplicationControllersStub: source.ReplicationControllers,
+				ReplicaSetsStub:            source.ReplicaSets,
+			}
+			handler = appsummary.NewBulkHandler(failingSource, fakeClock, lrpstatus.StateConfig{}, 5, time.Minute, logger)
+		})
+
+		It("returns a summary per process guid, leaving out the ones that failed", func() {
+			summaries := map[string]tps.AppSummary{}
+			Expect(response.Code).To(Equal(http.StatusOK))
+			err := json.NewDecoder(response.Body).Decode(&summaries)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(summaries).To(HaveLen(1))
+			Expect(summaries[processGuid.String()].Running).To(Equal(1))
+			Expect(summaries[processGuid.String()].TotalRestarts).To(BeEquivalentTo(1))
+		})
+
+		Context("with malformed process guids", func() {
+			BeforeEach(func() {
+				request.URL.RawQuery = "guids=a,,b"
+			})
+
+			It("responds with a 400", func() {
+				Expect(response.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+})
@@ -0,0 +1,67 @@
+package appsummary
+
+import (
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/pivotal-golang/clock"
+)
+
+// counts the instances reported by lrpstatus.ProcessInstances, so the
+// summary agrees with the status routes
+func Summarize(pg helpers.ProcessGuid, process *tpshelpers.Process, clk clock.Clock, stateConfig lrpstatus.StateConfig) tps.AppSummary {
+	summary := tps.AppSummary{
+		ProcessGuid: pg.String(),
+		Desired:     process.DesiredInstances,
+	}
+
+	now := clk.Now().Unix()
+	aged := false
+	for _, instance := range lrpstatus.ProcessInstances(pg, process, clk, stateConfig) {
+		switch instance.State {
+		case cc_messages.LRPInstanceStateRunning:
+			summary.Running++
+		case cc_messages.LRPInstanceStateStarting:
+			summary.Starting++
+		case cc_messages.LRPInstanceStateCrashed:
+			summary.Crashed++
+		case cc_messages.LRPInstanceStateDown:
+			summary.Down++
+		default:
+			summary.Unknown++
+		}
+
+		summary.TotalRestarts += instance.RestartCount
+
+		// placeholders for missing instances have no pod to age
+		if instance.PodStartTime == 0 {
+			continue
+		}
+		age := now - instance.PodStartTime
+		if age > summary.OldestInstanceAge {
+			summary.OldestInstanceAge = age
+		}
+		if !aged || age < summary.NewestInstanceAge {
+			summary.NewestInstanceAge = age
+		}
+		aged = true
+	}
+
+	summary.Health = health(summary)
+	return summary
+}
+
+func health(summary tps.AppSummary) string {
+	switch {
+	case summary.Desired == 0 && summary.Running == 0:
+		return tps.AppStopped
+	case summary.Running == 0:
+		return tps.AppDown
+	case summary.Running < summary.Desired:
+		return tps.AppDegraded
+	default:
+		return tps.AppHealthy
+	}
+}
@@ -1,38 +1,58 @@
 package bulklrpstatus
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
-	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry-incubator/nsync/helpers"
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/cloudfoundry-incubator/tps/topology"
 	"github.com/cloudfoundry/gunk/workpool"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
-	"k8s.io/kubernetes/pkg/api"
-	v1core "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1"
-	"k8s.io/kubernetes/pkg/labels"
 )
 
-var processGuidPattern = regexp.MustCompile(`^([a-zA-Z0-9_-]+,)*[a-zA-Z0-9_-]+$`)
-
 type handler struct {
-	k8sClient                 v1core.CoreInterface
+	podSource                 podsource.PodSource
+	nodes                     topology.NodeListers
 	clock                     clock.Clock
+	stateConfig               lrpstatus.StateConfig
 	logger                    lager.Logger
 	bulkLRPStatusWorkPoolSize int
+	requestTimeout            time.Duration
+	detailed                  bool
+}
+
+func NewHandler(podSource podsource.PodSource, clk clock.Clock, stateConfig lrpstatus.StateConfig, bulkLRPStatusWorkPoolSize int, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:                 podSource,
+		clock:                     clk,
+		stateConfig:               stateConfig,
+		bulkLRPStatusWorkPoolSize: bulkLRPStatusWorkPoolSize,
+		requestTimeout:            requestTimeout,
+		logger:                    logger,
+	}
 }
 
-func NewHandler(k8sClient v1core.CoreInterface, clk clock.Clock, bulkLRPStatusWorkPoolSize int, logger lager.Logger) http.Handler {
+// the v2 handler responds with a tps.LRPStatus per process guid
+func NewV2Handler(podSource podsource.PodSource, nodes topology.NodeListers, clk clock.Clock, stateConfig lrpstatus.StateConfig, bulkLRPStatusWorkPoolSize int, requestTimeout time.Duration, logger lager.Logger) http.Handler {
 	return &handler{
-		k8sClient: k8sClient,
-		clock:     clk,
+		podSource:                 podSource,
+		nodes:                     nodes,
+		clock:                     clk,
+		stateConfig:               stateConfig,
 		bulkLRPStatusWorkPoolSize: bulkLRPStatusWorkPoolSize,
-		logger: logger,
+		requestTimeout:            requestTimeout,
+		detailed:                  true,
+		logger:                    logger,
 	}
 }
 
@@ -40,41 +60,66 @@ func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	logger := handler.logger.Session("bulk-lrp-status")
 
 	guidParameter := r.FormValue("guids")
-	if !processGuidPattern.Match([]byte(guidParameter)) {
+	if !tpshelpers.ProcessGuidsPattern.Match([]byte(guidParameter)) {
 		logger.Error("failed-parsing-guids", nil, lager.Data{"guid-parameter": guidParameter})
-		w.WriteHeader(http.StatusBadRequest)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidRequest, "guids must be a comma separated list of process guids")
 		return
 	}
 
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
 	guids := strings.Split(guidParameter, ",")
 	works := []func(){}
 
-	statusBundle := make(map[string][]cc_messages.LRPInstance)
+	statusBundle := make(map[string]tps.LRPStatus)
 	statusLock := sync.Mutex{}
 
 	for _, processGuid := range guids {
-		works = append(works, handler.getStatusForLRPWorkFunction(logger, processGuid, &statusLock, statusBundle))
+		works = append(works, handler.getStatusForLRPWorkFunction(ctx, logger, processGuid, &statusLock, statusBundle))
 	}
 
 	throttler, err := workpool.NewThrottler(handler.bulkLRPStatusWorkPoolSize, works)
 	if err != nil {
 		logger.Error("failed-constructing-throttler", err, lager.Data{"max-workers": handler.bulkLRPStatusWorkPoolSize, "num-works": len(works)})
-		w.WriteHeader(http.StatusInternalServerError)
+		tpshelpers.WriteError(w, http.StatusInternalServerError, tps.InternalError, err.Error())
 		return
 	}
 
 	throttler.Work()
 
-	w.WriteHeader(http.StatusOK)
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		logger.Error("fetching-actual-lrps-info-timed-out", tpshelpers.ErrTimedOut)
+		tpshelpers.WriteUpstreamError(w, tpshelpers.ErrTimedOut)
+		return
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
-	err = json.NewEncoder(w).Encode(statusBundle)
+	err = json.NewEncoder(w).Encode(handler.response(statusBundle))
 	if err != nil {
 		logger.Error("stream-response-failed", err, nil)
 	}
 }
 
-func (handler *handler) getStatusForLRPWorkFunction(logger lager.Logger, processGuid string, statusLock *sync.Mutex, statusBundle map[string][]cc_messages.LRPInstance) func() {
+func (handler *handler) response(statusBundle map[string]tps.LRPStatus) interface{} {
+	if handler.detailed {
+		return statusBundle
+	}
+
+	ccStatusBundle := make(map[string][]cc_messages.LRPInstance, len(statusBundle))
+	for guid, status := range statusBundle {
+		ccStatusBundle[guid] = lrpstatus.CCInstances(status.Instances)
+	}
+	return ccStatusBundle
+}
+
+func (handler *handler) getStatusForLRPWorkFunction(ctx context.Context, logger lager.Logger, processGuid string, statusLock *sync.Mutex, statusBundle map[string]tps.LRPStatus) func() {
 	return func() {
 		logger = logger.Session("fetching-actual-lrps-info", lager.Data{"process-guid": processGuid})
 		logger.Info("start")
@@ -85,22 +130,32 @@ func (handler *handler) getStatusForLRPWorkFunction(logger lager.Logger, process
 			logger.Error("invalid-process-guid", err)
 			return
 		}
-		actualLRPGroups, err := handler.k8sClient.Pods(api.NamespaceAll).List(api.ListOptions{
-			LabelSelector: labels.Set{"cloudfoundry.org/process-guid": pg.ShortenedGuid()}.AsSelector(),
-		})
+		process, err := tpshelpers.FetchProcess(ctx, handler.podSource, pg.ShortenedGuid())
 		if err != nil {
 			logger.Error("fetching-actual-lrps-info-failed", err)
 			return
 		}
 
-		instances := lrpstatus.LRPInstances(actualLRPGroups.Items,
-			handler.clock,
-		)
+		instances := lrpstatus.ProcessInstances(pg, process, handler.clock, handler.stateConfig)
+		if len(instances) == 0 {
+			return
+		}
 
-		statusLock.Lock()
-		if instances != nil {
-			statusBundle[processGuid] = instances
+		status := tps.LRPStatus{ProcessGuid: processGuid, Instances: instances}
+		if handler.detailed {
+			// v2 callers are told which instances belong to other versions
+			// of the app so that a rolling update does not skew their counts
+			appPods, err := tpshelpers.ListAppPods(ctx, handler.podSource, pg.AppGuid.String())
+			if err != nil {
+				logger.Error("fetching-app-versions-failed", err)
+				return
+			}
+			status = lrpstatus.VersionedStatus(pg, instances, appPods, handler.clock, handler.stateConfig)
+			status.Zones = topology.Place(status.Instances, handler.nodes)
 		}
+
+		statusLock.Lock()
+		statusBundle[processGuid] = status
 		statusLock.Unlock()
 	}
 }
@@ -8,14 +8,18 @@ import (
 	"net/http/httptest"
 	"time"
 
-	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/unversioned"
 	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
 
 	"github.com/cloudfoundry-incubator/nsync/helpers"
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
 	"github.com/cloudfoundry-incubator/tps/handler/bulklrpstatus"
-	handlerfakes "github.com/cloudfoundry-incubator/tps/handler/handler_fakes"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	podsourcefakes "github.com/cloudfoundry-incubator/tps/podsource/fakes"
+	"github.com/cloudfoundry-incubator/tps/topology"
 	"github.com/nu7hatch/gouuid"
 	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager/lagertest"
@@ -36,10 +40,9 @@ var _ = Describe("Bulk Status", func() {
 		handler           http.Handler
 		response          *httptest.ResponseRecorder
 		request           *http.Request
-		fakeKubeClient    *handlerfakes.FakeKubeClient
+		source            *podsource.MemorySource
 		logger            *lagertest.TestLogger
 		fakeClock         *fakeclock.FakeClock
-		fakePod           *handlerfakes.FakePod
 		pod1              *v1.Pod
 		pod2              *v1.Pod
 		processGuid1      helpers.ProcessGuid
@@ -51,10 +54,10 @@ var _ = Describe("Bulk Status", func() {
 	BeforeEach(func() {
 		var err error
 
-		fakeKubeClient = &handlerfakes.FakeKubeClient{}
+		source = podsource.NewMemorySource()
 		logger = lagertest.NewTestLogger("test")
 		fakeClock = fakeclock.NewFakeClock(time.Date(2008, 8, 8, 8, 8, 8, 8, time.UTC))
-		handler = bulklrpstatus.NewHandler(fakeKubeClient, fakeClock, 15, logger)
+		handler = bulklrpstatus.NewHandler(source, fakeClock, lrpstatus.StateConfig{}, 15, time.Minute, logger)
 		response = httptest.NewRecorder()
 		url := "/v1/bulk_actual_lrp_status"
 		request, err = http.NewRequest("GET", url, nil)
@@ -66,7 +69,6 @@ var _ = Describe("Bulk Status", func() {
 		processGuid2, err = generateProcessGuid()
 		Expect(err).NotTo(HaveOccurred())
 
-		fakePod = &handlerfakes.FakePod{}
 		expectedSinceTime = fakeClock.Now()
 		actualSinceTime = fakeClock.Now()
 		fakeClock.Increment(5 * time.Second)
@@ -172,6 +174,13 @@ var _ = Describe("Bulk Status", func() {
 			It("fails", func() {
 				Expect(response.Code).To(Equal(http.StatusBadRequest))
 			})
+
+			It("describes the error in the body", func() {
+				var tpsErr tps.Error
+				err := json.Unmarshal(response.Body.Bytes(), &tpsErr)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tpsErr.Code).To(Equal(tps.InvalidRequest))
+			})
 		})
 	})
 
@@ -199,20 +208,7 @@ var _ = Describe("Bulk Status", func() {
 			query.Set("guids", fmt.Sprintf("%s,%s", processGuid1.String(), processGuid2.String()))
 			request.URL.RawQuery = query.Encode()
 
-			fakeKubeClient.PodsReturns(fakePod)
-			fakePod.ListStub = func(opts api.ListOptions) (*v1.PodList, error) {
-				if opts.LabelSelector.String() == "cloudfoundry.org/process-guid="+processGuid1.ShortenedGuid() {
-					// return pod1
-					return &v1.PodList{
-						Items: []v1.Pod{*pod1},
-					}, nil
-				} else {
-					return &v1.PodList{
-						Items: []v1.Pod{*pod2},
-					}, nil
-				}
-			}
-
+			source.AddPod(*pod1, *pod2)
 		})
 
 		Context("when the LRPs have been running for a while", func() {
@@ -249,21 +245,80 @@ var _ = Describe("Bulk Status", func() {
 			})
 		})
 
-		Context("when fetching one of the actualLRPs fails", func() {
+		Context("when serving the v2 route", func() {
 			BeforeEach(func() {
-				fakeKubeClient.PodsReturns(fakePod)
-				fakePod.ListStub = func(opts api.ListOptions) (*v1.PodList, error) {
-					if opts.LabelSelector.String() == "cloudfoundry.org/process-guid="+processGuid1.ShortenedGuid() {
-						// return pod1
-						return &v1.PodList{
-							Items: []v1.Pod{*pod1},
-						}, nil
-					} else if opts.LabelSelector.String() == "cloudfoundry.org/process-guid="+processGuid2.ShortenedGuid() {
-						return nil, errors.New("boom")
-					} else {
-						return nil, errors.New("UNEXPECTED GUID YO")
+				handler = bulklrpstatus.NewV2Handler(source, topology.NodeListers{}, fakeClock, lrpstatus.StateConfig{}, 15, time.Minute, logger)
+			})
+
+			It("returns a status with detailed instances per process guid", func() {
+				status := make(map[string]tps.LRPStatus)
+
+				Expect(response.Code).To(Equal(http.StatusOK))
+				err := json.Unmarshal(response.Body.Bytes(), &status)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(status).To(HaveLen(2))
+				Expect(status[processGuid1.String()].ProcessGuid).To(Equal(processGuid1.String()))
+				Expect(status[processGuid1.String()].Instances).To(HaveLen(1))
+				Expect(status[processGuid1.String()].Instances[0].PodName).To(Equal("pod-name1"))
+				Expect(status[processGuid2.String()].Instances).To(HaveLen(1))
+				Expect(status[processGuid2.String()].Instances[0].PodName).To(Equal("pod-name2"))
+			})
+
+			Context("when an older version of an app is still running", func() {
+				var oldProcessGuid helpers.ProcessGuid
+
+				BeforeEach(func() {
+					oldVersion, err := uuid.NewV4()
+					Expect(err).NotTo(HaveOccurred())
+					oldProcessGuid, err = helpers.NewProcessGuid(processGuid1.AppGuid.String() + "-" + oldVersion.String())
+					Expect(err).NotTo(HaveOccurred())
+
+					oldPod := *pod2
+					oldPod.ObjectMeta.Name = "pod-name0"
+					oldPod.ObjectMeta.UID = "1234-5670"
+					oldPod.ObjectMeta.Labels = map[string]string{
+						"cloudfoundry.org/app-guid":     processGuid1.AppGuid.String(),
+						"cloudfoundry.org/process-guid": oldProcessGuid.ShortenedGuid(),
 					}
+
+					source.AddPod(oldPod)
+				})
+
+				It("reports the current version and counts the instances of each version", func() {
+					status := make(map[string]tps.LRPStatus)
+					err := json.Unmarshal(response.Body.Bytes(), &status)
+					Expect(err).NotTo(HaveOccurred())
+
+					currentVersion := lrpstatus.ProcessVersion(processGuid1)
+					oldVersion := lrpstatus.ProcessVersion(oldProcessGuid)
+
+					appStatus := status[processGuid1.String()]
+					Expect(appStatus.CurrentVersion).To(Equal(currentVersion))
+					Expect(appStatus.Versions).To(Equal(map[string]int{currentVersion: 1, oldVersion: 1}))
+
+					Expect(appStatus.Instances).To(HaveLen(2))
+					Expect(appStatus.Instances[0].Version).To(Equal(currentVersion))
+					Expect(appStatus.Instances[0].Current).To(BeTrue())
+					Expect(appStatus.Instances[1].Version).To(Equal(oldVersion))
+					Expect(appStatus.Instances[1].Current).To(BeFalse())
+				})
+			})
+		})
+
+		Context("when fetching one of the actualLRPs fails", func() {
+			BeforeEach(func() {
+				failingSource := &podsourcefakes.FakePodSource{
+					PodsStub: func(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+						if selector.Matches(labels.Set{"cloudfoundry.org/process-guid": processGuid2.ShortenedGuid()}) {
+							return nil, errors.New("boom")
+						}
+						return source.Pods(namespace, selector)
+					},
+					ReplicationControllersStub: source.ReplicationControllers,
+					ReplicaSetsStub:            source.ReplicaSets,
 				}
+				handler = bulklrpstatus.NewHandler(failingSource, fakeClock, lrpstatus.StateConfig{}, 15, time.Minute, logger)
 			})
 
 			It("it is excluded from the result and logs the failure", func() {
@@ -280,6 +335,32 @@ var _ = Describe("Bulk Status", func() {
 				Expect(logger).To(Say("fetching-actual-lrps-info-failed"))
 			})
 		})
+
+		Context("when fetching the actualLRPs takes longer than the request timeout", func() {
+			var unblock chan struct{}
+
+			BeforeEach(func() {
+				unblock = make(chan struct{})
+				blockingSource := &podsourcefakes.FakePodSource{
+					PodsStub: func(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+						<-unblock
+						return source.Pods(namespace, selector)
+					},
+					ReplicationControllersStub: source.ReplicationControllers,
+					ReplicaSetsStub:            source.ReplicaSets,
+				}
+				handler = bulklrpstatus.NewHandler(blockingSource, fakeClock, lrpstatus.StateConfig{}, 15, 10*time.Millisecond, logger)
+			})
+
+			AfterEach(func() {
+				close(unblock)
+			})
+
+			It("responds with a 504", func() {
+				Expect(response.Code).To(Equal(http.StatusGatewayTimeout))
+				Expect(logger).To(Say("fetching-actual-lrps-info-timed-out"))
+			})
+		})
 	})
 })
 
@@ -1,5 +1,5 @@
 // This file was generated by counterfeiter
-package handler_fakes
+package fakes
 
 import (
 	"net/http"
@@ -2,34 +2,80 @@ package handler
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/auth"
+	"github.com/cloudfoundry-incubator/tps/handler/appsummary"
 	"github.com/cloudfoundry-incubator/tps/handler/bulklrpstatus"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpevents"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpinstance"
+	"github.com/cloudfoundry-incubator/tps/handler/lrplist"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstats"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/cloudfoundry-incubator/tps/topology"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 	"github.com/tedsuo/rata"
-
-	v1core "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1"
 )
 
-func New(k8sClient v1core.CoreInterface, noaaClient lrpstats.NoaaClient, maxInFlight, bulkLRPStatusWorkers int, logger lager.Logger) (http.Handler, error) {
-	semaphore := make(chan struct{}, maxInFlight)
+func New(podSource podsource.PodSource, noaaClient lrpstats.NoaaClient, nodes topology.NodeListers, authorizer auth.Authorizer, inFlight *InFlightLimit, bulkLRPStatusWorkers int, requestTimeout, metricsTimeout time.Duration, stateConfig lrpstatus.StateConfig, logger lager.Logger) (http.Handler, error) {
 	clock := clock.NewClock()
 
 	handlers := map[string]http.Handler{
 		tps.LRPStatus: tpsHandler{
-			semaphore:       semaphore,
-			delegateHandler: LogWrap(lrpstatus.NewHandler(k8sClient, clock, logger), logger),
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrpstatus.NewHandler(podSource, clock, stateConfig, requestTimeout, logger), logger),
 		},
 		tps.LRPStats: tpsHandler{
-			semaphore:       semaphore,
-			delegateHandler: LogWrap(lrpstats.NewHandler(k8sClient, noaaClient, clock, logger), logger),
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrpstats.NewHandler(podSource, noaaClient, clock, stateConfig, requestTimeout, metricsTimeout, logger), logger),
 		},
 		tps.BulkLRPStatus: tpsHandler{
-			semaphore:       semaphore,
-			delegateHandler: LogWrap(bulklrpstatus.NewHandler(k8sClient, clock, bulkLRPStatusWorkers, logger), logger),
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(bulklrpstatus.NewHandler(podSource, clock, stateConfig, bulkLRPStatusWorkers, requestTimeout, logger), logger),
+		},
+		tps.LRPEvents: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrpevents.NewHandler(podSource, requestTimeout, logger), logger),
+		},
+		tps.LRPInstanceStatus: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrpinstance.NewHandler(podSource, clock, stateConfig, requestTimeout, logger), logger),
+		},
+		tps.RestartLRPInstance: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrpinstance.NewRestartHandler(podSource, authorizer, requestTimeout, logger), logger),
+		},
+		tps.NamespaceLRPs: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrplist.NewHandler(podSource, authorizer, clock, stateConfig, requestTimeout, logger), logger),
+		},
+		tps.AllLRPs: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrplist.NewClusterHandler(podSource, authorizer, clock, stateConfig, requestTimeout, logger), logger),
+		},
+		tps.AppSummary: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(appsummary.NewHandler(podSource, clock, stateConfig, requestTimeout, logger), logger),
+		},
+		tps.BulkAppSummary: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(appsummary.NewBulkHandler(podSource, clock, stateConfig, bulkLRPStatusWorkers, requestTimeout, logger), logger),
+		},
+		tps.LRPStatusV2: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrpstatus.NewV2Handler(podSource, nodes, clock, stateConfig, requestTimeout, logger), logger),
+		},
+		tps.LRPStatsV2: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(lrpstats.NewV2Handler(podSource, noaaClient, nodes, clock, stateConfig, requestTimeout, metricsTimeout, logger), logger),
+		},
+		tps.BulkLRPStatusV2: tpsHandler{
+			inFlight:        inFlight,
+			delegateHandler: LogWrap(bulklrpstatus.NewV2Handler(podSource, nodes, clock, stateConfig, bulkLRPStatusWorkers, requestTimeout, logger), logger),
 		},
 	}
 
@@ -37,21 +83,16 @@ func New(k8sClient v1core.CoreInterface, noaaClient lrpstats.NoaaClient, maxInFl
 }
 
 type tpsHandler struct {
-	semaphore       chan struct{}
+	inFlight        *InFlightLimit
 	delegateHandler http.Handler
 }
 
 func (handler tpsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	select {
-	case handler.semaphore <- struct{}{}:
-	default:
-		w.WriteHeader(http.StatusServiceUnavailable)
+	if !handler.inFlight.acquire() {
+		helpers.WriteError(w, http.StatusServiceUnavailable, tps.TooManyRequests, "too many requests in flight")
 		return
 	}
+	defer handler.inFlight.release()
 
-	defer func() {
-		<-handler.semaphore
-	}()
-
-	handler.delegateHandler.ServeHTTP(w, r)
+	WarningWrap(handler.delegateHandler).ServeHTTP(w, r)
 }
@@ -36,7 +36,4 @@ func newTestRequest(body interface{}) *http.Request {
 	return request
 }
 
-//go:generate counterfeiter -o handler_fakes/fake_replication_controller.go --fake-name FakeReplicationController ../../../../k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1 ReplicationControllerInterface
-//go:generate counterfeiter -o handler_fakes/fake_pod.go --fake-name FakePod ../../../../k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1 PodInterface
-//go:generate counterfeiter -o handler_fakes/fake_k8s_client.go --fake-name FakeKubeClient ../../../../k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1 CoreInterface
-//go:generate counterfeiter -o handler_fakes/fake_namespace.go --fake-name FakeNamespace ../../../../k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1 NamespaceInterface
+//go:generate counterfeiter -o fakes/fake_handler.go --fake-name FakeHandler net/http Handler
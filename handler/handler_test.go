@@ -1,19 +1,26 @@
 package handler_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"time"
 
-	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/unversioned"
 	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
 
 	"github.com/cloudfoundry-incubator/nsync/helpers"
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	authfakes "github.com/cloudfoundry-incubator/tps/auth/fakes"
 	"github.com/cloudfoundry-incubator/tps/handler"
-	handlerfakes "github.com/cloudfoundry-incubator/tps/handler/handler_fakes"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstats/fakes"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	podsourcefakes "github.com/cloudfoundry-incubator/tps/podsource/fakes"
+	"github.com/cloudfoundry-incubator/tps/topology"
 	"github.com/cloudfoundry/sonde-go/events"
 	"github.com/gogo/protobuf/proto"
 	"github.com/pivotal-golang/lager/lagertest"
@@ -27,18 +34,19 @@ var _ = Describe("Handler", func() {
 	Describe("rate limiting", func() {
 
 		var (
-			noaaClient     *fakes.FakeNoaaClient
-			fakeKubeClient *handlerfakes.FakeKubeClient
+			noaaClient *fakes.FakeNoaaClient
+			source     *podsource.MemorySource
+			fakeSource *podsourcefakes.FakePodSource
 
 			logger *lagertest.TestLogger
 
-			server                 *httptest.Server
-			fakeActualLRPResponses chan *v1.PodList
-			statsRequest           *http.Request
-			statusRequest          *http.Request
-			httpClient             *http.Client
-			fakePod                *handlerfakes.FakePod
-			pod                    *v1.Pod
+			server        *httptest.Server
+			podListings   chan struct{}
+			statsRequest  *http.Request
+			statusRequest *http.Request
+			httpClient    *http.Client
+			pod           *v1.Pod
+			inFlight      *handler.InFlightLimit
 		)
 
 		BeforeEach(func() {
@@ -47,17 +55,27 @@ var _ = Describe("Handler", func() {
 
 			httpClient = &http.Client{}
 			logger = lagertest.NewTestLogger("test")
-			fakeKubeClient = &handlerfakes.FakeKubeClient{}
+			// pod listings hang until they are let through one at a time
+			podListings = make(chan struct{}, 2)
+			source = podsource.NewMemorySource()
+			fakeSource = &podsourcefakes.FakePodSource{
+				PodsStub: func(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+					<-podListings
+					return source.Pods(namespace, selector)
+				},
+				ReplicationControllersStub: source.ReplicationControllers,
+				ReplicaSetsStub:            source.ReplicaSets,
+			}
 			noaaClient = &fakes.FakeNoaaClient{}
 
-			httpHandler, err = handler.New(fakeKubeClient, noaaClient, 2, 15, logger)
+			inFlight = handler.NewInFlightLimit(2)
+			httpHandler, err = handler.New(fakeSource, noaaClient, topology.NodeListers{}, &authfakes.FakeAuthorizer{}, inFlight, 15, time.Minute, time.Minute, lrpstatus.StateConfig{}, logger)
 			Expect(err).NotTo(HaveOccurred())
 
 			server = httptest.NewServer(httpHandler)
 			processGuid, err := helpers.NewProcessGuid("8d58c09b-b305-4f16-bcfe-b78edcb77100-3f258eb0-9dac-460c-a424-b43fe92bee27")
 			Expect(err).NotTo(HaveOccurred())
 
-			fakeActualLRPResponses = make(chan *v1.PodList, 2)
 			statsRequest, err = http.NewRequest("GET", server.URL+"/v1/actual_lrps/"+processGuid.String()+"/stats", nil)
 			Expect(err).NotTo(HaveOccurred())
 			statsRequest.Header.Set("Authorization", "something")
@@ -65,8 +83,6 @@ var _ = Describe("Handler", func() {
 			statusRequest, err = http.NewRequest("GET", server.URL+"/v1/actual_lrps/"+processGuid.String(), nil)
 			Expect(err).NotTo(HaveOccurred())
 
-			fakePod = &handlerfakes.FakePod{}
-
 			now := unversioned.Now()
 			pod = &v1.Pod{
 				ObjectMeta: v1.ObjectMeta{
@@ -107,14 +123,7 @@ var _ = Describe("Handler", func() {
 				},
 			}
 
-			fakeKubeClient.PodsReturns(fakePod)
-			fakePod.ListReturns(&v1.PodList{
-				Items: []v1.Pod{*pod},
-			}, nil)
-
-			fakePod.ListStub = func(opts api.ListOptions) (*v1.PodList, error) {
-				return <-fakeActualLRPResponses, nil
-			}
+			source.AddPod(*pod)
 			// bbsClient.ActualLRPGroupsByProcessGuidStub = func(lager.Logger, string) ([]*models.ActualLRPGroup, error) {
 			// 	return <-fakeActualLRPResponses, nil
 			// }
@@ -138,7 +147,7 @@ var _ = Describe("Handler", func() {
 			// hit both status and stats endpoints once, make fake bbs hang
 			var wg sync.WaitGroup
 
-			defer close(fakeActualLRPResponses)
+			defer close(podListings)
 
 			wg.Add(1)
 			go func() {
@@ -161,7 +170,7 @@ var _ = Describe("Handler", func() {
 			}()
 
 			//Eventually(bbsClient.ActualLRPGroupsByProcessGuidCallCount).Should(Equal(2))
-			Eventually(fakePod.ListCallCount).Should(Equal(2))
+			Eventually(fakeSource.PodsCallCount).Should(Equal(2))
 			// hit it again, assert we get a 503
 			res, err := httpClient.Do(statusRequest)
 			Expect(err).NotTo(HaveOccurred())
@@ -170,10 +179,15 @@ var _ = Describe("Handler", func() {
 			res, err = httpClient.Do(statsRequest)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(res.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(res.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			var tpsErr tps.Error
+			Expect(json.NewDecoder(res.Body).Decode(&tpsErr)).To(Succeed())
+			Expect(tpsErr.Code).To(Equal(tps.TooManyRequests))
 
 			// un-hang http calls
-			fakeActualLRPResponses <- &v1.PodList{Items: []v1.Pod{*pod}}
-			fakeActualLRPResponses <- &v1.PodList{Items: []v1.Pod{*pod}}
+			podListings <- struct{}{}
+			podListings <- struct{}{}
 			wg.Wait()
 
 			// confirm we can request again
@@ -197,10 +211,50 @@ var _ = Describe("Handler", func() {
 				Expect(res.StatusCode).To(Equal(http.StatusOK))
 			}()
 
-			fakeActualLRPResponses <- &v1.PodList{Items: []v1.Pod{*pod}}
-			fakeActualLRPResponses <- &v1.PodList{Items: []v1.Pod{*pod}}
+			podListings <- struct{}{}
+			podListings <- struct{}{}
 			wg.Wait()
 
 		})
+
+		It("counts the requests of an earlier handler sharing the limit", func() {
+			var wg sync.WaitGroup
+			defer close(podListings)
+
+			for i := 0; i < 2; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer GinkgoRecover()
+
+					res, err := httpClient.Do(statusRequest)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(res.StatusCode).To(Equal(http.StatusOK))
+				}()
+			}
+			Eventually(fakeSource.PodsCallCount).Should(Equal(2))
+
+			swapped, err := handler.New(fakeSource, noaaClient, topology.NodeListers{}, &authfakes.FakeAuthorizer{}, inFlight, 15, time.Minute, time.Minute, lrpstatus.StateConfig{}, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			recorder := httptest.NewRecorder()
+			swapped.ServeHTTP(recorder, statusRequest)
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+
+			inFlight.SetMax(3)
+			recorder = httptest.NewRecorder()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				swapped.ServeHTTP(recorder, statusRequest)
+			}()
+			Eventually(fakeSource.PodsCallCount).Should(Equal(3))
+
+			podListings <- struct{}{}
+			podListings <- struct{}{}
+			podListings <- struct{}{}
+			wg.Wait()
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+		})
 	})
 })
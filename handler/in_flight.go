@@ -0,0 +1,43 @@
+package handler
+
+import "sync"
+
+// InFlightLimit bounds the requests the API serves at once. It outlives the
+// handlers New builds, so a handler swapped in by a reload shares the count
+// of the requests its predecessor is still serving.
+type InFlightLimit struct {
+	lock     sync.Mutex
+	max      int
+	inFlight int
+}
+
+func NewInFlightLimit(max int) *InFlightLimit {
+	return &InFlightLimit{max: max}
+}
+
+// SetMax changes the limit. Requests beyond a lowered limit finish, but no
+// new one starts until enough of them have.
+func (l *InFlightLimit) SetMax(max int) {
+	l.lock.Lock()
+	l.max = max
+	l.lock.Unlock()
+}
+
+// acquire reports whether another request may start; one that may has to
+// call release when it finishes
+func (l *InFlightLimit) acquire() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.inFlight >= l.max {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+func (l *InFlightLimit) release() {
+	l.lock.Lock()
+	l.inFlight--
+	l.lock.Unlock()
+}
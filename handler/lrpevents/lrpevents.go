@@ -0,0 +1,227 @@
+package lrpevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/pivotal-golang/lager"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+)
+
+const (
+	DefaultPerPage = 50
+	MaxPerPage     = 200
+)
+
+type handler struct {
+	podSource      podsource.PodSource
+	requestTimeout time.Duration
+	logger         lager.Logger
+}
+
+func NewHandler(podSource podsource.PodSource, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:      podSource,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+	}
+}
+
+func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	guid := r.FormValue(":guid")
+	logger := handler.logger.Session("lrp-events", lager.Data{"process-guid": guid})
+
+	pg, err := helpers.NewProcessGuid(guid)
+	if err != nil {
+		logger.Error("invalid-process-guid", err)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidProcessGuid, err.Error())
+		return
+	}
+
+	page, perPage, err := pagination(r)
+	if err != nil {
+		logger.Error("invalid-pagination", err)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
+	logger.Info("fetching-actual-lrp-info")
+	process, err := tpshelpers.FetchProcess(ctx, handler.podSource, pg.ShortenedGuid())
+	if err == context.Canceled {
+		logger.Info("request-cancelled")
+		return
+	}
+	if err != nil {
+		logger.Error("fetching-actual-lrp-info-failed", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
+
+	if len(process.Pods) == 0 {
+		logger.Info("actual-lrp-not-found")
+		tpshelpers.WriteError(w, http.StatusNotFound, tps.ProcessNotFound, "no instances found for process guid "+guid)
+		return
+	}
+
+	lrpEvents := []tps.LRPEvent{}
+	// numbered as in the status of the process, so events of a pod being
+	// deleted do not take the index of a live one
+	for _, indexed := range lrpstatus.IndexPods(process) {
+		pod := indexed.Pod
+		podEvents, err := handler.podEvents(ctx, pod)
+		if err == context.Canceled {
+			logger.Info("request-cancelled")
+			return
+		}
+		if err != nil {
+			logger.Error("fetching-pod-events-failed", err, lager.Data{"pod": pod.ObjectMeta.Name})
+			tpshelpers.WriteUpstreamError(w, err)
+			return
+		}
+
+		for _, event := range podEvents {
+			lrpEvents = append(lrpEvents, tps.LRPEvent{
+				Time:    eventTime(event).Unix(),
+				Index:   indexed.Index,
+				Reason:  event.Reason,
+				Message: event.Message,
+				Count:   event.Count,
+			})
+		}
+
+		lrpEvents = append(lrpEvents, terminations(pod, indexed.Index)...)
+	}
+
+	sort.Sort(byTimeDescending(lrpEvents))
+
+	response := tps.LRPEvents{
+		ProcessGuid:  guid,
+		Page:         page,
+		PerPage:      perPage,
+		TotalResults: len(lrpEvents),
+		TotalPages:   (len(lrpEvents) + perPage - 1) / perPage,
+		Events:       []tps.LRPEvent{},
+	}
+
+	start := (page - 1) * perPage
+	if start < len(lrpEvents) {
+		end := start + perPage
+		if end > len(lrpEvents) {
+			end = len(lrpEvents)
+		}
+		response.Events = lrpEvents[start:end]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(response)
+	if err != nil {
+		logger.Error("stream-response-failed", err)
+	}
+}
+
+func (handler *handler) podEvents(ctx context.Context, pod v1.Pod) ([]v1.Event, error) {
+	var events []v1.Event
+	err := tpshelpers.CallWithContext(ctx, func() error {
+		var err error
+		events, err = handler.podSource.PodEvents(pod)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// the current and previous exits of the application container; the kubelet
+// only keeps the last one, events cover anything older
+func terminations(pod v1.Pod, index uint) []tps.LRPEvent {
+	containerStatus := lrpstatus.ApplicationContainerStatus(pod)
+	if containerStatus == nil {
+		return nil
+	}
+
+	lrpEvents := []tps.LRPEvent{}
+	for _, terminated := range []*v1.ContainerStateTerminated{
+		containerStatus.State.Terminated,
+		containerStatus.LastTerminationState.Terminated,
+	} {
+		if terminated == nil {
+			continue
+		}
+
+		message := terminated.Message
+		if message == "" {
+			message = fmt.Sprintf("application container exited with code %d", terminated.ExitCode)
+		}
+
+		lrpEvents = append(lrpEvents, tps.LRPEvent{
+			Time:    terminated.FinishedAt.Unix(),
+			Index:   index,
+			Reason:  terminated.Reason,
+			Message: message,
+		})
+	}
+
+	return lrpEvents
+}
+
+func eventTime(event v1.Event) unversioned.Time {
+	if event.LastTimestamp.IsZero() {
+		return event.FirstTimestamp
+	}
+	return event.LastTimestamp
+}
+
+func pagination(r *http.Request) (int, int, error) {
+	page, err := intParameter(r, "page", 1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	perPage, err := intParameter(r, "per_page", DefaultPerPage)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if perPage > MaxPerPage {
+		return 0, 0, fmt.Errorf("per_page must not be greater than %d", MaxPerPage)
+	}
+
+	return page, perPage, nil
+}
+
+func intParameter(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.FormValue(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+
+	return i, nil
+}
+
+type byTimeDescending []tps.LRPEvent
+
+func (events byTimeDescending) Len() int           { return len(events) }
+func (events byTimeDescending) Swap(i, j int)      { events[i], events[j] = events[j], events[i] }
+func (events byTimeDescending) Less(i, j int) bool { return events[i].Time > events[j].Time }
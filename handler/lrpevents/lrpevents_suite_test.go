@@ -0,0 +1,13 @@
+package lrpevents_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLrpevents(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lrpevents Suite")
+}
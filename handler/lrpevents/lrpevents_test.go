@@ -0,0 +1,204 @@
+package lrpevents_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpevents"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/nu7hatch/gouuid"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRPEvents", func() {
+	var (
+		source      *podsource.MemorySource
+		handler     http.Handler
+		response    *httptest.ResponseRecorder
+		request     *http.Request
+		processGuid helpers.ProcessGuid
+		logger      *lagertest.TestLogger
+		baseTime    time.Time
+	)
+
+	newPod := func(name, uid string) v1.Pod {
+		return v1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      name,
+				Namespace: "namespace",
+				UID:       v1.UID(uid),
+				Labels: map[string]string{
+					"cloudfoundry.org/process-guid": processGuid.ShortenedGuid(),
+				},
+			},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "application",
+					State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+				}},
+			},
+		}
+	}
+
+	newEvent := func(podName, reason string, offset time.Duration) v1.Event {
+		return v1.Event{
+			ObjectMeta:     v1.ObjectMeta{Namespace: "namespace"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: podName},
+			Reason:         reason,
+			Message:        reason + " message",
+			LastTimestamp:  unversioned.NewTime(baseTime.Add(offset)),
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		logger = lagertest.NewTestLogger("test")
+		baseTime = time.Date(2016, 8, 8, 8, 8, 8, 0, time.UTC)
+
+		appGuid, _ := uuid.NewV4()
+		appVersion, _ := uuid.NewV4()
+		processGuid, err = helpers.NewProcessGuid(appGuid.String() + "-" + appVersion.String())
+		Expect(err).NotTo(HaveOccurred())
+
+		podA := newPod("pod-a", "1111")
+		podA.Status.ContainerStatuses[0].LastTerminationState = v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{
+				ExitCode:   1,
+				Reason:     "Error",
+				FinishedAt: unversioned.NewTime(baseTime.Add(30 * time.Second)),
+			},
+		}
+		podB := newPod("pod-b", "2222")
+
+		source = podsource.NewMemorySource()
+		source.AddPod(podB, podA)
+		source.AddEvent(
+			newEvent("pod-a", "BackOff", 40*time.Second),
+			newEvent("pod-b", "Pulled", 10*time.Second),
+			newEvent("pod-b", "Started", 20*time.Second),
+		)
+
+		handler = lrpevents.NewHandler(source, time.Minute, logger)
+		response = httptest.NewRecorder()
+
+		request, err = http.NewRequest("GET", "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		request.Form = url.Values{":guid": []string{processGuid.String()}}
+	})
+
+	JustBeforeEach(func() {
+		handler.ServeHTTP(response, request)
+	})
+
+	decodeEvents := func() tps.LRPEvents {
+		var lrpEvents tps.LRPEvents
+		Expect(response.Code).To(Equal(http.StatusOK))
+		err := json.Unmarshal(response.Body.Bytes(), &lrpEvents)
+		Expect(err).NotTo(HaveOccurred())
+		return lrpEvents
+	}
+
+	It("merges pod events and container terminations newest first", func() {
+		lrpEvents := decodeEvents()
+		Expect(lrpEvents.ProcessGuid).To(Equal(processGuid.String()))
+		Expect(lrpEvents.TotalResults).To(Equal(4))
+
+		reasons := []string{}
+		for _, event := range lrpEvents.Events {
+			reasons = append(reasons, event.Reason)
+		}
+		Expect(reasons).To(Equal([]string{"BackOff", "Error", "Started", "Pulled"}))
+	})
+
+	It("reports the instance index of each entry", func() {
+		lrpEvents := decodeEvents()
+		Expect(lrpEvents.Events[0].Index).To(BeEquivalentTo(0))
+		Expect(lrpEvents.Events[1].Index).To(BeEquivalentTo(0))
+		Expect(lrpEvents.Events[2].Index).To(BeEquivalentTo(1))
+	})
+
+	It("describes terminations that carry no message by their exit code", func() {
+		lrpEvents := decodeEvents()
+		Expect(lrpEvents.Events[1].Message).To(Equal("application container exited with code 1"))
+		Expect(lrpEvents.Events[1].Time).To(Equal(baseTime.Add(30 * time.Second).Unix()))
+	})
+
+	Context("when a pod being deleted sorts ahead of the live ones", func() {
+		BeforeEach(func() {
+			deletionTimestamp := unversioned.NewTime(baseTime)
+			stopping := newPod("pod-0", "0000")
+			stopping.ObjectMeta.DeletionTimestamp = &deletionTimestamp
+			source.AddPod(stopping)
+			source.AddEvent(newEvent("pod-0", "Killing", 50*time.Second))
+
+			source.AddReplicationController(v1.ReplicationController{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "rc",
+					Namespace: "namespace",
+					Labels: map[string]string{
+						"cloudfoundry.org/process-guid": processGuid.ShortenedGuid(),
+					},
+				},
+				Spec: v1.ReplicationControllerSpec{Replicas: helpers.Int32Ptr(3)},
+			})
+		})
+
+		It("numbers it after the desired indexes as the status does", func() {
+			lrpEvents := decodeEvents()
+			Expect(lrpEvents.Events[0].Reason).To(Equal("Killing"))
+			Expect(lrpEvents.Events[0].Index).To(BeEquivalentTo(3))
+			Expect(lrpEvents.Events[1].Reason).To(Equal("BackOff"))
+			Expect(lrpEvents.Events[1].Index).To(BeEquivalentTo(0))
+			Expect(lrpEvents.Events[3].Reason).To(Equal("Started"))
+			Expect(lrpEvents.Events[3].Index).To(BeEquivalentTo(1))
+		})
+	})
+
+	Context("when a page is requested", func() {
+		BeforeEach(func() {
+			request.Form.Set("page", "2")
+			request.Form.Set("per_page", "3")
+		})
+
+		It("returns that page", func() {
+			lrpEvents := decodeEvents()
+			Expect(lrpEvents.Page).To(Equal(2))
+			Expect(lrpEvents.PerPage).To(Equal(3))
+			Expect(lrpEvents.TotalPages).To(Equal(2))
+			Expect(lrpEvents.Events).To(HaveLen(1))
+			Expect(lrpEvents.Events[0].Reason).To(Equal("Pulled"))
+		})
+	})
+
+	Context("when the pagination is invalid", func() {
+		BeforeEach(func() {
+			request.Form.Set("per_page", "0")
+		})
+
+		It("responds with a 400", func() {
+			Expect(response.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Context("when the process has no pods", func() {
+		BeforeEach(func() {
+			Expect(source.DeletePod(newPod("pod-a", "1111"))).To(Succeed())
+			Expect(source.DeletePod(newPod("pod-b", "2222"))).To(Succeed())
+		})
+
+		It("responds with a 404", func() {
+			Expect(response.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
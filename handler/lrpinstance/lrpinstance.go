@@ -0,0 +1,189 @@
+package lrpinstance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/auth"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+type statusHandler struct {
+	podSource      podsource.PodSource
+	clock          clock.Clock
+	stateConfig    lrpstatus.StateConfig
+	requestTimeout time.Duration
+	logger         lager.Logger
+}
+
+type restartHandler struct {
+	podSource      podsource.PodSource
+	authorizer     auth.Authorizer
+	requestTimeout time.Duration
+	logger         lager.Logger
+}
+
+// serves the status of a single instance, in the shape of the entries
+// returned by the LRPStatus route
+func NewHandler(podSource podsource.PodSource, clk clock.Clock, stateConfig lrpstatus.StateConfig, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &statusHandler{
+		podSource:      podSource,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+	}
+}
+
+// deletes the pod of a single instance so that its replication controller
+// replaces it, like a diego cell does for restart-app-instance. Only tokens
+// the authorizer allows to change the app may restart its instances.
+func NewRestartHandler(podSource podsource.PodSource, authorizer auth.Authorizer, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &restartHandler{
+		podSource:      podSource,
+		authorizer:     authorizer,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+	}
+}
+
+func (handler *statusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := handler.logger.Session("lrp-instance-status", lager.Data{
+		"process-guid": r.FormValue(":guid"),
+		"index":        r.FormValue(":index"),
+	})
+
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
+	pg, process, index, ok := lookupProcess(ctx, logger, handler.podSource, w, r)
+	if !ok {
+		return
+	}
+
+	for _, instance := range lrpstatus.ProcessInstances(pg, process, handler.clock, handler.stateConfig) {
+		if instance.Index == index {
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(instance.LRPInstance)
+			if err != nil {
+				logger.Error("stream-response-failed", err)
+			}
+			return
+		}
+	}
+
+	logger.Info("instance-not-found")
+	tpshelpers.WriteError(w, http.StatusNotFound, tps.InstanceNotFound, "no instance at index "+r.FormValue(":index"))
+}
+
+func (handler *restartHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		tpshelpers.WriteError(w, http.StatusUnauthorized, tps.Unauthorized, "missing authorization header")
+		return
+	}
+
+	logger := handler.logger.Session("restart-lrp-instance", lager.Data{
+		"process-guid": r.FormValue(":guid"),
+		"index":        r.FormValue(":index"),
+	})
+
+	pg, err := helpers.NewProcessGuid(r.FormValue(":guid"))
+	if err != nil {
+		logger.Error("invalid-process-guid", err)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidProcessGuid, err.Error())
+		return
+	}
+
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
+	err = tpshelpers.CallWithContext(ctx, func() error {
+		return handler.authorizer.AuthorizeApp(authorization, pg.AppGuid.String())
+	})
+	if err == context.Canceled {
+		logger.Info("request-cancelled")
+		return
+	}
+	if err != nil {
+		logger.Error("not-authorized", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
+
+	_, process, index, ok := lookupProcess(ctx, logger, handler.podSource, w, r)
+	if !ok {
+		return
+	}
+
+	pod, found := lrpstatus.PodAtIndex(process, index)
+	if !found {
+		logger.Info("instance-not-found")
+		tpshelpers.WriteError(w, http.StatusNotFound, tps.InstanceNotFound, "no instance at index "+r.FormValue(":index"))
+		return
+	}
+
+	logger.Info("deleting-pod", lager.Data{"namespace": pod.ObjectMeta.Namespace, "pod": pod.ObjectMeta.Name})
+	err = tpshelpers.CallWithContext(ctx, func() error {
+		return handler.podSource.DeletePod(pod)
+	})
+	if err == context.Canceled {
+		logger.Info("request-cancelled")
+		return
+	}
+	if err != nil {
+		logger.Error("deleting-pod-failed", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
+
+	logger.Info("deleted-pod")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resolves the guid and index of the request to the process, writing the
+// error response and returning false when it cannot
+func lookupProcess(ctx context.Context, logger lager.Logger, podSource podsource.PodSource, w http.ResponseWriter, r *http.Request) (helpers.ProcessGuid, *tpshelpers.Process, uint, bool) {
+	guid := r.FormValue(":guid")
+	pg, err := helpers.NewProcessGuid(guid)
+	if err != nil {
+		logger.Error("invalid-process-guid", err)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidProcessGuid, err.Error())
+		return pg, nil, 0, false
+	}
+
+	index, err := strconv.ParseUint(r.FormValue(":index"), 10, 32)
+	if err != nil {
+		logger.Error("invalid-index", err)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidRequest, "index must be a non-negative integer")
+		return pg, nil, 0, false
+	}
+
+	process, err := tpshelpers.FetchProcess(ctx, podSource, pg.ShortenedGuid())
+	if err == context.Canceled {
+		logger.Info("request-cancelled")
+		return pg, nil, 0, false
+	}
+	if err != nil {
+		logger.Error("fetching-actual-lrp-info-failed", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return pg, nil, 0, false
+	}
+
+	if !process.Exists() {
+		logger.Info("actual-lrp-not-found")
+		tpshelpers.WriteError(w, http.StatusNotFound, tps.ProcessNotFound, "no instances found for process guid "+guid)
+		return pg, nil, 0, false
+	}
+
+	return pg, process, uint(index), true
+}
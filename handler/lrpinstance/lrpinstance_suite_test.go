@@ -0,0 +1,13 @@
+package lrpinstance_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLrpinstance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lrpinstance Suite")
+}
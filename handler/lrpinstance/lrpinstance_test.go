@@ -0,0 +1,266 @@
+package lrpinstance_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/auth"
+	authfakes "github.com/cloudfoundry-incubator/tps/auth/fakes"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpinstance"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/nu7hatch/gouuid"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRPInstance", func() {
+	var (
+		source      *podsource.MemorySource
+		fakeClock   *fakeclock.FakeClock
+		handler     http.Handler
+		response    *httptest.ResponseRecorder
+		request     *http.Request
+		processGuid helpers.ProcessGuid
+		logger      *lagertest.TestLogger
+	)
+
+	newPod := func(name, uid string) v1.Pod {
+		startTime := unversioned.NewTime(fakeClock.Now())
+		return v1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      name,
+				Namespace: "namespace",
+				UID:       v1.UID(uid),
+				Labels: map[string]string{
+					"cloudfoundry.org/process-guid": processGuid.ShortenedGuid(),
+				},
+			},
+			Status: v1.PodStatus{
+				StartTime: &startTime,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "application",
+					State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+					Ready: true,
+				}},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Date(2016, 8, 8, 8, 8, 8, 0, time.UTC))
+
+		appGuid, _ := uuid.NewV4()
+		appVersion, _ := uuid.NewV4()
+		processGuid, err = helpers.NewProcessGuid(appGuid.String() + "-" + appVersion.String())
+		Expect(err).NotTo(HaveOccurred())
+
+		source = podsource.NewMemorySource()
+		source.AddPod(newPod("pod-b", "2222"), newPod("pod-a", "1111"))
+
+		response = httptest.NewRecorder()
+		request, err = http.NewRequest("GET", "", nil)
+		Expect(err).NotTo(HaveOccurred())
+		request.Form = url.Values{
+			":guid":  []string{processGuid.String()},
+			":index": []string{"1"},
+		}
+	})
+
+	JustBeforeEach(func() {
+		handler.ServeHTTP(response, request)
+	})
+
+	Describe("GET", func() {
+		BeforeEach(func() {
+			handler = lrpinstance.NewHandler(source, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+		})
+
+		It("returns the instance at the index", func() {
+			var instance cc_messages.LRPInstance
+			Expect(response.Code).To(Equal(http.StatusOK))
+			err := json.Unmarshal(response.Body.Bytes(), &instance)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(instance.Index).To(BeEquivalentTo(1))
+			Expect(instance.InstanceGuid).To(Equal("2222"))
+			Expect(instance.State).To(Equal(cc_messages.LRPInstanceStateRunning))
+		})
+
+		Context("when there is no instance at the index", func() {
+			BeforeEach(func() {
+				request.Form.Set(":index", "2")
+			})
+
+			It("responds with a 404", func() {
+				var tpsErr tps.Error
+				Expect(response.Code).To(Equal(http.StatusNotFound))
+				err := json.Unmarshal(response.Body.Bytes(), &tpsErr)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tpsErr.Code).To(Equal(tps.InstanceNotFound))
+			})
+		})
+
+		Context("when the index is not a number", func() {
+			BeforeEach(func() {
+				request.Form.Set(":index", "first")
+			})
+
+			It("responds with a 400", func() {
+				Expect(response.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("DELETE", func() {
+		var authorizer *authfakes.FakeAuthorizer
+
+		podNames := func() []string {
+			pods, err := source.Pods("namespace", labels.Everything())
+			Expect(err).NotTo(HaveOccurred())
+			names := []string{}
+			for _, pod := range pods {
+				names = append(names, pod.ObjectMeta.Name)
+			}
+			return names
+		}
+
+		BeforeEach(func() {
+			authorizer = &authfakes.FakeAuthorizer{}
+			handler = lrpinstance.NewRestartHandler(source, authorizer, time.Minute, logger)
+			request.Header.Set("Authorization", "bearer something")
+		})
+
+		It("deletes the pod at the index", func() {
+			Expect(response.Code).To(Equal(http.StatusNoContent))
+			Expect(authorizer.AuthorizeAppCallCount()).To(Equal(1))
+			authorization, appGuid := authorizer.AuthorizeAppArgsForCall(0)
+			Expect(authorization).To(Equal("bearer something"))
+			Expect(appGuid).To(Equal(processGuid.AppGuid.String()))
+
+			Expect(podNames()).To(Equal([]string{"pod-a"}))
+		})
+
+		Context("without an authorization header", func() {
+			BeforeEach(func() {
+				request.Header.Del("Authorization")
+			})
+
+			It("responds with a 401 and deletes nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusUnauthorized))
+				Expect(podNames()).To(Equal([]string{"pod-a", "pod-b"}))
+			})
+		})
+
+		Context("with a token the authorizer rejects", func() {
+			BeforeEach(func() {
+				request.Header.Set("Authorization", "bearer bogus")
+				authorizer.AuthorizeAppReturns(auth.ErrUnauthorized)
+			})
+
+			It("responds with a 401 and deletes nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusUnauthorized))
+				var tpsErr tps.Error
+				Expect(json.Unmarshal(response.Body.Bytes(), &tpsErr)).To(Succeed())
+				Expect(tpsErr.Code).To(Equal(tps.Unauthorized))
+				Expect(podNames()).To(Equal([]string{"pod-a", "pod-b"}))
+			})
+		})
+
+		Context("with a token that may not change the app", func() {
+			BeforeEach(func() {
+				authorizer.AuthorizeAppReturns(auth.ErrForbidden)
+			})
+
+			It("responds with a 403 and deletes nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusForbidden))
+				var tpsErr tps.Error
+				Expect(json.Unmarshal(response.Body.Bytes(), &tpsErr)).To(Succeed())
+				Expect(tpsErr.Code).To(Equal(tps.Forbidden))
+				Expect(podNames()).To(Equal([]string{"pod-a", "pod-b"}))
+			})
+		})
+
+		Context("when the authorizer cannot tell", func() {
+			BeforeEach(func() {
+				authorizer.AuthorizeAppReturns(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			})
+
+			It("responds with a 503 and deletes nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+				Expect(podNames()).To(Equal([]string{"pod-a", "pod-b"}))
+			})
+		})
+
+		Context("when a pod being deleted sorts before the instance", func() {
+			BeforeEach(func() {
+				deletedAt := unversioned.NewTime(fakeClock.Now())
+				terminatingPod := newPod("pod-old", "0000")
+				terminatingPod.ObjectMeta.DeletionTimestamp = &deletedAt
+				source.AddPod(terminatingPod)
+			})
+
+			It("deletes the pod of the instance reported at the index", func() {
+				Expect(response.Code).To(Equal(http.StatusNoContent))
+				Expect(podNames()).To(Equal([]string{"pod-a", "pod-old"}))
+			})
+
+			Context("and the index is the one reported for that pod", func() {
+				BeforeEach(func() {
+					request.Form.Set(":index", "2")
+				})
+
+				It("responds with a 404 and deletes nothing", func() {
+					Expect(response.Code).To(Equal(http.StatusNotFound))
+					Expect(podNames()).To(Equal([]string{"pod-a", "pod-b", "pod-old"}))
+				})
+			})
+		})
+
+		Context("when there is no instance at the index", func() {
+			BeforeEach(func() {
+				request.Form.Set(":index", "5")
+			})
+
+			It("responds with a 404 and deletes nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusNotFound))
+				Expect(podNames()).To(Equal([]string{"pod-a", "pod-b"}))
+			})
+		})
+
+		Context("when deleting the pod fails", func() {
+			BeforeEach(func() {
+				handler = lrpinstance.NewRestartHandler(failingDeletes{source}, authorizer, time.Minute, logger)
+			})
+
+			It("responds with a 503", func() {
+				Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+	})
+})
+
+// a source whose deletes fail, as when the API server cannot be reached
+type failingDeletes struct {
+	*podsource.MemorySource
+}
+
+func (failingDeletes) DeletePod(v1.Pod) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+}
@@ -0,0 +1,292 @@
+package lrplist
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/auth"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+var states = map[string]bool{
+	string(cc_messages.LRPInstanceStateRunning):  true,
+	string(cc_messages.LRPInstanceStateStarting): true,
+	string(cc_messages.LRPInstanceStateCrashed):  true,
+	string(cc_messages.LRPInstanceStateDown):     true,
+	string(cc_messages.LRPInstanceStateUnknown):  true,
+}
+
+type handler struct {
+	podSource      podsource.PodSource
+	clock          clock.Clock
+	stateConfig    lrpstatus.StateConfig
+	requestTimeout time.Duration
+	clusterWide    bool
+	authorizer     auth.Authorizer
+	logger         lager.Logger
+}
+
+// lists the processes of the namespace in the :ns route parameter. Each
+// space runs in the namespace named after its guid, so only tokens the
+// authorizer lets see that space, or an admin's, may use it
+func NewHandler(podSource podsource.PodSource, authorizer auth.Authorizer, clk clock.Clock, stateConfig lrpstatus.StateConfig, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:      podSource,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		authorizer:     authorizer,
+		logger:         logger,
+	}
+}
+
+// lists the processes of every namespace; as an admin route only tokens the
+// authorizer takes for an admin's may use it
+func NewClusterHandler(podSource podsource.PodSource, authorizer auth.Authorizer, clk clock.Clock, stateConfig lrpstatus.StateConfig, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:      podSource,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		clusterWide:    true,
+		authorizer:     authorizer,
+		logger:         logger,
+	}
+}
+
+type listRequest struct {
+	selector labels.Selector
+	limit    int
+	after    string
+	state    cc_messages.LRPInstanceState
+}
+
+func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := r.FormValue(":ns")
+	authorization := r.Header.Get("Authorization")
+	if handler.clusterWide {
+		namespace = api.NamespaceAll
+	}
+
+	if authorization == "" {
+		tpshelpers.WriteError(w, http.StatusUnauthorized, tps.Unauthorized, "missing authorization header")
+		return
+	}
+
+	logger := handler.logger.Session("list-lrps", lager.Data{"namespace": namespace})
+
+	req, err := parseListRequest(r)
+	if err != nil {
+		logger.Error("invalid-request", err)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
+	err = tpshelpers.CallWithContext(ctx, func() error {
+		return handler.authorize(authorization, namespace)
+	})
+	switch err {
+	case nil:
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	default:
+		logger.Error("not-authorized", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
+
+	processes, err := handler.fetchProcesses(ctx, namespace, req.selector)
+	switch err {
+	case nil:
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	default:
+		logger.Error("failed-listing-processes", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(handler.page(logger, processes, req))
+	if err != nil {
+		logger.Error("stream-response-failed", err)
+	}
+}
+
+func (handler *handler) authorize(authorization, namespace string) error {
+	if handler.clusterWide {
+		return handler.authorizer.AuthorizeAdmin(authorization)
+	}
+
+	err := handler.authorizer.AuthorizeSpace(authorization, namespace)
+	if err == auth.ErrForbidden {
+		// namespaces that are not a space's can still be listed by an admin
+		return handler.authorizer.AuthorizeAdmin(authorization)
+	}
+	return err
+}
+
+func parseListRequest(r *http.Request) (listRequest, error) {
+	req := listRequest{limit: DefaultLimit}
+
+	// only pods carrying a process guid belong to an LRP
+	selector := podsource.ProcessGuidLabel
+	if labelSelector := r.FormValue("labelSelector"); labelSelector != "" {
+		selector += "," + labelSelector
+	}
+	var err error
+	req.selector, err = labels.Parse(selector)
+	if err != nil {
+		return req, errors.New("invalid labelSelector: " + err.Error())
+	}
+
+	if limit := r.FormValue("limit"); limit != "" {
+		req.limit, err = strconv.Atoi(limit)
+		if err != nil || req.limit <= 0 {
+			return req, errors.New("limit must be a positive integer")
+		}
+		if req.limit > MaxLimit {
+			req.limit = MaxLimit
+		}
+	}
+
+	if token := r.FormValue("continue"); token != "" {
+		after, err := base64.URLEncoding.DecodeString(token)
+		if err != nil || len(after) == 0 {
+			return req, errors.New("invalid continue token")
+		}
+		req.after = string(after)
+	}
+
+	if state := r.FormValue("state"); state != "" {
+		if !states[state] {
+			return req, errors.New("invalid state " + state)
+		}
+		req.state = cc_messages.LRPInstanceState(state)
+	}
+
+	return req, nil
+}
+
+// groups the pods, replication controllers and replica sets matching
+// selector by their shortened process guid
+func (handler *handler) fetchProcesses(ctx context.Context, namespace string, selector labels.Selector) (map[string]*tpshelpers.Process, error) {
+	pods, err := tpshelpers.ListPods(ctx, handler.podSource, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	rcs, err := tpshelpers.ListReplicationControllers(ctx, handler.podSource, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	rss, err := tpshelpers.ListReplicaSets(ctx, handler.podSource, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	processes := map[string]*tpshelpers.Process{}
+	process := func(shortenedGuid string) *tpshelpers.Process {
+		if processes[shortenedGuid] == nil {
+			processes[shortenedGuid] = &tpshelpers.Process{}
+		}
+		return processes[shortenedGuid]
+	}
+
+	for _, pod := range pods {
+		p := process(pod.ObjectMeta.Labels[podsource.ProcessGuidLabel])
+		p.Pods = append(p.Pods, pod)
+	}
+	for _, rc := range rcs {
+		p := process(rc.ObjectMeta.Labels[podsource.ProcessGuidLabel])
+		p.DesiredInstances += tpshelpers.Replicas(rc)
+		p.Desired = true
+	}
+	for _, rs := range rss {
+		p := process(rs.ObjectMeta.Labels[podsource.ProcessGuidLabel])
+		p.DesiredInstances += tpshelpers.ReplicaSetReplicas(rs)
+		p.Desired = true
+	}
+
+	return processes, nil
+}
+
+// kubernetes 1.3 cannot page list results, so the whole list is fetched and
+// paged here by shortened guid; the continue token is the last guid served
+func (handler *handler) page(logger lager.Logger, processes map[string]*tpshelpers.Process, req listRequest) tps.LRPList {
+	shortenedGuids := make([]string, 0, len(processes))
+	for shortenedGuid := range processes {
+		if shortenedGuid > req.after {
+			shortenedGuids = append(shortenedGuids, shortenedGuid)
+		}
+	}
+	sort.Strings(shortenedGuids)
+
+	list := tps.LRPList{Items: []tps.LRPStatus{}}
+	last := ""
+	for _, shortenedGuid := range shortenedGuids {
+		if len(list.Items) == req.limit {
+			list.Continue = base64.URLEncoding.EncodeToString([]byte(last))
+			break
+		}
+
+		pg, err := helpers.DecodeProcessGuid(shortenedGuid)
+		if err != nil {
+			logger.Error("invalid-process-guid-label", err, lager.Data{"shortened-process-guid": shortenedGuid})
+			continue
+		}
+
+		instances := filterByState(lrpstatus.ProcessInstances(pg, processes[shortenedGuid], handler.clock, handler.stateConfig), req.state)
+		if len(instances) == 0 {
+			continue
+		}
+
+		list.Items = append(list.Items, tps.LRPStatus{
+			ProcessGuid: pg.String(),
+			Instances:   instances,
+		})
+		last = shortenedGuid
+	}
+
+	return list
+}
+
+func filterByState(instances []tps.LRPInstance, state cc_messages.LRPInstanceState) []tps.LRPInstance {
+	if state == "" {
+		return instances
+	}
+
+	filtered := []tps.LRPInstance{}
+	for _, instance := range instances {
+		if instance.State == state {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,13 @@
+package lrplist_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLrplist(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Lrplist Suite")
+}
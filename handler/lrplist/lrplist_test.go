@@ -0,0 +1,404 @@
+package lrplist_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"time"
+
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/auth"
+	authfakes "github.com/cloudfoundry-incubator/tps/auth/fakes"
+	"github.com/cloudfoundry-incubator/tps/handler/lrplist"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	podsourcefakes "github.com/cloudfoundry-incubator/tps/podsource/fakes"
+	"github.com/nu7hatch/gouuid"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LRPList", func() {
+	var (
+		source       *podsource.MemorySource
+		fakeClock    *fakeclock.FakeClock
+		handler      http.Handler
+		response     *httptest.ResponseRecorder
+		request      *http.Request
+		processGuids []helpers.ProcessGuid
+		authorizer   *authfakes.FakeAuthorizer
+		logger       *lagertest.TestLogger
+	)
+
+	newPod := func(pg helpers.ProcessGuid, uid string, state v1.ContainerState, extraLabels map[string]string) v1.Pod {
+		startTime := unversioned.NewTime(fakeClock.Now())
+		podLabels := map[string]string{"cloudfoundry.org/process-guid": pg.ShortenedGuid()}
+		for k, v := range extraLabels {
+			podLabels[k] = v
+		}
+		return v1.Pod{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "pod-" + uid,
+				Namespace: "space",
+				UID:       v1.UID(uid),
+				Labels:    podLabels,
+			},
+			Status: v1.PodStatus{
+				StartTime: &startTime,
+				ContainerStatuses: []v1.ContainerStatus{{
+					Name:  "application",
+					State: state,
+					Ready: true,
+				}},
+			},
+		}
+	}
+
+	decodeList := func() tps.LRPList {
+		var list tps.LRPList
+		Expect(response.Code).To(Equal(http.StatusOK))
+		err := json.NewDecoder(response.Body).Decode(&list)
+		Expect(err).NotTo(HaveOccurred())
+		return list
+	}
+
+	running := v1.ContainerState{Running: &v1.ContainerStateRunning{}}
+	crashed := v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1}}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		processGuids = []helpers.ProcessGuid{}
+		for i := 0; i < 3; i++ {
+			appGuid, _ := uuid.NewV4()
+			appVersion, _ := uuid.NewV4()
+			pg, err := helpers.NewProcessGuid(appGuid.String() + "-" + appVersion.String())
+			Expect(err).NotTo(HaveOccurred())
+			processGuids = append(processGuids, pg)
+		}
+		// pages are ordered by shortened guid
+		sort.Sort(byShortenedGuid(processGuids))
+
+		source = podsource.NewMemorySource()
+		source.AddPod(
+			newPod(processGuids[0], "a-1", running, map[string]string{"team": "blue"}),
+			newPod(processGuids[1], "b-1", running, nil),
+			newPod(processGuids[1], "b-2", crashed, nil),
+			newPod(processGuids[2], "c-1", crashed, map[string]string{"team": "blue"}),
+		)
+
+		authorizer = &authfakes.FakeAuthorizer{}
+		handler = lrplist.NewHandler(source, authorizer, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+		response = httptest.NewRecorder()
+	})
+
+	newRequest := func(query string) *http.Request {
+		req, err := http.NewRequest("GET", "/v1/namespaces/space/actual_lrps?"+query, nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.ParseForm()
+		req.Form.Set(":ns", "space")
+		req.Header.Set("Authorization", "bearer token")
+		return req
+	}
+
+	JustBeforeEach(func() {
+		handler.ServeHTTP(response, request)
+	})
+
+	Context("with no parameters", func() {
+		BeforeEach(func() {
+			elsewhere := newPod(processGuids[0], "a-2", running, nil)
+			elsewhere.ObjectMeta.Namespace = "other-space"
+			unlabelled := newPod(processGuids[0], "x-1", running, nil)
+			unlabelled.ObjectMeta.Labels = nil
+			source.AddPod(elsewhere, unlabelled)
+
+			request = newRequest("")
+		})
+
+		It("lists the pods of the namespace that carry a process guid", func() {
+			list := decodeList()
+			Expect(list.Items[0].ProcessGuid).To(Equal(processGuids[0].String()))
+			Expect(list.Items[0].Instances).To(HaveLen(1))
+		})
+
+		It("returns every process with its instances", func() {
+			list := decodeList()
+			Expect(list.Continue).To(BeEmpty())
+			Expect(list.Items).To(HaveLen(3))
+			Expect(list.Items[0].ProcessGuid).To(Equal(processGuids[0].String()))
+			Expect(list.Items[1].ProcessGuid).To(Equal(processGuids[1].String()))
+			Expect(list.Items[1].Instances).To(HaveLen(2))
+			Expect(list.Items[2].ProcessGuid).To(Equal(processGuids[2].String()))
+		})
+	})
+
+	Context("with a label selector", func() {
+		BeforeEach(func() {
+			request = newRequest("labelSelector=team%3Dblue")
+		})
+
+		It("only returns the matching processes", func() {
+			list := decodeList()
+			Expect(list.Items).To(HaveLen(2))
+			Expect(list.Items[0].ProcessGuid).To(Equal(processGuids[0].String()))
+			Expect(list.Items[1].ProcessGuid).To(Equal(processGuids[2].String()))
+		})
+	})
+
+	Context("with a state filter", func() {
+		BeforeEach(func() {
+			request = newRequest("state=CRASHED")
+		})
+
+		It("only returns the instances in that state", func() {
+			list := decodeList()
+			Expect(list.Items).To(HaveLen(2))
+			Expect(list.Items[0].ProcessGuid).To(Equal(processGuids[1].String()))
+			Expect(list.Items[0].Instances).To(HaveLen(1))
+			Expect(list.Items[0].Instances[0].State).To(Equal(cc_messages.LRPInstanceStateCrashed))
+			Expect(list.Items[1].ProcessGuid).To(Equal(processGuids[2].String()))
+		})
+	})
+
+	Context("with a limit", func() {
+		BeforeEach(func() {
+			request = newRequest("limit=2")
+		})
+
+		It("returns a page and a continue token", func() {
+			list := decodeList()
+			Expect(list.Items).To(HaveLen(2))
+			Expect(list.Continue).NotTo(BeEmpty())
+
+			response = httptest.NewRecorder()
+			handler.ServeHTTP(response, newRequest("limit=2&continue="+list.Continue))
+
+			list = decodeList()
+			Expect(list.Items).To(HaveLen(1))
+			Expect(list.Items[0].ProcessGuid).To(Equal(processGuids[2].String()))
+			Expect(list.Continue).To(BeEmpty())
+		})
+	})
+
+	Context("with replication controllers desiring more instances", func() {
+		BeforeEach(func() {
+			source.AddReplicationController(v1.ReplicationController{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "rc-a",
+					Namespace: "space",
+					Labels: map[string]string{
+						"cloudfoundry.org/process-guid": processGuids[0].ShortenedGuid(),
+					},
+				},
+				Spec: v1.ReplicationControllerSpec{Replicas: helpers.Int32Ptr(2)},
+			})
+			request = newRequest("")
+		})
+
+		It("reports the missing instances", func() {
+			list := decodeList()
+			Expect(list.Items[0].Instances).To(HaveLen(2))
+			Expect(list.Items[0].Instances[1].State).To(Equal(cc_messages.LRPInstanceStateDown))
+		})
+	})
+
+	Context("with replica sets desiring more instances", func() {
+		BeforeEach(func() {
+			source.AddReplicaSet(v1beta1.ReplicaSet{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "rs-a",
+					Namespace: "space",
+					Labels: map[string]string{
+						"cloudfoundry.org/process-guid": processGuids[0].ShortenedGuid(),
+					},
+				},
+				Spec: v1beta1.ReplicaSetSpec{Replicas: helpers.Int32Ptr(3)},
+			})
+			request = newRequest("")
+		})
+
+		It("reports the missing instances", func() {
+			list := decodeList()
+			Expect(list.Items[0].Instances).To(HaveLen(3))
+			Expect(list.Items[0].Instances[2].State).To(Equal(cc_messages.LRPInstanceStateDown))
+		})
+	})
+
+	Context("with invalid parameters", func() {
+		for _, query := range []string{"labelSelector=%3D%3D%3D", "limit=0", "limit=many", "continue=%25%25", "state=ASLEEP"} {
+			query := query
+
+			Context("?"+query, func() {
+				BeforeEach(func() {
+					request = newRequest(query)
+				})
+
+				It("responds with a 400", func() {
+					Expect(response.Code).To(Equal(http.StatusBadRequest))
+				})
+			})
+		}
+	})
+
+	Context("when kubernetes cannot be reached", func() {
+		BeforeEach(func() {
+			source.FailWith(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			request = newRequest("")
+		})
+
+		It("responds with a 503", func() {
+			Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Describe("authorization", func() {
+		var fakeSource *podsourcefakes.FakePodSource
+
+		BeforeEach(func() {
+			fakeSource = &podsourcefakes.FakePodSource{
+				PodsStub:                   source.Pods,
+				ReplicationControllersStub: source.ReplicationControllers,
+				ReplicaSetsStub:            source.ReplicaSets,
+			}
+			handler = lrplist.NewHandler(fakeSource, authorizer, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+			request = newRequest("")
+		})
+
+		It("checks the token against the space of the namespace", func() {
+			Expect(response.Code).To(Equal(http.StatusOK))
+			Expect(authorizer.AuthorizeSpaceCallCount()).To(Equal(1))
+			authorization, spaceGuid := authorizer.AuthorizeSpaceArgsForCall(0)
+			Expect(authorization).To(Equal("bearer token"))
+			Expect(spaceGuid).To(Equal("space"))
+			Expect(authorizer.AuthorizeAdminCallCount()).To(Equal(0))
+		})
+
+		Context("without an authorization header", func() {
+			BeforeEach(func() {
+				request.Header.Del("Authorization")
+			})
+
+			It("responds with a 401 and lists nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusUnauthorized))
+				Expect(authorizer.AuthorizeSpaceCallCount()).To(Equal(0))
+				Expect(fakeSource.PodsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with a bogus token", func() {
+			BeforeEach(func() {
+				authorizer.AuthorizeSpaceReturns(auth.ErrUnauthorized)
+			})
+
+			It("responds with a 401 and lists nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusUnauthorized))
+				Expect(fakeSource.PodsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with a token that cannot see the space", func() {
+			BeforeEach(func() {
+				authorizer.AuthorizeSpaceReturns(auth.ErrForbidden)
+			})
+
+			Context("and is not an admin's", func() {
+				BeforeEach(func() {
+					authorizer.AuthorizeAdminReturns(auth.ErrForbidden)
+				})
+
+				It("responds with a 403 and lists nothing", func() {
+					Expect(response.Code).To(Equal(http.StatusForbidden))
+					Expect(fakeSource.PodsCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("but is an admin's", func() {
+				It("lists the pods of the namespace", func() {
+					Expect(response.Code).To(Equal(http.StatusOK))
+					Expect(authorizer.AuthorizeAdminCallCount()).To(Equal(1))
+					Expect(fakeSource.PodsCallCount()).To(Equal(1))
+				})
+			})
+		})
+	})
+
+	Describe("the cluster-wide route", func() {
+		var fakeSource *podsourcefakes.FakePodSource
+
+		BeforeEach(func() {
+			fakeSource = &podsourcefakes.FakePodSource{
+				PodsStub:                   source.Pods,
+				ReplicationControllersStub: source.ReplicationControllers,
+				ReplicaSetsStub:            source.ReplicaSets,
+			}
+			handler = lrplist.NewClusterHandler(fakeSource, authorizer, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+			request = newRequest("")
+			request.Header.Del("Authorization")
+		})
+
+		It("requires an authorization header", func() {
+			Expect(response.Code).To(Equal(http.StatusUnauthorized))
+			Expect(authorizer.AuthorizeAdminCallCount()).To(Equal(0))
+			Expect(fakeSource.PodsCallCount()).To(Equal(0))
+		})
+
+		Context("with an admin's token", func() {
+			BeforeEach(func() {
+				request.Header.Set("Authorization", "bearer token")
+			})
+
+			It("lists the pods of every namespace", func() {
+				Expect(response.Code).To(Equal(http.StatusOK))
+				Expect(authorizer.AuthorizeAdminCallCount()).To(Equal(1))
+				Expect(authorizer.AuthorizeAdminArgsForCall(0)).To(Equal("bearer token"))
+				namespace, _ := fakeSource.PodsArgsForCall(0)
+				Expect(namespace).To(Equal(api.NamespaceAll))
+			})
+		})
+
+		Context("with a bogus token", func() {
+			BeforeEach(func() {
+				request.Header.Set("Authorization", "bearer bogus")
+				authorizer.AuthorizeAdminReturns(auth.ErrUnauthorized)
+			})
+
+			It("responds with a 401 and lists nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusUnauthorized))
+				Expect(fakeSource.PodsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("with a token that is not an admin's", func() {
+			BeforeEach(func() {
+				request.Header.Set("Authorization", "bearer developer")
+				authorizer.AuthorizeAdminReturns(auth.ErrForbidden)
+			})
+
+			It("responds with a 403 and lists nothing", func() {
+				Expect(response.Code).To(Equal(http.StatusForbidden))
+				Expect(fakeSource.PodsCallCount()).To(Equal(0))
+			})
+		})
+	})
+})
+
+type byShortenedGuid []helpers.ProcessGuid
+
+func (p byShortenedGuid) Len() int           { return len(p) }
+func (p byShortenedGuid) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p byShortenedGuid) Less(i, j int) bool { return p[i].ShortenedGuid() < p[j].ShortenedGuid() }
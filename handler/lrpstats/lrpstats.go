@@ -1,23 +1,24 @@
 package lrpstats
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
+	"time"
 
 	"github.com/cloudfoundry-incubator/bbs/models"
 	"github.com/cloudfoundry-incubator/nsync/helpers"
 	"github.com/cloudfoundry-incubator/nsync/recipebuilder"
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/cloudfoundry-incubator/tps/tc_client"
+	"github.com/cloudfoundry-incubator/tps/topology"
 	"github.com/cloudfoundry/sonde-go/events"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
-	kubeerrors "k8s.io/kubernetes/pkg/api/errors"
-
-	"k8s.io/kubernetes/pkg/api"
-	v1core "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1"
-	"k8s.io/kubernetes/pkg/labels"
 )
 
 //go:generate counterfeiter -o fakes/fake_noaaclient.go . NoaaClient
@@ -27,20 +28,48 @@ type NoaaClient interface {
 }
 
 type handler struct {
-	k8sClient  v1core.CoreInterface
-	noaaClient NoaaClient
-	clock      clock.Clock
-	logger     lager.Logger
+	podSource      podsource.PodSource
+	noaaClient     NoaaClient
+	nodes          topology.NodeListers
+	clock          clock.Clock
+	stateConfig    lrpstatus.StateConfig
+	requestTimeout time.Duration
+	metricsTimeout time.Duration
+	detailed       bool
+	logger         lager.Logger
 }
 
-func NewHandler(k8sClient v1core.CoreInterface, noaaClient NoaaClient, clk clock.Clock, logger lager.Logger) http.Handler {
-	return &handler{k8sClient: k8sClient, noaaClient: noaaClient, clock: clk, logger: logger}
+func NewHandler(podSource podsource.PodSource, noaaClient NoaaClient, clk clock.Clock, stateConfig lrpstatus.StateConfig, requestTimeout, metricsTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:      podSource,
+		noaaClient:     noaaClient,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		metricsTimeout: metricsTimeout,
+		logger:         logger,
+	}
+}
+
+// the v2 handler responds with a tps.LRPStatus carrying tps.LRPInstances
+func NewV2Handler(podSource podsource.PodSource, noaaClient NoaaClient, nodes topology.NodeListers, clk clock.Clock, stateConfig lrpstatus.StateConfig, requestTimeout, metricsTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:      podSource,
+		noaaClient:     noaaClient,
+		nodes:          nodes,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		metricsTimeout: metricsTimeout,
+		detailed:       true,
+		logger:         logger,
+	}
 }
 
 func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	authorization := r.Header.Get("Authorization")
 	if authorization == "" {
-		w.WriteHeader(http.StatusUnauthorized)
+		tpshelpers.WriteError(w, http.StatusUnauthorized, tps.Unauthorized, "missing authorization header")
 		return
 	}
 
@@ -49,49 +78,73 @@ func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	logger := handler.logger.Session("lrp-stats", lager.Data{"process-guid": guid})
 
 	if guid == "" {
-		w.WriteHeader(http.StatusBadRequest)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidProcessGuid, "missing process guid")
 		return
 	}
 
 	pg, err := helpers.NewProcessGuid(guid)
 	if err != nil {
 		logger.Error("invalid-process-guid", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidProcessGuid, err.Error())
 		return
 	}
 
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
+
 	logger.Info("fetching-actual-lrp-info")
-	actualLRPs, err := handler.k8sClient.Pods(api.NamespaceAll).List(api.ListOptions{
-		LabelSelector: labels.Set{"cloudfoundry.org/process-guid": pg.ShortenedGuid()}.AsSelector(),
-	})
+	process, err := tpshelpers.FetchProcess(ctx, handler.podSource, pg.ShortenedGuid())
 
-	if actualLRPs != nil && len(actualLRPs.Items) == 0 && responseCodeFromError(err) == http.StatusNotFound {
-		logger.Info("fetching-actual-lrp-not-found")
-		w.WriteHeader(http.StatusNotFound)
+	switch err {
+	case nil:
+	case tpshelpers.ErrTimedOut:
+		logger.Error("fetching-actual-lrp-info-timed-out", err)
+		tpshelpers.WriteUpstreamError(w, err)
 		return
-	}
-
-	if err != nil {
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	default:
 		logger.Error("fetching-actual-lrp-info-failed", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		tpshelpers.WriteUpstreamError(w, err)
 		return
 	}
 
-	if actualLRPs == nil || len(actualLRPs.Items) == 0 {
-		logger.Error("fetching-actual-lrp-info-failed", errors.New("invalid-actual-lrp"))
-		w.WriteHeader(http.StatusInternalServerError)
+	if !process.Exists() {
+		logger.Info("fetching-actual-lrp-not-found")
+		tpshelpers.WriteError(w, http.StatusNotFound, tps.ProcessNotFound, "no instances found for process guid "+guid)
 		return
 	}
 
-	logGuid := actualLRPs.Items[0].ObjectMeta.Annotations["cloudfoundry.org/log-guid"]
-	logger.Info("fetching-container-metrics", lager.Data{
-		"log-guid": logGuid,
-	})
-	metrics, err := handler.noaaClient.ContainerMetrics(logGuid, authorization)
-	if err != nil {
-		handler.logger.Error("fetching-container-metrics-failed", err, lager.Data{
+	var metrics []*events.ContainerMetric
+	if len(process.Pods) > 0 {
+		logGuid := process.Pods[0].ObjectMeta.Annotations[podsource.LogGuidAnnotation]
+		logger.Info("fetching-container-metrics", lager.Data{
 			"log-guid": logGuid,
 		})
+		metrics, err = handler.containerMetrics(ctx, logGuid, authorization)
+		if partial, ok := err.(*tc_client.PartialResultError); ok {
+			logger.Info("container-metrics-incomplete", lager.Data{
+				"log-guid":    logGuid,
+				"undecodable": partial.Failed,
+				"error":       partial.Err.Error(),
+			})
+			err = nil
+		}
+		switch err {
+		case nil:
+		case context.Canceled:
+			logger.Info("request-cancelled")
+			return
+		case tpshelpers.ErrTimedOut:
+			logger.Error("fetching-container-metrics-timed-out", err, lager.Data{
+				"log-guid": logGuid,
+			})
+		default:
+			logger.Error("fetching-container-metrics-failed", err, lager.Data{
+				"log-guid": logGuid,
+			})
+		}
 	}
 
 	metricsByInstanceIndex := make(map[uint]*cc_messages.LRPInstanceStats)
@@ -106,11 +159,10 @@ func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	instances := lrpstatus.LRPInstances(actualLRPs.Items,
-		handler.clock,
-	)
+	instances := lrpstatus.ProcessInstances(pg, process, handler.clock, handler.stateConfig)
 
 	for i, instance := range instances {
+		instances[i].Stats = metricsByInstanceIndex[instance.Index]
 		if instance.State == cc_messages.LRPInstanceStateCrashed {
 			instances[i].Uptime = 0
 			if instances[i].Stats != nil {
@@ -121,15 +173,47 @@ func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
-	err = json.NewEncoder(w).Encode(instances)
+	if handler.detailed {
+		status := tps.LRPStatus{ProcessGuid: guid, Instances: instances}
+		status.Zones = topology.Place(status.Instances, handler.nodes)
+		err = json.NewEncoder(w).Encode(status)
+	} else {
+		err = json.NewEncoder(w).Encode(lrpstatus.CCInstances(instances))
+	}
 	if err != nil {
 		handler.logger.Error("stream-response-failed", err, lager.Data{"guid": guid})
 	}
 }
 
+// fetches the container metrics of an app, leaving them out if they take
+// longer than the metrics timeout so the instances can still be reported.
+// The metrics that could be decoded come with a partial result error.
+func (handler *handler) containerMetrics(ctx context.Context, logGuid, authorization string) ([]*events.ContainerMetric, error) {
+	if handler.metricsTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, handler.metricsTimeout)
+		defer cancel()
+	}
+
+	var metrics []*events.ContainerMetric
+	err := tpshelpers.CallWithContext(ctx, func() error {
+		var err error
+		metrics, err = handler.noaaClient.ContainerMetrics(logGuid, authorization)
+		return err
+	})
+	if _, partial := err.(*tc_client.PartialResultError); partial {
+		return metrics, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
 func getDefaultPort(mappings []*models.PortMapping) uint16 {
 	for _, mapping := range mappings {
 		if mapping.ContainerPort == recipebuilder.DefaultPort {
@@ -139,17 +223,3 @@ func getDefaultPort(mappings []*models.PortMapping) uint16 {
 
 	return 0
 }
-
-func responseCodeFromError(err error) int {
-	switch err := err.(type) {
-	case *kubeerrors.StatusError:
-		switch err.ErrStatus.Code {
-		case http.StatusNotFound:
-			return http.StatusNotFound
-		default:
-			return http.StatusInternalServerError
-		}
-	default:
-		return http.StatusInternalServerError
-	}
-}
@@ -3,6 +3,7 @@ package lrpstats_test
 import (
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -11,12 +12,18 @@ import (
 	kubeerrors "k8s.io/kubernetes/pkg/api/errors"
 	"k8s.io/kubernetes/pkg/api/unversioned"
 	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
 
 	"github.com/cloudfoundry-incubator/nsync/helpers"
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
-	handlerfakes "github.com/cloudfoundry-incubator/tps/handler/handler_fakes"
+	"github.com/cloudfoundry-incubator/tps"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstats"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstats/fakes"
+	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	podsourcefakes "github.com/cloudfoundry-incubator/tps/podsource/fakes"
+	"github.com/cloudfoundry-incubator/tps/tc_client"
+	"github.com/cloudfoundry-incubator/tps/topology"
 	"github.com/cloudfoundry/sonde-go/events"
 	"github.com/gogo/protobuf/proto"
 	"github.com/nu7hatch/gouuid"
@@ -33,27 +40,26 @@ var _ = Describe("Stats", func() {
 	const logGuid = "log-guid"
 
 	var (
-		handler        http.Handler
-		response       *httptest.ResponseRecorder
-		request        *http.Request
-		noaaClient     *fakes.FakeNoaaClient
-		fakeKubeClient *handlerfakes.FakeKubeClient
-		logger         *lagertest.TestLogger
-		fakeClock      *fakeclock.FakeClock
-		fakePod        *handlerfakes.FakePod
-		pod1           *v1.Pod
-		processGuid1   helpers.ProcessGuid
-		err            error
+		handler      http.Handler
+		response     *httptest.ResponseRecorder
+		request      *http.Request
+		noaaClient   *fakes.FakeNoaaClient
+		source       *podsource.MemorySource
+		logger       *lagertest.TestLogger
+		fakeClock    *fakeclock.FakeClock
+		pod1         *v1.Pod
+		processGuid1 helpers.ProcessGuid
+		err          error
 	)
 
 	BeforeEach(func() {
 		var err error
 
-		fakeKubeClient = &handlerfakes.FakeKubeClient{}
+		source = podsource.NewMemorySource()
 		noaaClient = &fakes.FakeNoaaClient{}
 		logger = lagertest.NewTestLogger("test")
 		fakeClock = fakeclock.NewFakeClock(time.Date(2008, 8, 8, 8, 8, 8, 8, time.UTC))
-		handler = lrpstats.NewHandler(fakeKubeClient, noaaClient, fakeClock, logger)
+		handler = lrpstats.NewHandler(source, noaaClient, fakeClock, lrpstatus.StateConfig{}, time.Minute, time.Minute, logger)
 		response = httptest.NewRecorder()
 		request, err = http.NewRequest("GET", "/v1/actual_lrps/:guid/stats", nil)
 		Expect(err).NotTo(HaveOccurred())
@@ -66,6 +72,7 @@ var _ = Describe("Stats", func() {
 	Describe("Validation", func() {
 		It("fails with a missing authorization header", func() {
 			Expect(response.Code).To(Equal(http.StatusUnauthorized))
+			Expect(decodeError(response).Code).To(Equal(tps.Unauthorized))
 		})
 
 		Context("with an authorization header", func() {
@@ -76,6 +83,18 @@ var _ = Describe("Stats", func() {
 			It("fails with no guid", func() {
 				Expect(response.Code).To(Equal(http.StatusBadRequest))
 			})
+
+			Context("with a malformed guid", func() {
+				BeforeEach(func() {
+					request.Form = url.Values{}
+					request.Form.Add(":guid", "not-a-process-guid")
+				})
+
+				It("responds with a 400 and an InvalidProcessGuid error", func() {
+					Expect(response.Code).To(Equal(http.StatusBadRequest))
+					Expect(decodeError(response).Code).To(Equal(tps.InvalidProcessGuid))
+				})
+			})
 		})
 	})
 
@@ -112,7 +131,6 @@ var _ = Describe("Stats", func() {
 			// 	models.NewPortMapping(1234, uint32(recipebuilder.DefaultPort)),
 			// )
 
-			fakePod = &handlerfakes.FakePod{}
 			actualTime := unversioned.NewTime(fakeClock.Now())
 			pod1 = &v1.Pod{
 				ObjectMeta: v1.ObjectMeta{
@@ -153,10 +171,7 @@ var _ = Describe("Stats", func() {
 				},
 			}
 
-			fakeKubeClient.PodsReturns(fakePod)
-			fakePod.ListReturns(&v1.PodList{
-				Items: []v1.Pod{*pod1},
-			}, nil)
+			source.AddPod(*pod1)
 		})
 
 		Context("when the LRP has terminated", func() {
@@ -181,7 +196,7 @@ var _ = Describe("Stats", func() {
 					//Port:         1234,
 					//NetInfo:      netInfo,
 					Since:  expectedSinceTime,
-					Uptime: 5,
+					Uptime: 0,
 					// Stats: &cc_messages.LRPInstanceStats{
 					// 	Time:          time.Unix(0, 0),
 					// 	CpuPercentage: 0,
@@ -278,12 +293,149 @@ var _ = Describe("Stats", func() {
 			})
 		})
 
+		Context("when there are metrics for the instance index", func() {
+			BeforeEach(func() {
+				noaaClient.ContainerMetricsReturns([]*events.ContainerMetric{
+					{
+						ApplicationId: proto.String("appId"),
+						InstanceIndex: proto.Int32(0),
+						CpuPercentage: proto.Float64(4),
+						MemoryBytes:   proto.Uint64(1024),
+						DiskBytes:     proto.Uint64(2048),
+					},
+				}, nil)
+			})
+
+			It("attaches the stats to the instance", func() {
+				var stats []cc_messages.LRPInstance
+				err := json.Unmarshal(response.Body.Bytes(), &stats)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stats).To(HaveLen(1))
+				Expect(stats[0].Stats).NotTo(BeNil())
+				Expect(stats[0].Stats.CpuPercentage).To(Equal(0.04))
+				Expect(stats[0].Stats.MemoryBytes).To(Equal(uint64(1024)))
+				Expect(stats[0].Stats.DiskBytes).To(Equal(uint64(2048)))
+			})
+		})
+
+		Context("when only some of the metrics could be decoded", func() {
+			BeforeEach(func() {
+				noaaClient.ContainerMetricsReturns([]*events.ContainerMetric{
+					{
+						ApplicationId: proto.String("appId"),
+						InstanceIndex: proto.Int32(0),
+						MemoryBytes:   proto.Uint64(1024),
+					},
+				}, &tc_client.PartialResultError{Failed: 1, Err: errors.New("unexpected EOF")})
+			})
+
+			It("attaches the stats it has", func() {
+				var stats []cc_messages.LRPInstance
+				Expect(response.Code).To(Equal(http.StatusOK))
+				err := json.Unmarshal(response.Body.Bytes(), &stats)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stats).To(HaveLen(1))
+				Expect(stats[0].Stats).NotTo(BeNil())
+				Expect(stats[0].Stats.MemoryBytes).To(Equal(uint64(1024)))
+			})
+
+			It("logs that they are incomplete", func() {
+				Expect(logger).To(Say("container-metrics-incomplete"))
+			})
+		})
+
+		Context("when serving the v2 route", func() {
+			BeforeEach(func() {
+				handler = lrpstats.NewV2Handler(source, noaaClient, topology.NodeListers{}, fakeClock, lrpstatus.StateConfig{}, time.Minute, time.Minute, logger)
+				noaaClient.ContainerMetricsReturns([]*events.ContainerMetric{
+					{
+						ApplicationId: proto.String("appId"),
+						InstanceIndex: proto.Int32(0),
+						CpuPercentage: proto.Float64(4),
+						MemoryBytes:   proto.Uint64(1024),
+						DiskBytes:     proto.Uint64(2048),
+					},
+				}, nil)
+			})
+
+			It("returns the detailed instances with their stats", func() {
+				var status tps.LRPStatus
+				Expect(response.Code).To(Equal(http.StatusOK))
+				err := json.Unmarshal(response.Body.Bytes(), &status)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(status.ProcessGuid).To(Equal(processGuid1.String()))
+				Expect(status.Instances).To(HaveLen(1))
+				Expect(status.Instances[0].PodName).To(Equal("pod-name"))
+				Expect(status.Instances[0].Stats).NotTo(BeNil())
+				Expect(status.Instances[0].Stats.MemoryBytes).To(Equal(uint64(1024)))
+			})
+		})
+
+		Context("when ContainerMetrics takes longer than the metrics timeout", func() {
+			var unblock chan struct{}
+
+			BeforeEach(func() {
+				unblock = make(chan struct{})
+				handler = lrpstats.NewHandler(source, noaaClient, fakeClock, lrpstatus.StateConfig{}, time.Minute, 10*time.Millisecond, logger)
+				noaaClient.ContainerMetricsStub = func(string, string) ([]*events.ContainerMetric, error) {
+					<-unblock
+					return nil, nil
+				}
+			})
+
+			AfterEach(func() {
+				close(unblock)
+			})
+
+			It("responds with the instances and no stats", func() {
+				var stats []cc_messages.LRPInstance
+				Expect(response.Code).To(Equal(http.StatusOK))
+				err := json.Unmarshal(response.Body.Bytes(), &stats)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stats).To(HaveLen(1))
+				Expect(stats[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
+				Expect(stats[0].Stats).To(BeNil())
+			})
+
+			It("logs the timeout", func() {
+				Expect(logger).To(Say("fetching-container-metrics-timed-out"))
+			})
+		})
+
+		Context("when listing the pods takes longer than the request timeout", func() {
+			var unblock chan struct{}
+
+			BeforeEach(func() {
+				unblock = make(chan struct{})
+				blockingSource := &podsourcefakes.FakePodSource{
+					PodsStub: func(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+						<-unblock
+						return source.Pods(namespace, selector)
+					},
+					ReplicationControllersStub: source.ReplicationControllers,
+					ReplicaSetsStub:            source.ReplicaSets,
+				}
+				handler = lrpstats.NewHandler(blockingSource, noaaClient, fakeClock, lrpstatus.StateConfig{}, 10*time.Millisecond, time.Minute, logger)
+			})
+
+			AfterEach(func() {
+				close(unblock)
+			})
+
+			It("responds with a 504", func() {
+				Expect(response.Code).To(Equal(http.StatusGatewayTimeout))
+			})
+
+			It("does not fetch container metrics", func() {
+				Expect(noaaClient.ContainerMetricsCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when fetching the desiredLRP fails", func() {
 			Context("when the desiredLRP is not found", func() {
 				BeforeEach(func() {
-					fakePod.ListReturns(&v1.PodList{
-						Items: []v1.Pod{},
-					}, &kubeerrors.StatusError{
+					source.FailWith(&kubeerrors.StatusError{
 						ErrStatus: unversioned.Status{
 							Message: "replication controller not found",
 							Status:  unversioned.StatusFailure,
@@ -295,29 +447,58 @@ var _ = Describe("Stats", func() {
 
 				It("responds with a 404", func() {
 					Expect(response.Code).To(Equal(http.StatusNotFound))
+					Expect(decodeError(response).Code).To(Equal(tps.ProcessNotFound))
 				})
 			})
 
 			Context("when another type of error occurs", func() {
 				BeforeEach(func() {
-					fakePod.ListReturns(&v1.PodList{
-						Items: []v1.Pod{},
-					}, errors.New("garbage"))
+					source.FailWith(errors.New("garbage"))
 				})
 
-				It("responds with a 500", func() {
+				It("responds with a 500 and an InternalError error", func() {
 					Expect(response.Code).To(Equal(http.StatusInternalServerError))
+					Expect(decodeError(response).Code).To(Equal(tps.InternalError))
 				})
 			})
 		})
 
-		Context("when fetching actualLRPs fails", func() {
+		Context("when no pods match the process guid", func() {
 			BeforeEach(func() {
-				fakePod.ListReturns(nil, errors.New("bad stuff happened"))
+				Expect(source.DeletePod(*pod1)).To(Succeed())
 			})
 
-			It("responds with a 500", func() {
-				Expect(response.Code).To(Equal(http.StatusInternalServerError))
+			It("responds with a 404", func() {
+				Expect(response.Code).To(Equal(http.StatusNotFound))
+				Expect(decodeError(response).Code).To(Equal(tps.ProcessNotFound))
+			})
+		})
+
+		Context("when the kubernetes API server responds with an error", func() {
+			BeforeEach(func() {
+				source.FailWith(&kubeerrors.StatusError{
+					ErrStatus: unversioned.Status{
+						Status: unversioned.StatusFailure,
+						Reason: unversioned.StatusReasonInternalError,
+						Code:   http.StatusInternalServerError,
+					},
+				})
+			})
+
+			It("responds with a 502", func() {
+				Expect(response.Code).To(Equal(http.StatusBadGateway))
+				Expect(decodeError(response).Code).To(Equal(tps.UpstreamError))
+			})
+		})
+
+		Context("when kubernetes cannot be reached", func() {
+			BeforeEach(func() {
+				source.FailWith(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			})
+
+			It("responds with a 503", func() {
+				Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+				Expect(decodeError(response).Code).To(Equal(tps.UpstreamUnavailable))
 			})
 
 			It("logs the failure", func() {
@@ -327,6 +508,13 @@ var _ = Describe("Stats", func() {
 	})
 })
 
+func decodeError(response *httptest.ResponseRecorder) tps.Error {
+	var tpsErr tps.Error
+	err := json.Unmarshal(response.Body.Bytes(), &tpsErr)
+	Expect(err).NotTo(HaveOccurred())
+	return tpsErr
+}
+
 func generateProcessGuid() (helpers.ProcessGuid, error) {
 	appGuid, _ := uuid.NewV4()
 
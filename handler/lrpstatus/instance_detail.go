@@ -0,0 +1,90 @@
+package lrpstatus
+
+import (
+	"strings"
+
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+)
+
+const applicationContainerName = "application"
+
+// collects the pod and container details reported by the v2 routes
+func instanceDetail(pod v1.Pod) tps.LRPInstance {
+	instance := tps.LRPInstance{
+		NodeName:  pod.Spec.NodeName,
+		Cluster:   pod.ObjectMeta.Annotations[podsource.ClusterAnnotation],
+		PodName:   pod.ObjectMeta.Name,
+		Namespace: pod.ObjectMeta.Namespace,
+	}
+
+	if pod.Status.StartTime != nil {
+		instance.PodStartTime = unixTime(*pod.Status.StartTime)
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		instance.Conditions = append(instance.Conditions, tps.PodCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: unixTime(condition.LastTransitionTime),
+		})
+	}
+
+	containerStatus := ApplicationContainerStatus(pod)
+	if containerStatus == nil {
+		return instance
+	}
+
+	instance.RestartCount = containerStatus.RestartCount
+	instance.Ready = containerStatus.Ready
+	instance.ImageDigest = imageDigest(containerStatus.ImageID)
+
+	terminated := containerStatus.State.Terminated
+	if terminated == nil {
+		terminated = containerStatus.LastTerminationState.Terminated
+	}
+	if terminated != nil {
+		instance.LastTermination = &tps.TerminationState{
+			Reason:     terminated.Reason,
+			ExitCode:   terminated.ExitCode,
+			Message:    terminated.Message,
+			FinishedAt: unixTime(terminated.FinishedAt),
+		}
+	}
+
+	return instance
+}
+
+// returns nil when the kubelet has not reported the application container yet
+func ApplicationContainerStatus(pod v1.Pod) *v1.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == applicationContainerName {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+// the kubelet reports image ids as docker://sha256:... or
+// docker-pullable://repo@sha256:...; keep only the digest
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if i := strings.Index(imageID, "://"); i >= 0 {
+		return imageID[i+3:]
+	}
+	return imageID
+}
+
+// unset kubernetes timestamps are reported as 0 rather than year 1
+func unixTime(t unversioned.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
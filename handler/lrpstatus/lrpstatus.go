@@ -1,32 +1,54 @@
 package lrpstatus
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/tps"
 	tpshelpers "github.com/cloudfoundry-incubator/tps/helpers"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/cloudfoundry-incubator/tps/topology"
 	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
-	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/v1"
-	v1core "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1"
-	"k8s.io/kubernetes/pkg/labels"
 )
 
 type handler struct {
-	k8sClient v1core.CoreInterface
-	clock     clock.Clock
-	logger    lager.Logger
+	podSource      podsource.PodSource
+	nodes          topology.NodeListers
+	clock          clock.Clock
+	stateConfig    StateConfig
+	requestTimeout time.Duration
+	detailed       bool
+	logger         lager.Logger
 }
 
-func NewHandler(k8sClient v1core.CoreInterface, clk clock.Clock, logger lager.Logger) http.Handler {
+func NewHandler(podSource podsource.PodSource, clk clock.Clock, stateConfig StateConfig, requestTimeout time.Duration, logger lager.Logger) http.Handler {
 	return &handler{
-		k8sClient: k8sClient,
-		clock:     clk,
-		logger:    logger,
+		podSource:      podSource,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		logger:         logger,
+	}
+}
+
+// the v2 handler responds with a tps.LRPStatus carrying tps.LRPInstances
+// instead of the bare cc_messages.LRPInstance list CC expects from v1
+func NewV2Handler(podSource podsource.PodSource, nodes topology.NodeListers, clk clock.Clock, stateConfig StateConfig, requestTimeout time.Duration, logger lager.Logger) http.Handler {
+	return &handler{
+		podSource:      podSource,
+		nodes:          nodes,
+		clock:          clk,
+		stateConfig:    stateConfig,
+		requestTimeout: requestTimeout,
+		detailed:       true,
+		logger:         logger,
 	}
 }
 
@@ -39,91 +61,308 @@ func (handler *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	pg, err := helpers.NewProcessGuid(guid)
 	if err != nil {
 		logger.Error("invalid-process-guid", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		tpshelpers.WriteError(w, http.StatusBadRequest, tps.InvalidProcessGuid, err.Error())
 		return
 	}
 
 	logger.Info("shortened-process-guid", lager.Data{"shortened-process-guid": pg.ShortenedGuid()})
 
-	actualPodsList, err := handler.k8sClient.Pods(api.NamespaceAll).List(api.ListOptions{
-		LabelSelector: labels.Set{"cloudfoundry.org/process-guid": pg.ShortenedGuid()}.AsSelector(),
-	})
+	ctx, cancel := tpshelpers.RequestContext(r, handler.requestTimeout)
+	defer cancel()
 
-	if err != nil {
+	process, err := tpshelpers.FetchProcess(ctx, handler.podSource, pg.ShortenedGuid())
+	switch err {
+	case nil:
+	case tpshelpers.ErrTimedOut:
+		logger.Error("fetching-actual-lrp-info-timed-out", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	default:
 		logger.Error("failed-fetching-actual-lrp-info", err)
-		w.WriteHeader(http.StatusInternalServerError)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
+
+	if !process.Exists() {
+		logger.Info("actual-lrp-not-found")
+		tpshelpers.WriteError(w, http.StatusNotFound, tps.ProcessNotFound, "no instances found for process guid "+guid)
 		return
 	}
 
-	instances := LRPInstances(actualPodsList.Items,
-		handler.clock,
-	)
+	instances := ProcessInstances(pg, process, handler.clock, handler.stateConfig)
+
+	logger.Debug("fetched-actual-lrp-info-instances", lager.Data{"instances": instances})
+
+	if !handler.detailed {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(CCInstances(instances))
+		if err != nil {
+			logger.Error("stream-response-failed", err)
+		}
+		return
+	}
+
+	appPods, err := tpshelpers.ListAppPods(ctx, handler.podSource, pg.AppGuid.String())
+	switch err {
+	case nil:
+	case context.Canceled:
+		logger.Info("request-cancelled")
+		return
+	default:
+		logger.Error("failed-fetching-app-versions", err)
+		tpshelpers.WriteUpstreamError(w, err)
+		return
+	}
 
-	logger.Debug("failed-fetching-actual-lrp-info-instances", lager.Data{"instances": instances})
-	err = json.NewEncoder(w).Encode(instances)
+	status := VersionedStatus(pg, instances, appPods, handler.clock, handler.stateConfig)
+	status.Zones = topology.Place(status.Instances, handler.nodes)
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(status)
 	if err != nil {
 		logger.Error("stream-response-failed", err)
 	}
 }
 
-func LRPInstances(
+// the instances of the pods of a process followed by a DOWN placeholder for
+// each desired index that has no pod. Evicted pods and pods being deleted do
+// not count towards the desired instances; they are reported after the
+// desired indexes, pods being deleted only when stateConfig asks for them.
+func ProcessInstances(pg helpers.ProcessGuid, process *tpshelpers.Process, clk clock.Clock, stateConfig StateConfig) []tps.LRPInstance {
+	instances := []tps.LRPInstance{}
+	stopped := []tps.LRPInstance{}
+	for _, indexed := range IndexPods(process) {
+		pod := indexed.Pod
+		if terminating(pod) && !stateConfig.reportTerminating() {
+			continue
+		}
+
+		instance := podInstance(pod, indexed.Index, clk, stateConfig)
+		if terminating(pod) || evicted(pod) {
+			stopped = append(stopped, instance)
+		} else {
+			instances = append(instances, instance)
+		}
+	}
+
+	instances = append(instances, MissingInstances(pg.String(), instances, process.DesiredInstances, clk)...)
+	instances = append(instances, stopped...)
+	for i := range instances {
+		instances[i].Version = ProcessVersion(pg)
+		instances[i].Current = true
+	}
+	return instances
+}
+
+// DOWN placeholders for the desired indexes no instance has, like the
+// UNCLAIMED actual LRPs diego reported for instances it had not placed
+func MissingInstances(processGuid string, instances []tps.LRPInstance, desired int, clk clock.Clock) []tps.LRPInstance {
+	used := map[uint]bool{}
+	for _, instance := range instances {
+		used[instance.Index] = true
+	}
+
+	missing := []tps.LRPInstance{}
+	for index := uint(0); index < uint(desired); index++ {
+		if used[index] {
+			continue
+		}
+		missing = append(missing, tps.LRPInstance{
+			LRPInstance: cc_messages.LRPInstance{
+				ProcessGuid: processGuid,
+				Index:       index,
+				State:       cc_messages.LRPInstanceStateDown,
+				Since:       clk.Now().Unix(),
+			},
+		})
+	}
+	return missing
+}
+
+// a pod together with the index its instance is reported at
+type IndexedPod struct {
+	Pod   v1.Pod
+	Index uint
+}
+
+// the pods of a process numbered the way ProcessInstances numbers their
+// instances: the pods that make up the process from 0 in uid order, then
+// the evicted pods and the pods being deleted after the desired indexes.
+// Pods whose state cannot be told yet get no index.
+func IndexPods(process *tpshelpers.Process) []IndexedPod {
+	live := []v1.Pod{}
+	evictedPods := []v1.Pod{}
+	terminatingPods := []v1.Pod{}
+	for _, pod := range tpshelpers.SortPods(process.Pods) {
+		switch {
+		case terminating(pod):
+			terminatingPods = append(terminatingPods, pod)
+		case evicted(pod):
+			evictedPods = append(evictedPods, pod)
+		case reported(pod):
+			live = append(live, pod)
+		}
+	}
+
+	offset := len(live)
+	if process.DesiredInstances > offset {
+		offset = process.DesiredInstances
+	}
+
+	indexed := make([]IndexedPod, 0, len(process.Pods))
+	for i, pod := range live {
+		indexed = append(indexed, IndexedPod{Pod: pod, Index: uint(i)})
+	}
+	for i, pod := range append(evictedPods, terminatingPods...) {
+		indexed = append(indexed, IndexedPod{Pod: pod, Index: uint(offset + i)})
+	}
+	return indexed
+}
+
+// returns the pod of the instance ProcessInstances reports at index. Pods
+// being deleted are not returned as they are already on their way out.
+func PodAtIndex(process *tpshelpers.Process, index uint) (v1.Pod, bool) {
+	for _, indexed := range IndexPods(process) {
+		if indexed.Index == index && !terminating(indexed.Pod) {
+			return indexed.Pod, true
+		}
+	}
+	return v1.Pod{}, false
+}
+
+// strips the v2 detail, leaving the instances in the shape CC expects
+func CCInstances(details []tps.LRPInstance) []cc_messages.LRPInstance {
+	instances := make([]cc_messages.LRPInstance, len(details))
+	for i := range details {
+		instances[i] = details[i].LRPInstance
+	}
+	return instances
+}
+
+// the instances of the pods numbered from 0 in uid order, leaving out pods
+// whose state cannot be told yet without using up an index
+func LRPInstanceDetails(
 	actualPods []v1.Pod,
 	clk clock.Clock,
-) []cc_messages.LRPInstance {
-	instances := make([]cc_messages.LRPInstance, len(actualPods))
+	stateConfig StateConfig,
+) []tps.LRPInstance {
+	instances := []tps.LRPInstance{}
+	for _, pod := range tpshelpers.SortPods(actualPods) {
+		if reported(pod) {
+			instances = append(instances, podInstance(pod, uint(len(instances)), clk, stateConfig))
+		}
+	}
+	return instances
+}
 
-	j := 0
+func podInstance(pod v1.Pod, index uint, clk clock.Clock, stateConfig StateConfig) tps.LRPInstance {
+	shortenedGuid := pod.ObjectMeta.Labels[podsource.ProcessGuidLabel]
+	// pods are listed by this label, so it always decodes
+	processGuid, _ := helpers.DecodeProcessGuid(shortenedGuid)
 
-	actualPods = tpshelpers.SortPods(actualPods)
+	state, details := instanceState(pod, stateConfig)
 
-	for i, pod := range actualPods {
-		//actual, _ := actualLRPGroup.Resolve()
-		shortenedGuid := pod.ObjectMeta.Labels["cloudfoundry.org/process-guid"]
-		processGuid, err := helpers.DecodeProcessGuid(shortenedGuid)
+	instance := instanceDetail(pod)
+	instance.LRPInstance = cc_messages.LRPInstance{
+		ProcessGuid:  processGuid.String(),
+		InstanceGuid: string(pod.ObjectMeta.UID),
+		Index:        index,
+		State:        state,
+		Details:      details,
+	}
+	instance.Since, instance.Uptime = containerTimes(pod, clk)
 
-		if err != nil {
-			// ignore this LRPInstance
-			//logger.Error("error get process guid", err)
+	return instance
+}
+
+// the state of the instance of the pod, or "" when the pod has been placed
+// but does not report its application container yet
+func instanceState(pod v1.Pod, stateConfig StateConfig) (cc_messages.LRPInstanceState, string) {
+	if state, details, stopped := terminationState(pod); stopped {
+		return state, details
+	}
+	if state, details, unclaimed := unclaimedState(pod); unclaimed {
+		return state, details
+	}
+	return getApplicationContainerState(pod, stateConfig)
+}
+
+// whether the pod has an instance to report; stateConfig only changes which
+// state a running container is in, not whether it has one
+func reported(pod v1.Pod) bool {
+	state, _ := instanceState(pod, StateConfig{})
+	return state != ""
+}
+
+// since and uptime of the application container rather than the pod, so
+// restarts inside the same pod reset them the way a crash reset them on diego
+func containerTimes(pod v1.Pod, clk clock.Clock) (int64, int64) {
+	// pods the scheduler has not placed yet have no start time
+	since := unixTime(pod.ObjectMeta.CreationTimestamp)
+	if pod.Status.StartTime != nil {
+		since = pod.Status.StartTime.Unix()
+	}
+
+	containerStatus := ApplicationContainerStatus(pod)
+	if containerStatus == nil {
+		return since, 0
+	}
+
+	state := containerStatus.State
+	switch {
+	case state.Running != nil:
+		if !state.Running.StartedAt.IsZero() {
+			since = state.Running.StartedAt.Unix()
 		}
-		instanceState := getApplicationContainerState(pod)
-		if instanceState != "" {
-			instance := cc_messages.LRPInstance{
-				ProcessGuid:  processGuid.String(), // TODO: convert it to full pg
-				InstanceGuid: string(pod.ObjectMeta.UID),
-				Index:        uint(i),
-				Since:        pod.Status.StartTime.UnixNano() / 1e9,
-				Uptime:       (clk.Now().UnixNano() - pod.Status.StartTime.UnixNano()) / 1e9,
-				State:        instanceState,
-			}
-			instances[j] = instance
-			j = j + 1
+		return since, clk.Now().Unix() - since
+	case state.Terminated != nil:
+		if !state.Terminated.FinishedAt.IsZero() {
+			since = state.Terminated.FinishedAt.Unix()
+		}
+	case containerStatus.LastTerminationState.Terminated != nil:
+		// waiting to be restarted after a crash
+		if finishedAt := containerStatus.LastTerminationState.Terminated.FinishedAt; !finishedAt.IsZero() {
+			since = finishedAt.Unix()
 		}
 	}
 
-	// only return instances up to j
-	if j >= 1 {
-		return instances[0:j]
-	} else {
-		return nil
-	}
+	return since, 0
 }
 
 // return nil if we cannot find container name == "application"
-func getApplicationContainerState(pod v1.Pod) cc_messages.LRPInstanceState {
+func getApplicationContainerState(pod v1.Pod, stateConfig StateConfig) (cc_messages.LRPInstanceState, string) {
 	containerStatuses := pod.Status.ContainerStatuses
 	for _, containerStatus := range containerStatuses {
-		if containerStatus.Name == "application" {
-			if containerStatus.State.Waiting != nil {
-				return cc_messages.LRPInstanceStateStarting
+		if containerStatus.Name == applicationContainerName {
+			if details, crashed := crashState(containerStatus); crashed {
+				return cc_messages.LRPInstanceStateCrashed, details
+			} else if containerStatus.State.Waiting != nil {
+				return cc_messages.LRPInstanceStateStarting, ""
 			} else if containerStatus.State.Running != nil {
-				return cc_messages.LRPInstanceStateRunning
+				// diego only reported RUNNING once the health check passed
+				if !stateConfig.IgnoreReadiness && !containerStatus.Ready && !podReady(pod) {
+					return cc_messages.LRPInstanceStateStarting, notReadyDetails
+				}
+				return cc_messages.LRPInstanceStateRunning, ""
 			} else if containerStatus.State.Terminated != nil {
-				return cc_messages.LRPInstanceStateDown
+				return cc_messages.LRPInstanceStateDown, ""
 			} else {
-				return cc_messages.LRPInstanceStateUnknown
+				return cc_messages.LRPInstanceStateUnknown, ""
 			}
 		}
 	}
 
-	return ""
+	return "", ""
+}
+
+func podReady(pod v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
 }
@@ -1,7 +1,10 @@
 package lrpstatus_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -10,47 +13,84 @@ import (
 	"github.com/nu7hatch/gouuid"
 	"github.com/pivotal-golang/lager/lagertest"
 
+	"k8s.io/kubernetes/pkg/api"
+	kubeerrors "k8s.io/kubernetes/pkg/api/errors"
 	"k8s.io/kubernetes/pkg/api/unversioned"
 	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/labels"
 
 	"github.com/cloudfoundry-incubator/nsync/helpers"
-	handlerfakes "github.com/cloudfoundry-incubator/tps/handler/handler_fakes"
+	"github.com/cloudfoundry-incubator/tps"
 	"github.com/cloudfoundry-incubator/tps/handler/lrpstatus"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	podsourcefakes "github.com/cloudfoundry-incubator/tps/podsource/fakes"
+	"github.com/cloudfoundry-incubator/tps/topology"
+	topologyfakes "github.com/cloudfoundry-incubator/tps/topology/fakes"
 
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
 	"github.com/pivotal-golang/clock/fakeclock"
 )
 
 var _ = Describe("LRPStatus", func() {
 	var (
-		fakeKubeClient *handlerfakes.FakeKubeClient
-		handler        http.Handler
-		response       *httptest.ResponseRecorder
-		request        *http.Request
+		source   *podsource.MemorySource
+		handler  http.Handler
+		response *httptest.ResponseRecorder
+		request  *http.Request
 		//server      *httptest.Server
-		fakePod      *handlerfakes.FakePod
-		pod1         *v1.Pod
-		pod2         *v1.Pod
-		processGuid1 helpers.ProcessGuid
-		processGuid2 helpers.ProcessGuid
-		err          error
-		fakeClock    *fakeclock.FakeClock
-		logger       *lagertest.TestLogger
+		// serves the pods of source while recording what the handler asks for
+		fakeSource     *podsourcefakes.FakePodSource
+		fakeNodeLister *topologyfakes.FakeNodeLister
+		pod1           *v1.Pod
+		pod2           *v1.Pod
+		processGuid1   helpers.ProcessGuid
+		processGuid2   helpers.ProcessGuid
+		err            error
+		fakeClock      *fakeclock.FakeClock
+		logger         *lagertest.TestLogger
 	)
 
+	// replaces the pods of the source, as if kubernetes now listed only these
+	setPods := func(pods ...v1.Pod) {
+		existing, err := source.Pods(api.NamespaceAll, labels.Everything())
+		Expect(err).NotTo(HaveOccurred())
+		for _, pod := range existing {
+			Expect(source.DeletePod(pod)).To(Succeed())
+		}
+		source.AddPod(pods...)
+	}
+
+	desire := func(pg helpers.ProcessGuid, replicas int32) {
+		source.AddReplicationController(v1.ReplicationController{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "rc-" + pg.ShortenedGuid(),
+				Namespace: "namespace",
+				Labels:    map[string]string{"cloudfoundry.org/process-guid": pg.ShortenedGuid()},
+			},
+			Spec: v1.ReplicationControllerSpec{Replicas: helpers.Int32Ptr(replicas)},
+		})
+	}
+
 	BeforeEach(func() {
 		logger = lagertest.NewTestLogger("test")
 
-		fakeKubeClient = &handlerfakes.FakeKubeClient{}
+		source = podsource.NewMemorySource()
+		fakeSource = &podsourcefakes.FakePodSource{
+			PodsStub:                   source.Pods,
+			ReplicationControllersStub: source.ReplicationControllers,
+			ReplicaSetsStub:            source.ReplicaSets,
+		}
+		fakeNodeLister = &topologyfakes.FakeNodeLister{}
 		fakeClock = fakeclock.NewFakeClock(time.Now())
 		processGuid1, err = generateProcessGuid()
 		Expect(err).NotTo(HaveOccurred())
 		processGuid2, err = generateProcessGuid()
 		Expect(err).NotTo(HaveOccurred())
 
-		fakePod = &handlerfakes.FakePod{}
 		now := unversioned.Now()
 		pod1 = &v1.Pod{
 			ObjectMeta: v1.ObjectMeta{
@@ -91,7 +131,7 @@ var _ = Describe("LRPStatus", func() {
 
 		pod2 = &v1.Pod{
 			ObjectMeta: v1.ObjectMeta{
-				Name:              "pod-name",
+				Name:              "pod-name2",
 				Namespace:         "namespace",
 				UID:               "1234-5678",
 				CreationTimestamp: now,
@@ -126,7 +166,7 @@ var _ = Describe("LRPStatus", func() {
 			},
 		}
 
-		handler = lrpstatus.NewHandler(fakeKubeClient, fakeClock, logger)
+		handler = lrpstatus.NewHandler(fakeSource, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
 
 		request, err = http.NewRequest("POST", "", nil)
 		Expect(err).NotTo(HaveOccurred())
@@ -143,10 +183,7 @@ var _ = Describe("LRPStatus", func() {
 
 	Describe("Instance state", func() {
 		BeforeEach(func() {
-			fakeKubeClient.PodsReturns(fakePod)
-			fakePod.ListReturns(&v1.PodList{
-				Items: []v1.Pod{*pod1},
-			}, nil)
+			setPods(*pod1)
 		})
 
 		It("returns correct instance & state", func() {
@@ -162,10 +199,10 @@ var _ = Describe("LRPStatus", func() {
 
 	Describe("Instance state", func() {
 		BeforeEach(func() {
-			fakeKubeClient.PodsReturns(fakePod)
-			fakePod.ListReturns(&v1.PodList{
-				Items: []v1.Pod{*pod1, *pod2},
-			}, nil)
+			// a pod of the process whose container is not the application
+			otherContainer := *pod2
+			otherContainer.ObjectMeta.Labels = pod1.ObjectMeta.Labels
+			setPods(*pod1, otherContainer)
 		})
 
 		It("ignores containers whose name is not called application", func() {
@@ -178,8 +215,802 @@ var _ = Describe("LRPStatus", func() {
 			Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
 		})
 	})
+
+	Describe("Readiness", func() {
+		var res []cc_messages.LRPInstance
+
+		BeforeEach(func() {
+			pod1.Status.ContainerStatuses[0].Ready = false
+			pod1.Status.Conditions = []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionFalse},
+			}
+		})
+
+		JustBeforeEach(func() {
+			res = []cc_messages.LRPInstance{}
+			err = json.NewDecoder(response.Body).Decode(&res)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(1))
+		})
+
+		Context("when the running application container fails its readiness probe", func() {
+			BeforeEach(func() {
+				setPods(*pod1)
+			})
+
+			It("reports the instance as STARTING with the failing probe in the details", func() {
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateStarting))
+				Expect(res[0].Details).To(Equal("readiness probe failing"))
+			})
+		})
+
+		Context("when the pod is ready", func() {
+			BeforeEach(func() {
+				pod1.Status.Conditions[0].Status = v1.ConditionTrue
+				setPods(*pod1)
+			})
+
+			It("reports the instance as RUNNING", func() {
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
+				Expect(res[0].Details).To(BeEmpty())
+			})
+		})
+
+		Context("when readiness is ignored", func() {
+			BeforeEach(func() {
+				handler = lrpstatus.NewHandler(fakeSource, fakeClock, lrpstatus.StateConfig{IgnoreReadiness: true}, time.Minute, logger)
+				setPods(*pod1)
+			})
+
+			It("reports the running instance as RUNNING", func() {
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
+			})
+		})
+	})
+
+	Describe("Terminating pods", func() {
+		var (
+			terminatingPod v1.Pod
+			res            []cc_messages.LRPInstance
+		)
+
+		BeforeEach(func() {
+			deletionTimestamp := unversioned.Now()
+			terminatingPod = *pod1
+			terminatingPod.ObjectMeta.Name = "pod-name-old"
+			terminatingPod.ObjectMeta.UID = "1234-5676"
+			terminatingPod.ObjectMeta.DeletionTimestamp = &deletionTimestamp
+
+			setPods(terminatingPod, *pod1)
+			desire(processGuid1, 1)
+		})
+
+		JustBeforeEach(func() {
+			res = []cc_messages.LRPInstance{}
+			err = json.NewDecoder(response.Body).Decode(&res)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("leaves them out by default", func() {
+			Expect(res).To(HaveLen(1))
+			Expect(res[0].InstanceGuid).To(Equal("1234-5677"))
+			Expect(res[0].Index).To(BeEquivalentTo(0))
+			Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
+		})
+
+		Context("when terminating instances are reported", func() {
+			BeforeEach(func() {
+				stateConfig := lrpstatus.StateConfig{TerminatingInstances: lrpstatus.TerminatingInstancesReport}
+				handler = lrpstatus.NewHandler(fakeSource, fakeClock, stateConfig, time.Minute, logger)
+			})
+
+			It("reports them as DOWN and stopping after the desired indexes", func() {
+				Expect(res).To(HaveLen(2))
+				Expect(res[0].InstanceGuid).To(Equal("1234-5677"))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
+
+				Expect(res[1].InstanceGuid).To(Equal("1234-5676"))
+				Expect(res[1].Index).To(BeEquivalentTo(1))
+				Expect(res[1].State).To(Equal(cc_messages.LRPInstanceStateDown))
+				Expect(res[1].Details).To(Equal("stopping"))
+			})
+
+			Context("and the pod was lost with its node", func() {
+				BeforeEach(func() {
+					terminatingPod.Status.Reason = "NodeLost"
+					terminatingPod.Status.Message = "Node node-1 which was running pod pod-name is unresponsive"
+					setPods(terminatingPod, *pod1)
+				})
+
+				It("reports the eviction instead of a stop", func() {
+					Expect(res).To(HaveLen(2))
+					Expect(res[1].State).To(Equal(cc_messages.LRPInstanceStateDown))
+					Expect(res[1].Details).To(Equal("node lost: Node node-1 which was running pod pod-name is unresponsive"))
+				})
+			})
+		})
+
+		Context("when a pod was evicted from its node", func() {
+			BeforeEach(func() {
+				pod1.Status.Phase = v1.PodFailed
+				pod1.Status.Reason = "Evicted"
+				pod1.Status.Message = "The node was low on resource: memory."
+				setPods(*pod1)
+			})
+
+			It("reports it as DOWN with the eviction message after the desired indexes", func() {
+				Expect(res).To(HaveLen(2))
+				Expect(res[0].Index).To(BeEquivalentTo(0))
+				Expect(res[0].InstanceGuid).To(BeEmpty())
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateDown))
+
+				Expect(res[1].InstanceGuid).To(Equal("1234-5677"))
+				Expect(res[1].Index).To(BeEquivalentTo(1))
+				Expect(res[1].State).To(Equal(cc_messages.LRPInstanceStateDown))
+				Expect(res[1].Details).To(Equal("evicted: The node was low on resource: memory."))
+			})
+
+			Context("and its replacement is running", func() {
+				BeforeEach(func() {
+					// sorts after the evicted pod
+					replacement := *pod1
+					replacement.ObjectMeta.Name = "pod-name-new"
+					replacement.ObjectMeta.UID = "1234-5678"
+					replacement.Status = v1.PodStatus{
+						ContainerStatuses: []v1.ContainerStatus{{
+							Name:  "application",
+							State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+							Ready: true,
+						}},
+					}
+					setPods(*pod1, replacement)
+				})
+
+				It("does not count the evicted pod towards the desired instances", func() {
+					Expect(res).To(HaveLen(2))
+					Expect(res[0].InstanceGuid).To(Equal("1234-5678"))
+					Expect(res[0].Index).To(BeEquivalentTo(0))
+					Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
+
+					Expect(res[1].InstanceGuid).To(Equal("1234-5677"))
+					Expect(res[1].Index).To(BeEquivalentTo(1))
+				})
+			})
+		})
+	})
+
+	Describe("Missing instances", func() {
+		BeforeEach(func() {
+			setPods(*pod1)
+			desire(processGuid1, 3)
+			desire(processGuid2, 5)
+		})
+
+		It("asks for the replication controllers of the process", func() {
+			Expect(fakeSource.ReplicationControllersCallCount()).To(Equal(1))
+			_, selector := fakeSource.ReplicationControllersArgsForCall(0)
+			Expect(selector.String()).To(Equal("cloudfoundry.org/process-guid=" + processGuid1.ShortenedGuid()))
+		})
+
+		Context("when a pod sorting first does not report its application container", func() {
+			BeforeEach(func() {
+				unreported := *pod1
+				unreported.ObjectMeta.Name = "pod-name-unreported"
+				unreported.ObjectMeta.UID = "1234-5670"
+				unreported.Status = v1.PodStatus{Phase: v1.PodRunning}
+				setPods(unreported, *pod1)
+			})
+
+			It("leaves it out without using up an index", func() {
+				res := []cc_messages.LRPInstance{}
+				err = json.NewDecoder(response.Body).Decode(&res)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(res).To(HaveLen(3))
+				Expect(res[0].InstanceGuid).To(Equal("1234-5677"))
+				Expect(res[0].Index).To(BeEquivalentTo(0))
+				Expect(res[1].Index).To(BeEquivalentTo(1))
+				Expect(res[1].State).To(Equal(cc_messages.LRPInstanceStateDown))
+				Expect(res[2].Index).To(BeEquivalentTo(2))
+			})
+		})
+
+		It("reports a DOWN placeholder for each desired index without a pod", func() {
+			res := []cc_messages.LRPInstance{}
+			err = json.NewDecoder(response.Body).Decode(&res)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(res).To(HaveLen(3))
+			Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateRunning))
+			Expect(res[1]).To(Equal(cc_messages.LRPInstance{
+				ProcessGuid: processGuid1.String(),
+				Index:       1,
+				State:       cc_messages.LRPInstanceStateDown,
+				Since:       fakeClock.Now().Unix(),
+			}))
+			Expect(res[2].Index).To(BeEquivalentTo(2))
+			Expect(res[2].State).To(Equal(cc_messages.LRPInstanceStateDown))
+		})
+
+		Context("when the process is kept by a replica set", func() {
+			BeforeEach(func() {
+				source.AddReplicaSet(v1beta1.ReplicaSet{
+					ObjectMeta: v1.ObjectMeta{
+						Name:      "rs-" + processGuid1.ShortenedGuid(),
+						Namespace: "namespace",
+						Labels:    map[string]string{"cloudfoundry.org/process-guid": processGuid1.ShortenedGuid()},
+					},
+					Spec: v1beta1.ReplicaSetSpec{Replicas: helpers.Int32Ptr(2)},
+				})
+			})
+
+			It("adds the replicas it desires", func() {
+				res := []cc_messages.LRPInstance{}
+				err = json.NewDecoder(response.Body).Decode(&res)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(res).To(HaveLen(5))
+				Expect(res[4].State).To(Equal(cc_messages.LRPInstanceStateDown))
+			})
+		})
+
+		Context("when the process has no pods at all", func() {
+			BeforeEach(func() {
+				setPods()
+			})
+
+			It("reports every desired index as DOWN instead of a 404", func() {
+				res := []cc_messages.LRPInstance{}
+				Expect(response.Code).To(Equal(http.StatusOK))
+				err = json.NewDecoder(response.Body).Decode(&res)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(res).To(HaveLen(3))
+				for _, instance := range res {
+					Expect(instance.State).To(Equal(cc_messages.LRPInstanceStateDown))
+				}
+			})
+		})
+
+		Context("when listing the replication controllers fails", func() {
+			BeforeEach(func() {
+				fakeSource.ReplicationControllersReturns(nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			})
+
+			It("responds with a 503", func() {
+				Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+	})
+
+	Describe("Placement errors", func() {
+		var res []cc_messages.LRPInstance
+
+		JustBeforeEach(func() {
+			res = []cc_messages.LRPInstance{}
+			err = json.NewDecoder(response.Body).Decode(&res)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when the scheduler cannot place the pod", func() {
+			BeforeEach(func() {
+				pod1.Status = v1.PodStatus{
+					Phase: v1.PodPending,
+					Conditions: []v1.PodCondition{{
+						Type:    v1.PodScheduled,
+						Status:  v1.ConditionFalse,
+						Reason:  "Unschedulable",
+						Message: "0/3 nodes are available: 3 Insufficient memory.",
+					}},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports the instance as DOWN with the scheduler's message", func() {
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateDown))
+				Expect(res[0].Details).To(Equal("0/3 nodes are available: 3 Insufficient memory."))
+			})
+
+			It("uses the creation time in place of the missing start time", func() {
+				Expect(res[0].Since).To(Equal(pod1.ObjectMeta.CreationTimestamp.Unix()))
+				Expect(res[0].Uptime).To(BeZero())
+			})
+		})
+
+		Context("when the image cannot be pulled", func() {
+			BeforeEach(func() {
+				pod1.Status.Phase = v1.PodPending
+				pod1.Status.ContainerStatuses[0].State = v1.ContainerState{
+					Waiting: &v1.ContainerStateWaiting{
+						Reason:  "ImagePullBackOff",
+						Message: "Back-off pulling image \"cloudfoundry/cflinuxfs2:latest\"",
+					},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports the instance as DOWN with the pull failure", func() {
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateDown))
+				Expect(res[0].Details).To(Equal("Back-off pulling image \"cloudfoundry/cflinuxfs2:latest\""))
+			})
+		})
+
+		Context("when the container is waiting for another reason", func() {
+			BeforeEach(func() {
+				pod1.Status.ContainerStatuses[0].State = v1.ContainerState{
+					Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports the instance as STARTING", func() {
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateStarting))
+				Expect(res[0].Details).To(BeEmpty())
+			})
+		})
+
+		Context("when the container keeps crashing", func() {
+			BeforeEach(func() {
+				pod1.Status.ContainerStatuses[0].State = v1.ContainerState{
+					Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+				}
+				pod1.Status.ContainerStatuses[0].LastTerminationState = v1.ContainerState{
+					Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports the instance as CRASHED with its last exit", func() {
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateCrashed))
+				Expect(res[0].Details).To(Equal("Error: exited with code 1"))
+			})
+		})
+
+		Context("when the container exited with an error", func() {
+			BeforeEach(func() {
+				pod1.Status.ContainerStatuses[0].State = v1.ContainerState{
+					Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports the instance as CRASHED", func() {
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateCrashed))
+				Expect(res[0].Details).To(Equal("OOMKilled: exited with code 137"))
+			})
+		})
+
+		Context("when the container exited cleanly", func() {
+			BeforeEach(func() {
+				pod1.Status.ContainerStatuses[0].State = v1.ContainerState{
+					Terminated: &v1.ContainerStateTerminated{ExitCode: 0, Reason: "Completed"},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports the instance as DOWN", func() {
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateDown))
+			})
+		})
+
+		Context("when a scheduled pod has no application container status yet", func() {
+			BeforeEach(func() {
+				pod1.Status = v1.PodStatus{Phase: v1.PodPending}
+				setPods(*pod1)
+			})
+
+			It("reports the instance as STARTING", func() {
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].State).To(Equal(cc_messages.LRPInstanceStateStarting))
+				Expect(res[0].Uptime).To(BeZero())
+			})
+		})
+	})
+
+	Describe("Since and uptime", func() {
+		var (
+			podStartTime unversioned.Time
+			res          []cc_messages.LRPInstance
+		)
+
+		BeforeEach(func() {
+			podStartTime = unversioned.NewTime(fakeClock.Now().Add(-time.Hour))
+			pod1.Status.StartTime = &podStartTime
+		})
+
+		JustBeforeEach(func() {
+			res = []cc_messages.LRPInstance{}
+			err = json.NewDecoder(response.Body).Decode(&res)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(res).To(HaveLen(1))
+		})
+
+		Context("when the application container restarted inside the pod", func() {
+			var startedAt unversioned.Time
+
+			BeforeEach(func() {
+				startedAt = unversioned.NewTime(fakeClock.Now().Add(-10 * time.Second))
+				pod1.Status.ContainerStatuses[0].RestartCount = 3
+				pod1.Status.ContainerStatuses[0].State = v1.ContainerState{
+					Running: &v1.ContainerStateRunning{StartedAt: startedAt},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports the time since the container started", func() {
+				Expect(res[0].Since).To(Equal(startedAt.Unix()))
+				Expect(res[0].Uptime).To(BeEquivalentTo(10))
+			})
+		})
+
+		Context("when the application container is waiting to be restarted", func() {
+			var finishedAt unversioned.Time
+
+			BeforeEach(func() {
+				finishedAt = unversioned.NewTime(fakeClock.Now().Add(-5 * time.Second))
+				pod1.Status.ContainerStatuses[0].State = v1.ContainerState{
+					Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+				}
+				pod1.Status.ContainerStatuses[0].LastTerminationState = v1.ContainerState{
+					Terminated: &v1.ContainerStateTerminated{ExitCode: 1, FinishedAt: finishedAt},
+				}
+				setPods(*pod1)
+			})
+
+			It("reports zero uptime since the last crash", func() {
+				Expect(res[0].Since).To(Equal(finishedAt.Unix()))
+				Expect(res[0].Uptime).To(BeZero())
+			})
+		})
+
+		Context("when the kubelet has not reported when the container started", func() {
+			BeforeEach(func() {
+				setPods(*pod1)
+			})
+
+			It("falls back to the pod start time", func() {
+				Expect(res[0].Since).To(Equal(podStartTime.Unix()))
+				Expect(res[0].Uptime).To(BeEquivalentTo(3600))
+			})
+		})
+	})
+
+	Describe("v2", func() {
+		var finishedAt unversioned.Time
+
+		BeforeEach(func() {
+			handler = lrpstatus.NewV2Handler(fakeSource, topology.NodeListers{"": fakeNodeLister}, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+
+			finishedAt = unversioned.NewTime(fakeClock.Now().Add(-time.Minute))
+			pod1.Spec.NodeName = "node-1"
+			pod1.Status.Conditions = []v1.PodCondition{
+				{Type: v1.PodReady, Status: v1.ConditionTrue},
+			}
+			pod1.Status.ContainerStatuses[0].RestartCount = 2
+			pod1.Status.ContainerStatuses[0].ImageID = "docker-pullable://cloudfoundry/cflinuxfs2@sha256:abc123"
+			pod1.Status.ContainerStatuses[0].LastTerminationState = v1.ContainerState{
+				Terminated: &v1.ContainerStateTerminated{
+					ExitCode:   137,
+					Reason:     "OOMKilled",
+					FinishedAt: finishedAt,
+				},
+			}
+
+			setPods(*pod1)
+		})
+
+		It("returns the v1 fields alongside the instance detail", func() {
+			var status tps.LRPStatus
+			err = json.NewDecoder(response.Body).Decode(&status)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(status.ProcessGuid).To(Equal(processGuid1.String()))
+			Expect(status.Instances).To(HaveLen(1))
+
+			instance := status.Instances[0]
+			Expect(instance.InstanceGuid).To(Equal("1234-5677"))
+			Expect(instance.State).To(Equal(cc_messages.LRPInstanceStateRunning))
+			Expect(instance.RestartCount).To(BeEquivalentTo(2))
+			Expect(instance.NodeName).To(Equal("node-1"))
+			Expect(instance.PodName).To(Equal("pod-name"))
+			Expect(instance.Namespace).To(Equal("namespace"))
+			Expect(instance.ImageDigest).To(Equal("sha256:abc123"))
+			Expect(instance.Ready).To(BeTrue())
+			Expect(instance.PodStartTime).To(Equal(pod1.Status.StartTime.Unix()))
+			Expect(instance.LastTermination).To(Equal(&tps.TerminationState{
+				Reason:     "OOMKilled",
+				ExitCode:   137,
+				FinishedAt: finishedAt.Unix(),
+			}))
+			Expect(instance.Conditions).To(ConsistOf(tps.PodCondition{
+				Type:   "Ready",
+				Status: "True",
+			}))
+		})
+
+		Context("when the node of the pod is known", func() {
+			BeforeEach(func() {
+				fakeNodeLister.PlacementReturns(topology.Placement{Zone: "us-east-1a", Region: "us-east-1"}, true)
+			})
+
+			It("reports the zone and region of the node", func() {
+				var status tps.LRPStatus
+				err = json.NewDecoder(response.Body).Decode(&status)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNodeLister.PlacementArgsForCall(0)).To(Equal("node-1"))
+				Expect(status.Instances[0].Zone).To(Equal("us-east-1a"))
+				Expect(status.Instances[0].Region).To(Equal("us-east-1"))
+				Expect(status.Zones).To(Equal(map[string]int{"us-east-1a": 1}))
+			})
+		})
+
+		Context("when the pod was found in one of several clusters", func() {
+			var clusterBNodes *topologyfakes.FakeNodeLister
+
+			BeforeEach(func() {
+				pod1.ObjectMeta.Annotations[podsource.ClusterAnnotation] = "cluster-b"
+				setPods(*pod1)
+
+				// both clusters have a node-1
+				fakeNodeLister.PlacementReturns(topology.Placement{Zone: "us-east-1a"}, true)
+				clusterBNodes = &topologyfakes.FakeNodeLister{}
+				clusterBNodes.PlacementReturns(topology.Placement{Zone: "us-west-1b"}, true)
+
+				nodes := topology.NodeListers{"cluster-a": fakeNodeLister, "cluster-b": clusterBNodes}
+				handler = lrpstatus.NewV2Handler(fakeSource, nodes, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+			})
+
+			It("reports the cluster of the instance", func() {
+				var status tps.LRPStatus
+				err = json.NewDecoder(response.Body).Decode(&status)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(status.Instances[0].Cluster).To(Equal("cluster-b"))
+			})
+
+			It("places the instance on the node of its own cluster", func() {
+				var status tps.LRPStatus
+				err = json.NewDecoder(response.Body).Decode(&status)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(status.Instances[0].Zone).To(Equal("us-west-1b"))
+				Expect(fakeNodeLister.PlacementCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Describe("v2 during a rolling update", func() {
+		var (
+			oldProcessGuid helpers.ProcessGuid
+			oldPod         v1.Pod
+		)
+
+		BeforeEach(func() {
+			handler = lrpstatus.NewV2Handler(fakeSource, topology.NodeListers{"": fakeNodeLister}, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+
+			oldVersion, err := uuid.NewV4()
+			Expect(err).NotTo(HaveOccurred())
+			oldProcessGuid, err = helpers.NewProcessGuid(processGuid1.AppGuid.String() + "-" + oldVersion.String())
+			Expect(err).NotTo(HaveOccurred())
+
+			oldPod = *pod1
+			oldPod.ObjectMeta.Name = "pod-name-old"
+			oldPod.ObjectMeta.UID = "1234-5670"
+			oldPod.ObjectMeta.Labels = map[string]string{
+				"cloudfoundry.org/app-guid":     processGuid1.AppGuid.String(),
+				"cloudfoundry.org/process-guid": oldProcessGuid.ShortenedGuid(),
+			}
+
+			setPods(*pod1, oldPod)
+		})
+
+		It("lists the pods of every version of the app", func() {
+			Expect(fakeSource.PodsCallCount()).To(Equal(2))
+			_, selector := fakeSource.PodsArgsForCall(1)
+			Expect(selector.String()).To(Equal("cloudfoundry.org/app-guid=" + processGuid1.AppGuid.String()))
+		})
+
+		It("flags the instances of the other versions", func() {
+			var status tps.LRPStatus
+			err = json.NewDecoder(response.Body).Decode(&status)
+			Expect(err).NotTo(HaveOccurred())
+
+			currentVersion := lrpstatus.ProcessVersion(processGuid1)
+			oldVersion := lrpstatus.ProcessVersion(oldProcessGuid)
+
+			Expect(status.CurrentVersion).To(Equal(currentVersion))
+			Expect(status.Versions).To(Equal(map[string]int{currentVersion: 1, oldVersion: 1}))
+			Expect(status.Instances).To(HaveLen(2))
+
+			Expect(status.Instances[0].InstanceGuid).To(Equal("1234-5677"))
+			Expect(status.Instances[0].Version).To(Equal(currentVersion))
+			Expect(status.Instances[0].Current).To(BeTrue())
+
+			Expect(status.Instances[1].InstanceGuid).To(Equal("1234-5670"))
+			Expect(status.Instances[1].ProcessGuid).To(Equal(oldProcessGuid.String()))
+			Expect(status.Instances[1].Version).To(Equal(oldVersion))
+			Expect(status.Instances[1].Current).To(BeFalse())
+		})
+
+		Context("when serving the v1 route", func() {
+			BeforeEach(func() {
+				handler = lrpstatus.NewHandler(fakeSource, fakeClock, lrpstatus.StateConfig{}, time.Minute, logger)
+			})
+
+			It("only reports the requested version", func() {
+				res := []cc_messages.LRPInstance{}
+				err = json.NewDecoder(response.Body).Decode(&res)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(res).To(HaveLen(1))
+				Expect(res[0].InstanceGuid).To(Equal("1234-5677"))
+				Expect(fakeSource.PodsCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when several versions have instances", func() {
+			BeforeEach(func() {
+				olderVersion, err := uuid.NewV4()
+				Expect(err).NotTo(HaveOccurred())
+				olderProcessGuid, err := helpers.NewProcessGuid(processGuid1.AppGuid.String() + "-" + olderVersion.String())
+				Expect(err).NotTo(HaveOccurred())
+
+				oldPod2 := oldPod
+				oldPod2.ObjectMeta.Name = "pod-name-old-2"
+				oldPod2.ObjectMeta.UID = "1234-5671"
+
+				olderPod := oldPod
+				olderPod.ObjectMeta.Name = "pod-name-older"
+				olderPod.ObjectMeta.UID = "1234-5672"
+				olderPod.ObjectMeta.Labels = map[string]string{
+					"cloudfoundry.org/app-guid":     processGuid1.AppGuid.String(),
+					"cloudfoundry.org/process-guid": olderProcessGuid.ShortenedGuid(),
+				}
+
+				setPods(*pod1, oldPod, oldPod2, olderPod)
+			})
+
+			It("gives every instance its own index, the current ones first", func() {
+				var status tps.LRPStatus
+				err = json.NewDecoder(response.Body).Decode(&status)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(status.Instances).To(HaveLen(4))
+
+				seen := map[uint]bool{}
+				highestCurrent := uint(0)
+				for _, instance := range status.Instances {
+					Expect(seen).NotTo(HaveKey(instance.Index))
+					seen[instance.Index] = true
+
+					if instance.Current && instance.Index > highestCurrent {
+						highestCurrent = instance.Index
+					}
+				}
+
+				for _, instance := range status.Instances {
+					if !instance.Current {
+						Expect(instance.Index).To(BeNumerically(">", highestCurrent))
+					}
+				}
+			})
+		})
+	})
+
+	Describe("Errors", func() {
+		Context("when the process guid is malformed", func() {
+			BeforeEach(func() {
+				request.Form = url.Values{
+					":guid": []string{"not-a-process-guid"},
+				}
+			})
+
+			It("responds with a 400 and an InvalidProcessGuid error", func() {
+				Expect(response.Code).To(Equal(http.StatusBadRequest))
+				Expect(decodeError(response).Code).To(Equal(tps.InvalidProcessGuid))
+			})
+
+			It("does not list pods", func() {
+				Expect(fakeSource.PodsCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when no pods match the process guid", func() {
+			BeforeEach(func() {
+				setPods()
+			})
+
+			It("responds with a 404 and a ProcessNotFound error", func() {
+				Expect(response.Code).To(Equal(http.StatusNotFound))
+				Expect(decodeError(response).Code).To(Equal(tps.ProcessNotFound))
+			})
+		})
+
+		Context("when the kubernetes API server responds with an error", func() {
+			BeforeEach(func() {
+				source.FailWith(&kubeerrors.StatusError{
+					ErrStatus: unversioned.Status{
+						Status: unversioned.StatusFailure,
+						Reason: unversioned.StatusReasonServerTimeout,
+						Code:   http.StatusInternalServerError,
+					},
+				})
+			})
+
+			It("responds with a 502 and an UpstreamError error", func() {
+				Expect(response.Code).To(Equal(http.StatusBadGateway))
+				Expect(decodeError(response).Code).To(Equal(tps.UpstreamError))
+			})
+		})
+
+		Context("when the kubernetes API server cannot be reached", func() {
+			BeforeEach(func() {
+				source.FailWith(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")})
+			})
+
+			It("responds with a 503 and an UpstreamUnavailable error", func() {
+				Expect(response.Code).To(Equal(http.StatusServiceUnavailable))
+				Expect(decodeError(response).Code).To(Equal(tps.UpstreamUnavailable))
+			})
+		})
+	})
+
+	Context("when listing the pods hangs", func() {
+		var unblock chan struct{}
+
+		BeforeEach(func() {
+			unblock = make(chan struct{})
+			setPods(*pod1)
+			fakeSource.PodsStub = func(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+				<-unblock
+				return source.Pods(namespace, selector)
+			}
+		})
+
+		AfterEach(func() {
+			close(unblock)
+		})
+
+		Context("and the request timeout passes", func() {
+			BeforeEach(func() {
+				handler = lrpstatus.NewHandler(fakeSource, fakeClock, lrpstatus.StateConfig{}, 10*time.Millisecond, logger)
+			})
+
+			It("responds with a 504", func() {
+				Expect(response.Code).To(Equal(http.StatusGatewayTimeout))
+				Expect(decodeError(response).Code).To(Equal(tps.UpstreamTimeout))
+				Expect(logger).To(Say("fetching-actual-lrp-info-timed-out"))
+			})
+		})
+
+		Context("and the client goes away", func() {
+			BeforeEach(func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				request = request.WithContext(ctx)
+				cancel()
+			})
+
+			It("stops without writing a response", func() {
+				Expect(response.Body.Len()).To(BeZero())
+				Expect(logger).To(Say("request-cancelled"))
+			})
+		})
+	})
 })
 
+func decodeError(response *httptest.ResponseRecorder) tps.Error {
+	var tpsErr tps.Error
+	err := json.NewDecoder(response.Body).Decode(&tpsErr)
+	Expect(err).NotTo(HaveOccurred())
+	return tpsErr
+}
+
 func generateProcessGuid() (helpers.ProcessGuid, error) {
 	appGuid, _ := uuid.NewV4()
 
@@ -0,0 +1,59 @@
+package lrpstatus
+
+import (
+	"github.com/cloudfoundry-incubator/bbs/models"
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps/handler/cc_conv"
+	"k8s.io/kubernetes/pkg/api/v1"
+)
+
+// waiting reasons the kubelet reports when the droplet image cannot be fetched
+var imagePullFailures = map[string]bool{
+	"ErrImagePull":        true,
+	"ImagePullBackOff":    true,
+	"InvalidImageName":    true,
+	"ErrImageNeverPull":   true,
+	"RegistryUnavailable": true,
+}
+
+// the kubernetes counterpart of a diego placement error: the scheduler not
+// finding a node with enough resources, or the node not being able to pull
+// the image. Returns "" when the pod was placed and its image pulled.
+func PlacementError(pod v1.Pod) string {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse {
+			if condition.Message != "" {
+				return condition.Message
+			}
+			return condition.Reason
+		}
+	}
+
+	containerStatus := ApplicationContainerStatus(pod)
+	if containerStatus != nil && containerStatus.State.Waiting != nil {
+		waiting := containerStatus.State.Waiting
+		if imagePullFailures[waiting.Reason] {
+			if waiting.Message != "" {
+				return waiting.Message
+			}
+			return waiting.Reason
+		}
+	}
+
+	return ""
+}
+
+// a pending pod that is not running its application container yet is
+// treated like an unclaimed diego actual LRP
+func unclaimedState(pod v1.Pod) (cc_messages.LRPInstanceState, string, bool) {
+	placementError := PlacementError(pod)
+	if placementError != "" {
+		return cc_conv.StateFor(models.ActualLRPStateUnclaimed, placementError), placementError, true
+	}
+
+	if pod.Status.Phase == v1.PodPending && ApplicationContainerStatus(pod) == nil {
+		return cc_conv.StateFor(models.ActualLRPStateUnclaimed, ""), "", true
+	}
+
+	return "", "", false
+}
@@ -0,0 +1,36 @@
+package lrpstatus
+
+import "fmt"
+
+// how pods that are being deleted are reported
+const (
+	TerminatingInstancesOmit   = "omit"
+	TerminatingInstancesReport = "report"
+)
+
+// StateConfig holds the operator choices in how pod state is reported as
+// instance state
+type StateConfig struct {
+	// report running application containers as RUNNING whether or not they
+	// pass their readiness probe, as tps did before it checked readiness
+	IgnoreReadiness bool
+
+	// either TerminatingInstancesOmit or TerminatingInstancesReport; the
+	// zero value omits them
+	TerminatingInstances string
+}
+
+func (config StateConfig) Validate() error {
+	switch config.TerminatingInstances {
+	case "", TerminatingInstancesOmit, TerminatingInstancesReport:
+		return nil
+	default:
+		return fmt.Errorf("invalid terminating instances policy %q: must be %q or %q", config.TerminatingInstances, TerminatingInstancesOmit, TerminatingInstancesReport)
+	}
+}
+
+func (config StateConfig) reportTerminating() bool {
+	return config.TerminatingInstances == TerminatingInstancesReport
+}
+
+const notReadyDetails = "readiness probe failing"
@@ -0,0 +1,72 @@
+package lrpstatus
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"k8s.io/kubernetes/pkg/api/v1"
+)
+
+const stoppingDetails = "stopping"
+
+// the reason the kubelet gives while it holds off restarting a container
+// that keeps exiting
+const crashLoopBackOff = "CrashLoopBackOff"
+
+// pod status reasons set when the pod was removed from its node rather than
+// stopped by CC
+var evictionReasons = map[string]string{
+	"Evicted":  "evicted",
+	"NodeLost": "node lost",
+}
+
+func evicted(pod v1.Pod) bool {
+	_, ok := evictionReasons[pod.Status.Reason]
+	return ok
+}
+
+func terminating(pod v1.Pod) bool {
+	return pod.ObjectMeta.DeletionTimestamp != nil
+}
+
+// evicted and terminating pods are DOWN whatever their containers report,
+// so that they are not counted towards the running instances
+func terminationState(pod v1.Pod) (cc_messages.LRPInstanceState, string, bool) {
+	if prefix, ok := evictionReasons[pod.Status.Reason]; ok {
+		details := prefix
+		if pod.Status.Message != "" {
+			details = prefix + ": " + pod.Status.Message
+		}
+		return cc_messages.LRPInstanceStateDown, details, true
+	}
+
+	if terminating(pod) {
+		return cc_messages.LRPInstanceStateDown, stoppingDetails, true
+	}
+
+	return "", "", false
+}
+
+// whether the application container crashed, as diego reported an instance
+// CRASHED: it exited with an error, or it keeps exiting and is waiting to be
+// restarted. The details describe the last exit.
+func crashState(containerStatus v1.ContainerStatus) (string, bool) {
+	terminated := containerStatus.State.Terminated
+	if waiting := containerStatus.State.Waiting; waiting != nil {
+		if waiting.Reason != crashLoopBackOff {
+			return "", false
+		}
+		terminated = containerStatus.LastTerminationState.Terminated
+	} else if terminated == nil || terminated.ExitCode == 0 {
+		return "", false
+	}
+
+	if terminated == nil {
+		return "", true
+	}
+	details := fmt.Sprintf("exited with code %d", terminated.ExitCode)
+	if terminated.Reason != "" {
+		details = terminated.Reason + ": " + details
+	}
+	return details, true
+}
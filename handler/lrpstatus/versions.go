@@ -0,0 +1,89 @@
+package lrpstatus
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"github.com/pivotal-golang/clock"
+	"k8s.io/kubernetes/pkg/api/v1"
+)
+
+// replication controllers do not set it, but pods created by deployments
+// carry it in place of a version
+const podTemplateHashLabel = "pod-template-hash"
+
+// the version half of a process guid; CC creates a new one for the same app
+// guid on every restage or update
+func ProcessVersion(pg helpers.ProcessGuid) string {
+	return strings.TrimPrefix(pg.String(), pg.AppGuid.String()+"-")
+}
+
+func podVersion(pod v1.Pod) string {
+	if pg, err := helpers.DecodeProcessGuid(pod.ObjectMeta.Labels[podsource.ProcessGuidLabel]); err == nil {
+		return ProcessVersion(pg)
+	}
+	return pod.ObjectMeta.Labels[podTemplateHashLabel]
+}
+
+// the status of the requested process together with the instances of the
+// other versions of its app still running during a rolling update.
+// appPods are all the pods of the app; those of the requested process are
+// already in instances. The other versions are numbered after every index
+// of the requested one, each version after the one before, so no two
+// instances share an index.
+func VersionedStatus(pg helpers.ProcessGuid, instances []tps.LRPInstance, appPods []v1.Pod, clk clock.Clock, stateConfig StateConfig) tps.LRPStatus {
+	currentVersion := ProcessVersion(pg)
+
+	status := tps.LRPStatus{
+		ProcessGuid:    pg.String(),
+		CurrentVersion: currentVersion,
+		Instances:      instances,
+		Versions:       map[string]int{},
+	}
+	if len(instances) > 0 {
+		status.Versions[currentVersion] = len(instances)
+	}
+
+	podsByVersion := map[string][]v1.Pod{}
+	for _, pod := range appPods {
+		if pod.ObjectMeta.Labels[podsource.ProcessGuidLabel] == pg.ShortenedGuid() || terminating(pod) {
+			continue
+		}
+		version := podVersion(pod)
+		podsByVersion[version] = append(podsByVersion[version], pod)
+	}
+
+	versions := make([]string, 0, len(podsByVersion))
+	for version := range podsByVersion {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	offset := nextIndex(instances)
+	for _, version := range versions {
+		versionInstances := LRPInstanceDetails(podsByVersion[version], clk, stateConfig)
+		for _, instance := range versionInstances {
+			instance.Index += offset
+			instance.Version = version
+			status.Instances = append(status.Instances, instance)
+			status.Versions[version]++
+		}
+		offset = nextIndex(status.Instances)
+	}
+
+	return status
+}
+
+// the index after the highest one in instances
+func nextIndex(instances []tps.LRPInstance) uint {
+	next := uint(0)
+	for _, instance := range instances {
+		if instance.Index >= next {
+			next = instance.Index + 1
+		}
+	}
+	return next
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/cloudfoundry-incubator/tps/helpers"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -18,3 +20,35 @@ func LogWrap(handler http.Handler, logger lager.Logger) http.HandlerFunc {
 		requestLog.Debug("done")
 	}
 }
+
+// WarningWrap gives each request a collection of warnings and sends any
+// collected by the time the response is written as Warning headers
+func WarningWrap(handler http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, warnings := helpers.WithWarnings(r.Context())
+		handler.ServeHTTP(&warningWriter{ResponseWriter: w, warnings: warnings}, r.WithContext(ctx))
+	}
+}
+
+type warningWriter struct {
+	http.ResponseWriter
+	warnings    *helpers.Warnings
+	wroteHeader bool
+}
+
+func (w *warningWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		for _, message := range w.warnings.Messages() {
+			w.Header().Add("Warning", fmt.Sprintf("199 tps %q", message))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *warningWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -5,7 +5,8 @@ import (
 	"net/http/httptest"
 
 	"github.com/cloudfoundry-incubator/tps/handler"
-	"github.com/cloudfoundry-incubator/tps/handler/handler_fakes"
+	"github.com/cloudfoundry-incubator/tps/handler/fakes"
+	"github.com/cloudfoundry-incubator/tps/helpers"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -15,7 +16,7 @@ import (
 
 var _ = Describe("Middleware", func() {
 	var httpHandler http.Handler
-	var wrappedHandler *handler_fakes.FakeHandler
+	var wrappedHandler *fakes.FakeHandler
 	var req *http.Request
 	var res *httptest.ResponseRecorder
 	var logger lager.Logger
@@ -23,7 +24,7 @@ var _ = Describe("Middleware", func() {
 	BeforeEach(func() {
 		req = newTestRequest("")
 		res = httptest.NewRecorder()
-		wrappedHandler = new(handler_fakes.FakeHandler)
+		wrappedHandler = new(fakes.FakeHandler)
 		logger = lagertest.NewTestLogger("test")
 	})
 
@@ -50,4 +51,57 @@ var _ = Describe("Middleware", func() {
 			})
 		})
 	})
+
+	Describe("WarningWrap", func() {
+		BeforeEach(func() {
+			httpHandler = handler.WarningWrap(wrappedHandler)
+		})
+
+		Context("when the handler records warnings", func() {
+			BeforeEach(func() {
+				wrappedHandler.ServeHTTPStub = func(w http.ResponseWriter, r *http.Request) {
+					helpers.AddWarning(r.Context(), "cluster-b is unreachable")
+					helpers.AddWarning(r.Context(), "cluster-b is unreachable")
+					w.Write([]byte("partial"))
+				}
+				httpHandler.ServeHTTP(res, req)
+			})
+
+			It("sends each of them once as a Warning header", func() {
+				Expect(res.Code).To(Equal(http.StatusOK))
+				Expect(res.HeaderMap["Warning"]).To(Equal([]string{`199 tps "cluster-b is unreachable"`}))
+				Expect(res.Body.String()).To(Equal("partial"))
+			})
+		})
+
+		Context("when the handler records none", func() {
+			BeforeEach(func() {
+				wrappedHandler.ServeHTTPStub = func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusNotFound)
+				}
+				httpHandler.ServeHTTP(res, req)
+			})
+
+			It("sends no Warning header", func() {
+				Expect(res.Code).To(Equal(http.StatusNotFound))
+				Expect(res.HeaderMap).NotTo(HaveKey("Warning"))
+			})
+		})
+	})
+})
+
+var _ = Describe("SwappableHandler", func() {
+	It("serves requests with the handler it was last given", func() {
+		first := new(fakes.FakeHandler)
+		second := new(fakes.FakeHandler)
+
+		swappable := handler.NewSwappableHandler(first)
+		swappable.ServeHTTP(httptest.NewRecorder(), newTestRequest(""))
+		Expect(first.ServeHTTPCallCount()).To(Equal(1))
+
+		swappable.Swap(second)
+		swappable.ServeHTTP(httptest.NewRecorder(), newTestRequest(""))
+		Expect(first.ServeHTTPCallCount()).To(Equal(1))
+		Expect(second.ServeHTTPCallCount()).To(Equal(1))
+	})
 })
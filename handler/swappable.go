@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// SwappableHandler serves each request with the handler it was last given,
+// so the API can be rebuilt with reloaded settings while the server runs.
+// Requests already being served finish with the handler they started with.
+type SwappableHandler struct {
+	current atomic.Value
+}
+
+func NewSwappableHandler(handler http.Handler) *SwappableHandler {
+	swappable := &SwappableHandler{}
+	swappable.Swap(handler)
+	return swappable
+}
+
+func (s *SwappableHandler) Swap(handler http.Handler) {
+	s.current.Store(&handler)
+}
+
+func (s *SwappableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*s.current.Load().(*http.Handler)).ServeHTTP(w, r)
+}
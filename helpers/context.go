@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var ErrTimedOut = errors.New("upstream request timed out")
+
+// returns a context for serving r that is cancelled when the client goes
+// away or, if timeout is positive, once timeout has elapsed
+func RequestContext(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// runs fn in the background and waits until it returns or ctx is done,
+// whichever comes first. The clientsets and traffic controller client we
+// call do not take a context, so this is what notices a client going away.
+// They time out their own requests, which bounds how long fn outlives ctx;
+// a request timing out that way is reported as ErrTimedOut too.
+func CallWithContext(ctx context.Context, fn func() error) error {
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- fn()
+	}()
+
+	select {
+	case err := <-errChan:
+		if isTimeout(err) {
+			return ErrTimedOut
+		}
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrTimedOut
+		}
+		return ctx.Err()
+	}
+}
+
+// whether err is a request the client timed out
+func isTimeout(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
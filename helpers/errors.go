@@ -0,0 +1,53 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/auth"
+	kubeerrors "k8s.io/kubernetes/pkg/api/errors"
+)
+
+func WriteError(w http.ResponseWriter, statusCode int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(tps.Error{Code: code, Message: message})
+}
+
+// reports an error returned while talking to kubernetes or the traffic
+// controller. The API server answering with an error is a 502, not being
+// able to reach it at all is a 503 and running out of time is a 504. A token
+// the authorizer turned down is a 401 or 403. Anything else is our own
+// failure and a 500.
+func WriteUpstreamError(w http.ResponseWriter, err error) {
+	statusCode, code := UpstreamErrorStatus(err)
+	WriteError(w, statusCode, code, err.Error())
+}
+
+func UpstreamErrorStatus(err error) (int, string) {
+	switch err {
+	case ErrTimedOut:
+		return http.StatusGatewayTimeout, tps.UpstreamTimeout
+	case auth.ErrUnauthorized:
+		return http.StatusUnauthorized, tps.Unauthorized
+	case auth.ErrForbidden:
+		return http.StatusForbidden, tps.Forbidden
+	}
+
+	switch err := err.(type) {
+	case *kubeerrors.StatusError:
+		if err.ErrStatus.Code == http.StatusNotFound {
+			return http.StatusNotFound, tps.ProcessNotFound
+		}
+		return http.StatusBadGateway, tps.UpstreamError
+	case *auth.BadResponseError:
+		return http.StatusBadGateway, tps.UpstreamError
+	case net.Error:
+		// includes the *url.Error of a request that could not be sent
+		return http.StatusServiceUnavailable, tps.UpstreamUnavailable
+	default:
+		return http.StatusInternalServerError, tps.InternalError
+	}
+}
@@ -0,0 +1,7 @@
+package helpers
+
+import "regexp"
+
+// the guids parameter of the bulk routes: a comma separated list of
+// process guids
+var ProcessGuidsPattern = regexp.MustCompile(`^([a-zA-Z0-9_-]+,)*[a-zA-Z0-9_-]+$`)
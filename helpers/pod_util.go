@@ -1,11 +1,155 @@
 package helpers
 
 import (
+	"context"
 	"sort"
 
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"k8s.io/kubernetes/pkg/api"
 	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/labels"
 )
 
+// what kubernetes knows about a process: its pods and how many instances
+// its replication controllers and replica sets want
+type Process struct {
+	Pods             []v1.Pod
+	DesiredInstances int
+	Desired          bool
+}
+
+// a process is unknown when it has neither pods nor a controller
+func (p *Process) Exists() bool {
+	return p.Desired || len(p.Pods) > 0
+}
+
+func FetchProcess(ctx context.Context, source podsource.PodSource, shortenedGuid string) (*Process, error) {
+	pods, err := ListProcessPods(ctx, source, shortenedGuid)
+	if err != nil {
+		return nil, err
+	}
+
+	desired, found, err := DesiredInstances(ctx, source, shortenedGuid)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Process{
+		Pods:             pods,
+		DesiredInstances: desired,
+		Desired:          found,
+	}, nil
+}
+
+// list the pods of a process across all namespaces, giving up when ctx is done
+func ListProcessPods(ctx context.Context, source podsource.PodSource, shortenedGuid string) ([]v1.Pod, error) {
+	return ListPods(ctx, source, api.NamespaceAll, labels.Set{podsource.ProcessGuidLabel: shortenedGuid}.AsSelector())
+}
+
+// list the pods of every version of an app across all namespaces
+func ListAppPods(ctx context.Context, source podsource.PodSource, appGuid string) ([]v1.Pod, error) {
+	return ListPods(ctx, source, api.NamespaceAll, labels.Set{podsource.AppGuidLabel: appGuid}.AsSelector())
+}
+
+func ListPods(ctx context.Context, source podsource.PodSource, namespace string, selector labels.Selector) ([]v1.Pod, error) {
+	var pods []v1.Pod
+	err := CallWithContext(ctx, func() error {
+		var err error
+		pods, err = source.Pods(namespace, selector)
+		return err
+	})
+
+	if podsource.IsPartial(err) {
+		AddWarning(ctx, err.Error())
+		return pods, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return pods, nil
+}
+
+func ListReplicationControllers(ctx context.Context, source podsource.PodSource, namespace string, selector labels.Selector) ([]v1.ReplicationController, error) {
+	var rcs []v1.ReplicationController
+	err := CallWithContext(ctx, func() error {
+		var err error
+		rcs, err = source.ReplicationControllers(namespace, selector)
+		return err
+	})
+
+	if podsource.IsPartial(err) {
+		AddWarning(ctx, err.Error())
+		return rcs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rcs, nil
+}
+
+func ListReplicaSets(ctx context.Context, source podsource.PodSource, namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error) {
+	var rss []v1beta1.ReplicaSet
+	err := CallWithContext(ctx, func() error {
+		var err error
+		rss, err = source.ReplicaSets(namespace, selector)
+		return err
+	})
+
+	if podsource.IsPartial(err) {
+		AddWarning(ctx, err.Error())
+		return rss, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return rss, nil
+}
+
+// sums the replicas the replication controllers and replica sets of a
+// process ask for. found is false when the process has neither.
+func DesiredInstances(ctx context.Context, source podsource.PodSource, shortenedGuid string) (desired int, found bool, err error) {
+	selector := labels.Set{podsource.ProcessGuidLabel: shortenedGuid}.AsSelector()
+
+	rcs, err := ListReplicationControllers(ctx, source, api.NamespaceAll, selector)
+	if err != nil {
+		return 0, false, err
+	}
+
+	rss, err := ListReplicaSets(ctx, source, api.NamespaceAll, selector)
+	if err != nil {
+		return 0, false, err
+	}
+
+	for _, rc := range rcs {
+		desired += Replicas(rc)
+	}
+	for _, rs := range rss {
+		desired += ReplicaSetReplicas(rs)
+	}
+
+	return desired, len(rcs) > 0 || len(rss) > 0, nil
+}
+
+func Replicas(rc v1.ReplicationController) int {
+	if rc.Spec.Replicas == nil {
+		// defaulted to 1 by the API server
+		return 1
+	}
+	return int(*rc.Spec.Replicas)
+}
+
+func ReplicaSetReplicas(rs v1beta1.ReplicaSet) int {
+	if rs.Spec.Replicas == nil {
+		// defaulted to 1 by the API server
+		return 1
+	}
+	return int(*rs.Spec.Replicas)
+}
+
 // simple sort of a pod based on pod uid
 func SortPods(actualPods []v1.Pod) []v1.Pod {
 	// sort the pods by the pod UID
@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+)
+
+type warningsKey struct{}
+
+// Warnings collects the problems met while serving a request that did not
+// stop it from being answered, such as a cluster that could not be reached
+type Warnings struct {
+	lock     sync.Mutex
+	messages []string
+}
+
+// returns a context carrying a new, empty collection of warnings
+func WithWarnings(ctx context.Context) (context.Context, *Warnings) {
+	warnings := &Warnings{}
+	return context.WithValue(ctx, warningsKey{}, warnings), warnings
+}
+
+// records message with the warnings carried by ctx, if it carries any.
+// Repeated messages are only recorded once.
+func AddWarning(ctx context.Context, message string) {
+	warnings, ok := ctx.Value(warningsKey{}).(*Warnings)
+	if !ok {
+		return
+	}
+
+	warnings.lock.Lock()
+	defer warnings.lock.Unlock()
+
+	for _, m := range warnings.messages {
+		if m == message {
+			return
+		}
+	}
+	warnings.messages = append(warnings.messages, message)
+}
+
+func (w *Warnings) Messages() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return append([]string(nil), w.messages...)
+}
@@ -0,0 +1,144 @@
+package kubeclient
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3"
+	"k8s.io/kubernetes/pkg/client/restclient"
+	"k8s.io/kubernetes/pkg/client/unversioned/clientcmd"
+)
+
+// a kubernetes cluster and the credentials used to reach its API server,
+// given either directly, by a kubeconfig file or by the service account of
+// the pod the process runs in
+type Cluster struct {
+	Name       string `json:"name"`
+	API        string `json:"api,omitempty"`
+	CACert     string `json:"ca_cert,omitempty"`
+	ClientCert string `json:"client_cert,omitempty"`
+	ClientKey  string `json:"client_key,omitempty"`
+
+	KubeConfig string `json:"kubeconfig,omitempty"`
+	Context    string `json:"context,omitempty"`
+	InCluster  bool   `json:"in_cluster,omitempty"`
+
+	// read again every TokenRefreshInterval so that it can be rotated
+	TokenFile string `json:"token_file,omitempty"`
+
+	ImpersonateUser   string   `json:"impersonate_user,omitempty"`
+	ImpersonateGroups []string `json:"impersonate_groups,omitempty"`
+
+	QPS   float32 `json:"qps,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+func (c Cluster) Validate() error {
+	sources := 0
+	for _, set := range []bool{c.API != "", c.KubeConfig != "", c.InCluster} {
+		if set {
+			sources++
+		}
+	}
+
+	switch {
+	case sources == 0:
+		return errors.New("one of api, kubeconfig or in_cluster is required")
+	case sources > 1:
+		return errors.New("only one of api, kubeconfig or in_cluster may be set")
+	case c.Context != "" && c.KubeConfig == "":
+		return errors.New("context requires kubeconfig")
+	case len(c.ImpersonateGroups) > 0 && c.ImpersonateUser == "":
+		return errors.New("impersonate_groups requires impersonate_user")
+	}
+	return nil
+}
+
+func (c Cluster) RESTConfig() (*restclient.Config, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	var config *restclient.Config
+	tokenFile := c.TokenFile
+
+	switch {
+	case c.InCluster:
+		config, err = restclient.InClusterConfig()
+		if tokenFile == "" {
+			tokenFile = ServiceAccountTokenFile
+		}
+	case c.KubeConfig != "":
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: c.KubeConfig},
+			&clientcmd.ConfigOverrides{CurrentContext: c.Context},
+		).ClientConfig()
+	default:
+		config = &restclient.Config{
+			Host: c.API,
+			TLSClientConfig: restclient.TLSClientConfig{
+				CertFile: c.ClientCert,
+				KeyFile:  c.ClientKey,
+				CAFile:   c.CACert,
+			},
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenFile != "" {
+		// a static token would be sent in place of the rotated one
+		config.BearerToken = ""
+		config.WrapTransport = chain(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+			return NewTokenFileRoundTripper(tokenFile, TokenRefreshInterval, clock.NewClock(), rt)
+		})
+	}
+
+	if c.ImpersonateUser != "" {
+		config.WrapTransport = chain(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+			return NewImpersonatingRoundTripper(c.ImpersonateUser, c.ImpersonateGroups, rt)
+		})
+	}
+
+	if c.QPS != 0 {
+		config.QPS = c.QPS
+	}
+	if c.Burst != 0 {
+		config.Burst = c.Burst
+	}
+
+	return config, nil
+}
+
+// NewClientset builds a clientset for watching the cluster, whose requests
+// may stay open for as long as the watch lasts
+func NewClientset(cluster Cluster) (clientset.Interface, error) {
+	return NewClientsetWithTimeout(cluster, 0)
+}
+
+// NewClientsetWithTimeout builds a clientset whose requests are cancelled
+// once they take longer than timeout, or never when it is zero. Requests
+// made while serving the API use it, so a slow API server does not leave
+// calls behind after the API has given up on them.
+func NewClientsetWithTimeout(cluster Cluster, timeout time.Duration) (clientset.Interface, error) {
+	config, err := cluster.RESTConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = timeout
+	return clientset.NewForConfig(config)
+}
+
+// wraps with next after any wrapping the config already had
+func chain(wrap, next func(http.RoundTripper) http.RoundTripper) func(http.RoundTripper) http.RoundTripper {
+	if wrap == nil {
+		return next
+	}
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return next(wrap(rt))
+	}
+}
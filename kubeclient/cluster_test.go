@@ -0,0 +1,197 @@
+package kubeclient_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/kubeclient"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"k8s.io/kubernetes/pkg/api"
+)
+
+const kubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east:6443
+- name: west
+  cluster:
+    server: https://west:6443
+users:
+- name: tps
+  user:
+    token: static-token
+contexts:
+- name: east
+  context: {cluster: east, user: tps}
+- name: west
+  context: {cluster: west, user: tps}
+current-context: east
+`
+
+var _ = Describe("Cluster", func() {
+	Describe("Validate", func() {
+		It("accepts a cluster given by its api", func() {
+			Expect(kubeclient.Cluster{API: "https://kube:6443"}.Validate()).To(Succeed())
+		})
+
+		It("requires exactly one way of reaching the cluster", func() {
+			Expect(kubeclient.Cluster{}.Validate()).To(MatchError("one of api, kubeconfig or in_cluster is required"))
+			Expect(kubeclient.Cluster{API: "https://kube:6443", InCluster: true}.Validate()).To(MatchError("only one of api, kubeconfig or in_cluster may be set"))
+		})
+
+		It("only accepts a context with a kubeconfig", func() {
+			Expect(kubeclient.Cluster{API: "https://kube:6443", Context: "east"}.Validate()).To(MatchError("context requires kubeconfig"))
+		})
+
+		It("only accepts impersonated groups with an impersonated user", func() {
+			cluster := kubeclient.Cluster{API: "https://kube:6443", ImpersonateGroups: []string{"readers"}}
+			Expect(cluster.Validate()).To(MatchError("impersonate_groups requires impersonate_user"))
+		})
+	})
+
+	Describe("RESTConfig", func() {
+		It("uses the api and certificates given", func() {
+			config, err := kubeclient.Cluster{
+				API:        "https://kube:6443",
+				CACert:     "/certs/ca.crt",
+				ClientCert: "/certs/tps.crt",
+				ClientKey:  "/certs/tps.key",
+				QPS:        20,
+				Burst:      40,
+			}.RESTConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.Host).To(Equal("https://kube:6443"))
+			Expect(config.TLSClientConfig.CAFile).To(Equal("/certs/ca.crt"))
+			Expect(config.TLSClientConfig.CertFile).To(Equal("/certs/tps.crt"))
+			Expect(config.TLSClientConfig.KeyFile).To(Equal("/certs/tps.key"))
+			Expect(config.QPS).To(BeEquivalentTo(20))
+			Expect(config.Burst).To(Equal(40))
+			Expect(config.WrapTransport).To(BeNil())
+		})
+
+		Context("with a kubeconfig", func() {
+			var path string
+
+			BeforeEach(func() {
+				file, err := ioutil.TempFile("", "kubeconfig")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = file.WriteString(kubeConfig)
+				Expect(err).NotTo(HaveOccurred())
+				file.Close()
+				path = file.Name()
+			})
+
+			AfterEach(func() {
+				os.Remove(path)
+			})
+
+			It("uses its current context", func() {
+				config, err := kubeclient.Cluster{KubeConfig: path}.RESTConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Host).To(Equal("https://east:6443"))
+				Expect(config.BearerToken).To(Equal("static-token"))
+			})
+
+			It("uses the context asked for", func() {
+				config, err := kubeclient.Cluster{KubeConfig: path, Context: "west"}.RESTConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Host).To(Equal("https://west:6443"))
+			})
+
+			It("prefers a token file to the static token", func() {
+				config, err := kubeclient.Cluster{KubeConfig: path, TokenFile: "/var/run/token"}.RESTConfig()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.BearerToken).To(BeEmpty())
+				Expect(config.WrapTransport).NotTo(BeNil())
+			})
+		})
+
+		It("fails for an invalid cluster", func() {
+			_, err := kubeclient.Cluster{}.RESTConfig()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Flags", func() {
+		var (
+			flagSet *flag.FlagSet
+			flags   *kubeclient.Flags
+		)
+
+		BeforeEach(func() {
+			flagSet = flag.NewFlagSet("test", flag.ContinueOnError)
+			flags = kubeclient.AddFlags(flagSet)
+		})
+
+		It("describes the cluster given on the command line", func() {
+			err := flagSet.Parse([]string{
+				"-kubeConfig", "/etc/kube/config",
+				"-kubeContext", "west",
+				"-kubeTokenFile", "/var/run/token",
+				"-kubeImpersonateUser", "tps",
+				"-kubeImpersonateGroups", "readers, auditors",
+				"-kubeQPS", "20",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(flags.Cluster()).To(Equal(kubeclient.Cluster{
+				KubeConfig:        "/etc/kube/config",
+				Context:           "west",
+				TokenFile:         "/var/run/token",
+				ImpersonateUser:   "tps",
+				ImpersonateGroups: []string{"readers", "auditors"},
+				QPS:               20,
+				Burst:             10,
+			}))
+		})
+
+		It("applies the rate limits to clusters that set none", func() {
+			Expect(flagSet.Parse([]string{"-kubeBurst", "30"})).To(Succeed())
+
+			cluster := flags.Defaults(kubeclient.Cluster{Name: "east", QPS: 50})
+			Expect(cluster.QPS).To(BeEquivalentTo(50))
+			Expect(cluster.Burst).To(Equal(30))
+		})
+	})
+
+	Describe("NewClientsetWithTimeout", func() {
+		var (
+			server  *httptest.Server
+			release chan struct{}
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			}))
+		})
+
+		AfterEach(func() {
+			close(release)
+			server.Close()
+		})
+
+		It("cancels requests that take longer than the timeout", func() {
+			client, err := kubeclient.NewClientsetWithTimeout(kubeclient.Cluster{API: server.URL}, 50*time.Millisecond)
+			Expect(err).NotTo(HaveOccurred())
+
+			errs := make(chan error, 1)
+			go func() {
+				_, err := client.Core().Pods("default").List(api.ListOptions{})
+				errs <- err
+			}()
+
+			Eventually(errs, time.Second).Should(Receive(HaveOccurred()))
+		})
+	})
+})
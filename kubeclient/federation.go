@@ -0,0 +1,66 @@
+package kubeclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// the clusters a listener federates, and which of them each app is placed
+// in; apps without an entry are looked for in every cluster
+type Federation struct {
+	Clusters    []Cluster           `json:"clusters"`
+	AppClusters map[string][]string `json:"app_clusters,omitempty"`
+}
+
+func LoadFederation(path string) (Federation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Federation{}, err
+	}
+	defer file.Close()
+
+	var federation Federation
+	err = json.NewDecoder(file).Decode(&federation)
+	if err != nil {
+		return Federation{}, fmt.Errorf("invalid cluster file %s: %s", path, err)
+	}
+
+	err = federation.Validate()
+	if err != nil {
+		return Federation{}, err
+	}
+
+	return federation, nil
+}
+
+func (f Federation) Validate() error {
+	if len(f.Clusters) == 0 {
+		return errors.New("no clusters configured")
+	}
+
+	names := map[string]bool{}
+	for _, cluster := range f.Clusters {
+		if cluster.Name == "" {
+			return errors.New("cluster with no name")
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("cluster %s is configured twice", cluster.Name)
+		}
+		if err := cluster.Validate(); err != nil {
+			return fmt.Errorf("cluster %s: %s", cluster.Name, err)
+		}
+		names[cluster.Name] = true
+	}
+
+	for appGuid, clusters := range f.AppClusters {
+		for _, name := range clusters {
+			if !names[name] {
+				return fmt.Errorf("app %s is mapped to unknown cluster %s", appGuid, name)
+			}
+		}
+	}
+
+	return nil
+}
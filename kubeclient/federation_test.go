@@ -0,0 +1,98 @@
+package kubeclient_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/cloudfoundry-incubator/tps/kubeclient"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Federation", func() {
+	Describe("LoadFederation", func() {
+		var path string
+
+		writeFile := func(contents string) {
+			file, err := ioutil.TempFile("", "clusters")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = file.WriteString(contents)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.Close()).To(Succeed())
+			path = file.Name()
+		}
+
+		AfterEach(func() {
+			os.Remove(path)
+		})
+
+		It("reads the clusters and the app mapping", func() {
+			writeFile(`{
+				"clusters": [
+					{"name": "east", "api": "https://east:6443", "ca_cert": "/certs/east-ca.crt"},
+					{"name": "west", "api": "https://west:6443", "client_cert": "/certs/west.crt", "client_key": "/certs/west.key"}
+				],
+				"app_clusters": {"some-app-guid": ["west"]}
+			}`)
+
+			federation, err := kubeclient.LoadFederation(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(federation.Clusters).To(Equal([]kubeclient.Cluster{
+				{Name: "east", API: "https://east:6443", CACert: "/certs/east-ca.crt"},
+				{Name: "west", API: "https://west:6443", ClientCert: "/certs/west.crt", ClientKey: "/certs/west.key"},
+			}))
+			Expect(federation.AppClusters).To(Equal(map[string][]string{"some-app-guid": {"west"}}))
+		})
+
+		It("fails when the file is not JSON", func() {
+			writeFile("clusters: []")
+
+			_, err := kubeclient.LoadFederation(path)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("fails when the file does not exist", func() {
+			_, err := kubeclient.LoadFederation("/does/not/exist")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Validate", func() {
+		var federation kubeclient.Federation
+
+		BeforeEach(func() {
+			federation = kubeclient.Federation{
+				Clusters: []kubeclient.Cluster{
+					{Name: "east", API: "https://east:6443"},
+					{Name: "west", API: "https://west:6443"},
+				},
+				AppClusters: map[string][]string{"some-app-guid": {"east"}},
+			}
+		})
+
+		It("accepts a valid federation", func() {
+			Expect(federation.Validate()).To(Succeed())
+		})
+
+		It("requires at least one cluster", func() {
+			federation.Clusters = nil
+			Expect(federation.Validate()).To(MatchError("no clusters configured"))
+		})
+
+		It("requires cluster names to be unique", func() {
+			federation.Clusters[1].Name = "east"
+			Expect(federation.Validate()).To(MatchError("cluster east is configured twice"))
+		})
+
+		It("requires every cluster to say how to reach it", func() {
+			federation.Clusters[1].API = ""
+			Expect(federation.Validate()).To(MatchError("cluster west: one of api, kubeconfig or in_cluster is required"))
+		})
+
+		It("rejects apps mapped to clusters that are not configured", func() {
+			federation.AppClusters["some-app-guid"] = []string{"north"}
+			Expect(federation.Validate()).To(MatchError("app some-app-guid is mapped to unknown cluster north"))
+		})
+	})
+})
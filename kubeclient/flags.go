@@ -0,0 +1,125 @@
+package kubeclient
+
+import (
+	"flag"
+	"strings"
+)
+
+// the flags describing how to reach a single kubernetes cluster, shared by
+// the binaries that talk to kubernetes
+type Flags struct {
+	api               *string
+	caCert            *string
+	clientCert        *string
+	clientKey         *string
+	kubeConfig        *string
+	context           *string
+	inCluster         *bool
+	tokenFile         *string
+	impersonateUser   *string
+	impersonateGroups *string
+	qps               *float64
+	burst             *int
+}
+
+func AddFlags(flagSet *flag.FlagSet) *Flags {
+	return &Flags{
+		api: flagSet.String(
+			"kubeCluster",
+			"",
+			"kubernetes API server URL (scheme://ip:port)",
+		),
+		caCert: flagSet.String(
+			"kubeCACert",
+			"",
+			"path to kubernetes API server CA certificate",
+		),
+		clientCert: flagSet.String(
+			"kubeClientCert",
+			"",
+			"path to client certificate for authentication with the kubernetes API server",
+		),
+		clientKey: flagSet.String(
+			"kubeClientKey",
+			"",
+			"path to client key for authentication with the kubernetes API server",
+		),
+		kubeConfig: flagSet.String(
+			"kubeConfig",
+			"",
+			"path to a kubeconfig file to reach the kubernetes API server with, instead of -kubeCluster",
+		),
+		context: flagSet.String(
+			"kubeContext",
+			"",
+			"context of -kubeConfig to use; its current context when empty",
+		),
+		inCluster: flagSet.Bool(
+			"kubeInCluster",
+			false,
+			"reach the kubernetes API server with the service account of the pod the process runs in",
+		),
+		tokenFile: flagSet.String(
+			"kubeTokenFile",
+			"",
+			"path to a bearer token for authentication with the kubernetes API server; the file is read again every minute so the token can be rotated",
+		),
+		impersonateUser: flagSet.String(
+			"kubeImpersonateUser",
+			"",
+			"user to make kubernetes API requests as",
+		),
+		impersonateGroups: flagSet.String(
+			"kubeImpersonateGroups",
+			"",
+			"comma-separated groups to make kubernetes API requests as, with -kubeImpersonateUser",
+		),
+		qps: flagSet.Float64(
+			"kubeQPS",
+			5,
+			"sustained queries per second allowed to each kubernetes API server",
+		),
+		burst: flagSet.Int(
+			"kubeBurst",
+			10,
+			"queries allowed to each kubernetes API server in a burst above -kubeQPS",
+		),
+	}
+}
+
+// the cluster described by the flags
+func (f *Flags) Cluster() Cluster {
+	return f.Defaults(Cluster{
+		API:               *f.api,
+		CACert:            *f.caCert,
+		ClientCert:        *f.clientCert,
+		ClientKey:         *f.clientKey,
+		KubeConfig:        *f.kubeConfig,
+		Context:           *f.context,
+		InCluster:         *f.inCluster,
+		TokenFile:         *f.tokenFile,
+		ImpersonateUser:   *f.impersonateUser,
+		ImpersonateGroups: splitList(*f.impersonateGroups),
+	})
+}
+
+// applies the rate limit flags to a cluster that does not set its own
+func (f *Flags) Defaults(cluster Cluster) Cluster {
+	if cluster.QPS == 0 {
+		cluster.QPS = float32(*f.qps)
+	}
+	if cluster.Burst == 0 {
+		cluster.Burst = *f.burst
+	}
+	return cluster
+}
+
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
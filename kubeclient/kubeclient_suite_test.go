@@ -0,0 +1,13 @@
+package kubeclient_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestKubeclient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Kubeclient Suite")
+}
@@ -0,0 +1,117 @@
+package kubeclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+// the token mounted into pods for their service account, which the kubelet
+// replaces before it expires
+const ServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// how long a token read from a file is used before the file is read again
+const TokenRefreshInterval = time.Minute
+
+type tokenFileRoundTripper struct {
+	path     string
+	refresh  time.Duration
+	clock    clock.Clock
+	delegate http.RoundTripper
+
+	lock     sync.Mutex
+	token    string
+	expireAt time.Time
+}
+
+// NewTokenFileRoundTripper authenticates requests with the bearer token in
+// path, reading the file again once refresh has passed so that rotated
+// tokens are picked up. Requests that already carry an Authorization header
+// are left alone.
+func NewTokenFileRoundTripper(path string, refresh time.Duration, clock clock.Clock, delegate http.RoundTripper) http.RoundTripper {
+	return &tokenFileRoundTripper{
+		path:     path,
+		refresh:  refresh,
+		clock:    clock,
+		delegate: delegate,
+	}
+}
+
+func (rt *tokenFileRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") != "" {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	token, err := rt.currentToken()
+	if err != nil {
+		return nil, err
+	}
+
+	req = cloneRequest(req)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return rt.delegate.RoundTrip(req)
+}
+
+// keeps using the last token read when the file cannot be read, as it may
+// be in the middle of being replaced
+func (rt *tokenFileRoundTripper) currentToken() (string, error) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	now := rt.clock.Now()
+	if rt.token != "" && now.Before(rt.expireAt) {
+		return rt.token, nil
+	}
+
+	contents, err := ioutil.ReadFile(rt.path)
+	if err != nil {
+		if rt.token != "" {
+			return rt.token, nil
+		}
+		return "", err
+	}
+
+	rt.token = strings.TrimSpace(string(contents))
+	rt.expireAt = now.Add(rt.refresh)
+	return rt.token, nil
+}
+
+type impersonatingRoundTripper struct {
+	user     string
+	groups   []string
+	delegate http.RoundTripper
+}
+
+// NewImpersonatingRoundTripper makes requests on behalf of user and groups,
+// which the credentials used must be allowed to impersonate
+func NewImpersonatingRoundTripper(user string, groups []string, delegate http.RoundTripper) http.RoundTripper {
+	return &impersonatingRoundTripper{
+		user:     user,
+		groups:   groups,
+		delegate: delegate,
+	}
+}
+
+func (rt *impersonatingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = cloneRequest(req)
+	req.Header.Set("Impersonate-User", rt.user)
+	for _, group := range rt.groups {
+		req.Header.Add("Impersonate-Group", group)
+	}
+	return rt.delegate.RoundTrip(req)
+}
+
+// round trippers must not modify the request they are given
+func cloneRequest(req *http.Request) *http.Request {
+	clone := new(http.Request)
+	*clone = *req
+	clone.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		clone.Header[k] = append([]string(nil), v...)
+	}
+	return clone
+}
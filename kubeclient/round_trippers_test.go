@@ -0,0 +1,124 @@
+package kubeclient_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/kubeclient"
+	"github.com/pivotal-golang/clock/fakeclock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recordingRoundTripper struct {
+	requests []*http.Request
+}
+
+func (rt *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.requests = append(rt.requests, req)
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+var _ = Describe("Round trippers", func() {
+	var (
+		delegate *recordingRoundTripper
+		request  *http.Request
+	)
+
+	BeforeEach(func() {
+		delegate = &recordingRoundTripper{}
+
+		var err error
+		request, err = http.NewRequest("GET", "https://kube/api/v1/pods", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("NewTokenFileRoundTripper", func() {
+		var (
+			tokenFile    string
+			fakeClock    *fakeclock.FakeClock
+			roundTripper http.RoundTripper
+		)
+
+		writeToken := func(token string) {
+			Expect(ioutil.WriteFile(tokenFile, []byte(token+"\n"), 0600)).To(Succeed())
+		}
+
+		authorization := func() string {
+			_, err := roundTripper.RoundTrip(request)
+			Expect(err).NotTo(HaveOccurred())
+			return delegate.requests[len(delegate.requests)-1].Header.Get("Authorization")
+		}
+
+		BeforeEach(func() {
+			file, err := ioutil.TempFile("", "token")
+			Expect(err).NotTo(HaveOccurred())
+			file.Close()
+			tokenFile = file.Name()
+			writeToken("token-1")
+
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			roundTripper = kubeclient.NewTokenFileRoundTripper(tokenFile, time.Minute, fakeClock, delegate)
+		})
+
+		AfterEach(func() {
+			os.Remove(tokenFile)
+		})
+
+		It("sends the token in the file", func() {
+			Expect(authorization()).To(Equal("Bearer token-1"))
+		})
+
+		It("does not modify the request it was given", func() {
+			authorization()
+			Expect(request.Header.Get("Authorization")).To(BeEmpty())
+		})
+
+		It("reads the file again once the refresh interval has passed", func() {
+			Expect(authorization()).To(Equal("Bearer token-1"))
+
+			writeToken("token-2")
+			Expect(authorization()).To(Equal("Bearer token-1"))
+
+			fakeClock.Increment(time.Minute)
+			Expect(authorization()).To(Equal("Bearer token-2"))
+		})
+
+		It("keeps the last token while the file cannot be read", func() {
+			Expect(authorization()).To(Equal("Bearer token-1"))
+
+			Expect(os.Remove(tokenFile)).To(Succeed())
+			fakeClock.Increment(time.Minute)
+			Expect(authorization()).To(Equal("Bearer token-1"))
+		})
+
+		It("fails when the file has never been read", func() {
+			Expect(os.Remove(tokenFile)).To(Succeed())
+
+			_, err := roundTripper.RoundTrip(request)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("leaves requests that are already authorized alone", func() {
+			request.Header.Set("Authorization", "Basic abc")
+			Expect(authorization()).To(Equal("Basic abc"))
+		})
+	})
+
+	Describe("NewImpersonatingRoundTripper", func() {
+		It("sets the impersonation headers", func() {
+			roundTripper := kubeclient.NewImpersonatingRoundTripper("tps", []string{"readers", "auditors"}, delegate)
+
+			_, err := roundTripper.RoundTrip(request)
+			Expect(err).NotTo(HaveOccurred())
+
+			sent := delegate.requests[0]
+			Expect(sent.Header.Get("Impersonate-User")).To(Equal("tps"))
+			Expect(sent.Header["Impersonate-Group"]).To(Equal([]string{"readers", "auditors"}))
+			Expect(request.Header).NotTo(HaveKey("Impersonate-User"))
+		})
+	})
+})
@@ -0,0 +1,19 @@
+package tps
+
+// a page of the recent events and crashes of a process's instances, newest first
+type LRPEvents struct {
+	ProcessGuid  string     `json:"process_guid"`
+	Page         int        `json:"page"`
+	PerPage      int        `json:"per_page"`
+	TotalResults int        `json:"total_results"`
+	TotalPages   int        `json:"total_pages"`
+	Events       []LRPEvent `json:"events"`
+}
+
+type LRPEvent struct {
+	Time    int64  `json:"time"`
+	Index   uint   `json:"index"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	Count   int32  `json:"count,omitempty"`
+}
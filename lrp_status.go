@@ -0,0 +1,65 @@
+package tps
+
+import "github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+
+// the v2 routes respond with an LRPStatus per process guid. During a
+// rolling update Instances also holds the instances of the app's other
+// versions, with Current unset; Versions counts the instances per version.
+type LRPStatus struct {
+	ProcessGuid    string         `json:"process_guid"`
+	CurrentVersion string         `json:"current_version,omitempty"`
+	Instances      []LRPInstance  `json:"instances"`
+	Versions       map[string]int `json:"versions,omitempty"`
+
+	// how many of the current instances run in each zone
+	Zones map[string]int `json:"zones,omitempty"`
+}
+
+// an instance as reported by the v2 routes. The embedded cc_messages
+// instance keeps the v1 fields at the top level of the JSON object.
+type LRPInstance struct {
+	cc_messages.LRPInstance
+
+	RestartCount    int32             `json:"restart_count"`
+	LastTermination *TerminationState `json:"last_termination,omitempty"`
+	NodeName        string            `json:"node_name,omitempty"`
+	Zone            string            `json:"zone,omitempty"`
+	Region          string            `json:"region,omitempty"`
+	Cluster         string            `json:"cluster,omitempty"`
+	PodName         string            `json:"pod_name"`
+	Namespace       string            `json:"namespace"`
+	ImageDigest     string            `json:"image_digest,omitempty"`
+	Ready           bool              `json:"ready"`
+	Conditions      []PodCondition    `json:"conditions,omitempty"`
+
+	// Since and Uptime follow the application container; this is when the
+	// pod itself started, before any container restarts
+	PodStartTime int64 `json:"pod_start_time"`
+
+	// the version of the process guid the instance's pod runs, and whether
+	// it is the version that was asked for
+	Version string `json:"version,omitempty"`
+	Current bool   `json:"current"`
+}
+
+type TerminationState struct {
+	Reason     string `json:"reason"`
+	ExitCode   int32  `json:"exit_code"`
+	Message    string `json:"message,omitempty"`
+	FinishedAt int64  `json:"finished_at"`
+}
+
+type PodCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime int64  `json:"last_transition_time"`
+}
+
+// a page of the processes found in a namespace, or across the cluster.
+// Continue is passed back to fetch the next page and is empty on the last.
+type LRPList struct {
+	Items    []LRPStatus `json:"items"`
+	Continue string      `json:"continue,omitempty"`
+}
@@ -0,0 +1,230 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/tps/podsource"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+type FakePodSource struct {
+	PodsStub        func(namespace string, selector labels.Selector) ([]v1.Pod, error)
+	podsMutex       sync.RWMutex
+	podsArgsForCall []struct {
+		namespace string
+		selector  labels.Selector
+	}
+	podsReturns struct {
+		result1 []v1.Pod
+		result2 error
+	}
+	ReplicationControllersStub        func(namespace string, selector labels.Selector) ([]v1.ReplicationController, error)
+	replicationControllersMutex       sync.RWMutex
+	replicationControllersArgsForCall []struct {
+		namespace string
+		selector  labels.Selector
+	}
+	replicationControllersReturns struct {
+		result1 []v1.ReplicationController
+		result2 error
+	}
+	ReplicaSetsStub        func(namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error)
+	replicaSetsMutex       sync.RWMutex
+	replicaSetsArgsForCall []struct {
+		namespace string
+		selector  labels.Selector
+	}
+	replicaSetsReturns struct {
+		result1 []v1beta1.ReplicaSet
+		result2 error
+	}
+	PodEventsStub        func(pod v1.Pod) ([]v1.Event, error)
+	podEventsMutex       sync.RWMutex
+	podEventsArgsForCall []struct {
+		pod v1.Pod
+	}
+	podEventsReturns struct {
+		result1 []v1.Event
+		result2 error
+	}
+	DeletePodStub        func(pod v1.Pod) error
+	deletePodMutex       sync.RWMutex
+	deletePodArgsForCall []struct {
+		pod v1.Pod
+	}
+	deletePodReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePodSource) Pods(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+	fake.podsMutex.Lock()
+	fake.podsArgsForCall = append(fake.podsArgsForCall, struct {
+		namespace string
+		selector  labels.Selector
+	}{namespace, selector})
+	fake.podsMutex.Unlock()
+	if fake.PodsStub != nil {
+		return fake.PodsStub(namespace, selector)
+	} else {
+		return fake.podsReturns.result1, fake.podsReturns.result2
+	}
+}
+
+func (fake *FakePodSource) PodsCallCount() int {
+	fake.podsMutex.RLock()
+	defer fake.podsMutex.RUnlock()
+	return len(fake.podsArgsForCall)
+}
+
+func (fake *FakePodSource) PodsArgsForCall(i int) (string, labels.Selector) {
+	fake.podsMutex.RLock()
+	defer fake.podsMutex.RUnlock()
+	return fake.podsArgsForCall[i].namespace, fake.podsArgsForCall[i].selector
+}
+
+func (fake *FakePodSource) PodsReturns(result1 []v1.Pod, result2 error) {
+	fake.PodsStub = nil
+	fake.podsReturns = struct {
+		result1 []v1.Pod
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePodSource) ReplicationControllers(namespace string, selector labels.Selector) ([]v1.ReplicationController, error) {
+	fake.replicationControllersMutex.Lock()
+	fake.replicationControllersArgsForCall = append(fake.replicationControllersArgsForCall, struct {
+		namespace string
+		selector  labels.Selector
+	}{namespace, selector})
+	fake.replicationControllersMutex.Unlock()
+	if fake.ReplicationControllersStub != nil {
+		return fake.ReplicationControllersStub(namespace, selector)
+	} else {
+		return fake.replicationControllersReturns.result1, fake.replicationControllersReturns.result2
+	}
+}
+
+func (fake *FakePodSource) ReplicationControllersCallCount() int {
+	fake.replicationControllersMutex.RLock()
+	defer fake.replicationControllersMutex.RUnlock()
+	return len(fake.replicationControllersArgsForCall)
+}
+
+func (fake *FakePodSource) ReplicationControllersArgsForCall(i int) (string, labels.Selector) {
+	fake.replicationControllersMutex.RLock()
+	defer fake.replicationControllersMutex.RUnlock()
+	return fake.replicationControllersArgsForCall[i].namespace, fake.replicationControllersArgsForCall[i].selector
+}
+
+func (fake *FakePodSource) ReplicationControllersReturns(result1 []v1.ReplicationController, result2 error) {
+	fake.ReplicationControllersStub = nil
+	fake.replicationControllersReturns = struct {
+		result1 []v1.ReplicationController
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePodSource) ReplicaSets(namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error) {
+	fake.replicaSetsMutex.Lock()
+	fake.replicaSetsArgsForCall = append(fake.replicaSetsArgsForCall, struct {
+		namespace string
+		selector  labels.Selector
+	}{namespace, selector})
+	fake.replicaSetsMutex.Unlock()
+	if fake.ReplicaSetsStub != nil {
+		return fake.ReplicaSetsStub(namespace, selector)
+	} else {
+		return fake.replicaSetsReturns.result1, fake.replicaSetsReturns.result2
+	}
+}
+
+func (fake *FakePodSource) ReplicaSetsCallCount() int {
+	fake.replicaSetsMutex.RLock()
+	defer fake.replicaSetsMutex.RUnlock()
+	return len(fake.replicaSetsArgsForCall)
+}
+
+func (fake *FakePodSource) ReplicaSetsArgsForCall(i int) (string, labels.Selector) {
+	fake.replicaSetsMutex.RLock()
+	defer fake.replicaSetsMutex.RUnlock()
+	return fake.replicaSetsArgsForCall[i].namespace, fake.replicaSetsArgsForCall[i].selector
+}
+
+func (fake *FakePodSource) ReplicaSetsReturns(result1 []v1beta1.ReplicaSet, result2 error) {
+	fake.ReplicaSetsStub = nil
+	fake.replicaSetsReturns = struct {
+		result1 []v1beta1.ReplicaSet
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePodSource) PodEvents(pod v1.Pod) ([]v1.Event, error) {
+	fake.podEventsMutex.Lock()
+	fake.podEventsArgsForCall = append(fake.podEventsArgsForCall, struct {
+		pod v1.Pod
+	}{pod})
+	fake.podEventsMutex.Unlock()
+	if fake.PodEventsStub != nil {
+		return fake.PodEventsStub(pod)
+	} else {
+		return fake.podEventsReturns.result1, fake.podEventsReturns.result2
+	}
+}
+
+func (fake *FakePodSource) PodEventsCallCount() int {
+	fake.podEventsMutex.RLock()
+	defer fake.podEventsMutex.RUnlock()
+	return len(fake.podEventsArgsForCall)
+}
+
+func (fake *FakePodSource) PodEventsArgsForCall(i int) v1.Pod {
+	fake.podEventsMutex.RLock()
+	defer fake.podEventsMutex.RUnlock()
+	return fake.podEventsArgsForCall[i].pod
+}
+
+func (fake *FakePodSource) PodEventsReturns(result1 []v1.Event, result2 error) {
+	fake.PodEventsStub = nil
+	fake.podEventsReturns = struct {
+		result1 []v1.Event
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePodSource) DeletePod(pod v1.Pod) error {
+	fake.deletePodMutex.Lock()
+	fake.deletePodArgsForCall = append(fake.deletePodArgsForCall, struct {
+		pod v1.Pod
+	}{pod})
+	fake.deletePodMutex.Unlock()
+	if fake.DeletePodStub != nil {
+		return fake.DeletePodStub(pod)
+	} else {
+		return fake.deletePodReturns.result1
+	}
+}
+
+func (fake *FakePodSource) DeletePodCallCount() int {
+	fake.deletePodMutex.RLock()
+	defer fake.deletePodMutex.RUnlock()
+	return len(fake.deletePodArgsForCall)
+}
+
+func (fake *FakePodSource) DeletePodArgsForCall(i int) v1.Pod {
+	fake.deletePodMutex.RLock()
+	defer fake.deletePodMutex.RUnlock()
+	return fake.deletePodArgsForCall[i].pod
+}
+
+func (fake *FakePodSource) DeletePodReturns(result1 error) {
+	fake.DeletePodStub = nil
+	fake.deletePodReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ podsource.PodSource = new(FakePodSource)
@@ -0,0 +1,246 @@
+package podsource
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+type Cluster struct {
+	Name   string
+	Source PodSource
+}
+
+// PartialError is returned by a FederatedSource, along with the results of
+// the clusters that answered, when some of the clusters it asked failed
+type PartialError struct {
+	// the error of each failed cluster, by cluster name
+	Failed map[string]error
+}
+
+func (e *PartialError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failures := make([]string, len(names))
+	for i, name := range names {
+		failures[i] = fmt.Sprintf("%s: %s", name, e.Failed[name])
+	}
+	return "results are missing unreachable clusters: " + strings.Join(failures, "; ")
+}
+
+func IsPartial(err error) bool {
+	_, ok := err.(*PartialError)
+	return ok
+}
+
+// FederatedSource merges the pods, replication controllers and replica sets
+// of several clusters. Apps listed in appClusters are only looked up in their
+// clusters, any other app in all of them. Events and deletes go to the
+// cluster named by the pod's ClusterAnnotation.
+type FederatedSource struct {
+	clusters    []Cluster
+	appClusters map[string][]string
+}
+
+func NewFederatedSource(clusters []Cluster, appClusters map[string][]string) *FederatedSource {
+	return &FederatedSource{
+		clusters:    clusters,
+		appClusters: appClusters,
+	}
+}
+
+func (s *FederatedSource) Pods(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+	results := make([][]v1.Pod, len(s.clusters))
+	err := s.each(selector, func(i int, cluster Cluster) error {
+		pods, err := cluster.Source.Pods(namespace, selector)
+		if err != nil {
+			return err
+		}
+
+		for j := range pods {
+			pods[j] = withCluster(pods[j], cluster.Name)
+		}
+		results[i] = pods
+		return nil
+	})
+
+	pods := []v1.Pod{}
+	for _, result := range results {
+		pods = append(pods, result...)
+	}
+	return pods, err
+}
+
+func (s *FederatedSource) ReplicationControllers(namespace string, selector labels.Selector) ([]v1.ReplicationController, error) {
+	results := make([][]v1.ReplicationController, len(s.clusters))
+	err := s.each(selector, func(i int, cluster Cluster) error {
+		rcs, err := cluster.Source.ReplicationControllers(namespace, selector)
+		results[i] = rcs
+		return err
+	})
+
+	rcs := []v1.ReplicationController{}
+	for _, result := range results {
+		rcs = append(rcs, result...)
+	}
+	return rcs, err
+}
+
+func (s *FederatedSource) ReplicaSets(namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error) {
+	results := make([][]v1beta1.ReplicaSet, len(s.clusters))
+	err := s.each(selector, func(i int, cluster Cluster) error {
+		rss, err := cluster.Source.ReplicaSets(namespace, selector)
+		results[i] = rss
+		return err
+	})
+
+	rss := []v1beta1.ReplicaSet{}
+	for _, result := range results {
+		rss = append(rss, result...)
+	}
+	return rss, err
+}
+
+func (s *FederatedSource) PodEvents(pod v1.Pod) ([]v1.Event, error) {
+	cluster, err := s.clusterOf(pod)
+	if err != nil {
+		return nil, err
+	}
+	return cluster.Source.PodEvents(pod)
+}
+
+func (s *FederatedSource) DeletePod(pod v1.Pod) error {
+	cluster, err := s.clusterOf(pod)
+	if err != nil {
+		return err
+	}
+	return cluster.Source.DeletePod(pod)
+}
+
+// calls fn for each cluster the selector's app lives in, concurrently. When
+// every cluster fails the first failure is returned, when only some do a
+// PartialError.
+func (s *FederatedSource) each(selector labels.Selector, fn func(int, Cluster) error) error {
+	wanted := s.clustersFor(selector)
+
+	errs := make([]error, len(s.clusters))
+	wg := sync.WaitGroup{}
+	for i, cluster := range s.clusters {
+		if !wanted(cluster.Name) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, cluster Cluster) {
+			defer wg.Done()
+			errs[i] = fn(i, cluster)
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	asked := 0
+	failed := map[string]error{}
+	var firstErr error
+	for i, cluster := range s.clusters {
+		if !wanted(cluster.Name) {
+			continue
+		}
+		asked++
+		if errs[i] != nil {
+			failed[cluster.Name] = errs[i]
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+		}
+	}
+
+	switch {
+	case len(failed) == 0:
+		return nil
+	case len(failed) == asked:
+		return firstErr
+	default:
+		return &PartialError{Failed: failed}
+	}
+}
+
+func (s *FederatedSource) clustersFor(selector labels.Selector) func(string) bool {
+	all := func(string) bool { return true }
+
+	appGuid, found := exactMatch(selector, AppGuidLabel)
+	if !found {
+		shortenedGuid, found := exactMatch(selector, ProcessGuidLabel)
+		if !found {
+			return all
+		}
+		pg, err := helpers.DecodeProcessGuid(shortenedGuid)
+		if err != nil {
+			return all
+		}
+		appGuid = pg.AppGuid.String()
+	}
+
+	names, mapped := s.appClusters[appGuid]
+	if !mapped {
+		return all
+	}
+
+	return func(name string) bool {
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (s *FederatedSource) clusterOf(pod v1.Pod) (Cluster, error) {
+	name := pod.ObjectMeta.Annotations[ClusterAnnotation]
+	for _, cluster := range s.clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	return Cluster{}, fmt.Errorf("pod %s/%s is not from a known cluster", pod.ObjectMeta.Namespace, pod.ObjectMeta.Name)
+}
+
+// the value a selector requires the label to equal; the release_1_3
+// selectors cannot be inspected other than through their string form
+func exactMatch(selector labels.Selector, label string) (string, bool) {
+	for _, term := range strings.Split(selector.String(), ",") {
+		parts := strings.SplitN(term, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimPrefix(parts[1], "=")
+		if key == label {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// the pod may be shared with an informer's cache, so its annotations are
+// copied rather than added to
+func withCluster(pod v1.Pod, name string) v1.Pod {
+	annotations := make(map[string]string, len(pod.ObjectMeta.Annotations)+1)
+	for k, v := range pod.ObjectMeta.Annotations {
+		annotations[k] = v
+	}
+	annotations[ClusterAnnotation] = name
+	pod.ObjectMeta.Annotations = annotations
+	return pod
+}
@@ -0,0 +1,188 @@
+package podsource_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/nsync/helpers"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
+
+	"github.com/cloudfoundry-incubator/tps/podsource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FederatedSource", func() {
+	const (
+		appGuid = "8d58c09b-b305-4f16-bcfe-b78edcb77100"
+		version = "3f258eb0-9dac-460c-a424-b43fe92bee27"
+	)
+
+	var (
+		east, west *podsource.MemorySource
+		source     *podsource.FederatedSource
+	)
+
+	clusterOf := func(pod v1.Pod) string {
+		return pod.ObjectMeta.Annotations[podsource.ClusterAnnotation]
+	}
+
+	BeforeEach(func() {
+		east = podsource.NewMemorySource()
+		east.AddPod(newPod("ns-1", "pod-a-east", "guid-a"))
+		east.AddReplicationController(newRC("ns-1", "rc-a-east", "guid-a"))
+
+		west = podsource.NewMemorySource()
+		west.AddPod(newPod("ns-1", "pod-a-west", "guid-a"))
+		west.AddReplicationController(newRC("ns-1", "rc-a-west", "guid-a"))
+		west.AddReplicaSet(newRS("ns-1", "rs-a-west", "guid-a"))
+
+		source = podsource.NewFederatedSource([]podsource.Cluster{
+			{Name: "east", Source: east},
+			{Name: "west", Source: west},
+		}, map[string][]string{appGuid: {"west"}})
+	})
+
+	It("merges the pods of every cluster, annotated with their cluster", func() {
+		pods, err := source.Pods(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(pods)).To(Equal([]string{"ns-1/pod-a-east", "ns-1/pod-a-west"}))
+		Expect(clusterOf(pods[0])).To(Equal("east"))
+		Expect(clusterOf(pods[1])).To(Equal("west"))
+	})
+
+	It("leaves the pods held by the clusters' sources unannotated", func() {
+		_, err := source.Pods(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+
+		pods, err := east.Pods(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(clusterOf(pods[0])).To(BeEmpty())
+	})
+
+	It("merges the replication controllers of every cluster", func() {
+		rcs, err := source.ReplicationControllers(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rcs).To(HaveLen(2))
+	})
+
+	It("merges the replica sets of every cluster", func() {
+		rss, err := source.ReplicaSets(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rss).To(HaveLen(1))
+		Expect(rss[0].ObjectMeta.Name).To(Equal("rs-a-west"))
+	})
+
+	Describe("routing by app", func() {
+		BeforeEach(func() {
+			pod := newPod("ns-1", "pod-mapped-east", "unused")
+			pod.ObjectMeta.Labels["cloudfoundry.org/app-guid"] = appGuid
+			east.AddPod(pod)
+
+			pod = newPod("ns-1", "pod-mapped-west", "unused")
+			pod.ObjectMeta.Labels["cloudfoundry.org/app-guid"] = appGuid
+			west.AddPod(pod)
+		})
+
+		It("only asks the clusters a mapped app guid is in", func() {
+			pods, err := source.Pods(api.NamespaceAll, labels.Set{"cloudfoundry.org/app-guid": appGuid}.AsSelector())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(pods)).To(Equal([]string{"ns-1/pod-mapped-west"}))
+		})
+
+		It("routes process guids by the app guid they encode", func() {
+			processGuid, err := helpers.NewProcessGuid(appGuid + "-" + version)
+			Expect(err).NotTo(HaveOccurred())
+
+			pod := newPod("ns-1", "pod-process-east", processGuid.ShortenedGuid())
+			east.AddPod(pod)
+			pod.ObjectMeta.Name = "pod-process-west"
+			west.AddPod(pod)
+
+			pods, err := source.Pods(api.NamespaceAll, labels.Set{"cloudfoundry.org/process-guid": processGuid.ShortenedGuid()}.AsSelector())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(pods)).To(Equal([]string{"ns-1/pod-process-west"}))
+		})
+
+		It("does not fail because a cluster the app is not in is unreachable", func() {
+			east.FailWith(errors.New("connection refused"))
+
+			_, err := source.Pods(api.NamespaceAll, labels.Set{"cloudfoundry.org/app-guid": appGuid}.AsSelector())
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when one cluster is unreachable", func() {
+		BeforeEach(func() {
+			east.FailWith(errors.New("connection refused"))
+		})
+
+		It("returns the other clusters' pods with a partial error", func() {
+			pods, err := source.Pods(api.NamespaceAll, processSelector)
+			Expect(podsource.IsPartial(err)).To(BeTrue())
+			Expect(err).To(MatchError("results are missing unreachable clusters: east: connection refused"))
+			Expect(names(pods)).To(Equal([]string{"ns-1/pod-a-west"}))
+		})
+
+		It("returns the other clusters' replication controllers with a partial error", func() {
+			rcs, err := source.ReplicationControllers(api.NamespaceAll, processSelector)
+			Expect(podsource.IsPartial(err)).To(BeTrue())
+			Expect(rcs).To(HaveLen(1))
+		})
+	})
+
+	Context("when every cluster is unreachable", func() {
+		BeforeEach(func() {
+			east.FailWith(errors.New("east is down"))
+			west.FailWith(errors.New("west is down"))
+		})
+
+		It("fails with the error of the first cluster", func() {
+			_, err := source.Pods(api.NamespaceAll, processSelector)
+			Expect(err).To(MatchError("east is down"))
+			Expect(podsource.IsPartial(err)).To(BeFalse())
+		})
+	})
+
+	Describe("pod events and deletes", func() {
+		var pod v1.Pod
+
+		BeforeEach(func() {
+			west.AddEvent(v1.Event{
+				ObjectMeta:     v1.ObjectMeta{Namespace: "ns-1"},
+				InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "pod-a-west"},
+				Reason:         "Started",
+			})
+
+			pods, err := source.Pods(api.NamespaceAll, processSelector)
+			Expect(err).NotTo(HaveOccurred())
+			pod = pods[1]
+		})
+
+		It("asks the cluster the pod was found in for its events", func() {
+			events, err := source.PodEvents(pod)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Reason).To(Equal("Started"))
+		})
+
+		It("deletes the pod from the cluster it was found in", func() {
+			Expect(source.DeletePod(pod)).To(Succeed())
+
+			pods, err := west.Pods(api.NamespaceAll, processSelector)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pods).To(BeEmpty())
+
+			pods, err = east.Pods(api.NamespaceAll, processSelector)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pods).To(HaveLen(1))
+		})
+
+		It("fails for pods not from a known cluster", func() {
+			err := source.DeletePod(newPod("ns-1", "pod-a-west", "guid-a"))
+			Expect(err).To(MatchError("pod ns-1/pod-a-west is not from a known cluster"))
+		})
+	})
+})
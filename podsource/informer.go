@@ -0,0 +1,180 @@
+package podsource
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/client/cache"
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3"
+	"k8s.io/kubernetes/pkg/controller/framework"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+// InformerSource serves pods, replication controllers and replica sets from
+// caches kept up to date by watching the objects of processes across the
+// cluster, so a request costs no API calls. Events and deletes still go to
+// the API server. It is an ifrit runner and is ready once every cache has
+// synced.
+type InformerSource struct {
+	kubeSource
+
+	pods          cache.Store
+	rcs           cache.Store
+	rss           cache.Store
+	podController *framework.Controller
+	rcController  *framework.Controller
+	rsController  *framework.Controller
+	logger        lager.Logger
+}
+
+// NewInformerSource watches with watchClient, whose requests must be allowed
+// to stay open, and sends events and deletes to k8sClient
+func NewInformerSource(watchClient, k8sClient clientset.Interface, resyncPeriod time.Duration, logger lager.Logger) *InformerSource {
+	podListWatch := &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			return watchClient.Core().Pods(api.NamespaceAll).List(onlyProcesses(options))
+		},
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			return watchClient.Core().Pods(api.NamespaceAll).Watch(onlyProcesses(options))
+		},
+	}
+
+	rcListWatch := &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			return watchClient.Core().ReplicationControllers(api.NamespaceAll).List(onlyProcesses(options))
+		},
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			return watchClient.Core().ReplicationControllers(api.NamespaceAll).Watch(onlyProcesses(options))
+		},
+	}
+
+	rsListWatch := &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			return watchClient.Extensions().ReplicaSets(api.NamespaceAll).List(onlyProcesses(options))
+		},
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			return watchClient.Extensions().ReplicaSets(api.NamespaceAll).Watch(onlyProcesses(options))
+		},
+	}
+
+	pods, podController := framework.NewInformer(podListWatch, &v1.Pod{}, resyncPeriod, framework.ResourceEventHandlerFuncs{})
+	rcs, rcController := framework.NewInformer(rcListWatch, &v1.ReplicationController{}, resyncPeriod, framework.ResourceEventHandlerFuncs{})
+	rss, rsController := framework.NewInformer(rsListWatch, &v1beta1.ReplicaSet{}, resyncPeriod, framework.ResourceEventHandlerFuncs{})
+
+	return &InformerSource{
+		kubeSource:    newKubeSource(k8sClient),
+		pods:          pods,
+		rcs:           rcs,
+		rss:           rss,
+		podController: podController,
+		rcController:  rcController,
+		rsController:  rsController,
+		logger:        logger,
+	}
+}
+
+func (s *InformerSource) Pods(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+	pods := []v1.Pod{}
+	for _, obj := range s.pods.List() {
+		pod, ok := obj.(*v1.Pod)
+		if ok && matches(pod.ObjectMeta, namespace, selector) {
+			pods = append(pods, *pod)
+		}
+	}
+
+	sort.Sort(podsByKey(pods))
+	return pods, nil
+}
+
+func (s *InformerSource) ReplicationControllers(namespace string, selector labels.Selector) ([]v1.ReplicationController, error) {
+	rcs := []v1.ReplicationController{}
+	for _, obj := range s.rcs.List() {
+		rc, ok := obj.(*v1.ReplicationController)
+		if ok && matches(rc.ObjectMeta, namespace, selector) {
+			rcs = append(rcs, *rc)
+		}
+	}
+
+	sort.Sort(rcsByKey(rcs))
+	return rcs, nil
+}
+
+func (s *InformerSource) ReplicaSets(namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error) {
+	rss := []v1beta1.ReplicaSet{}
+	for _, obj := range s.rss.List() {
+		rs, ok := obj.(*v1beta1.ReplicaSet)
+		if ok && matches(rs.ObjectMeta, namespace, selector) {
+			rss = append(rss, *rs)
+		}
+	}
+
+	sort.Sort(rssByKey(rss))
+	return rss, nil
+}
+
+func (s *InformerSource) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := s.logger.Session("pod-cache")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	stop := make(chan struct{})
+	go s.podController.Run(stop)
+	go s.rcController.Run(stop)
+	go s.rsController.Run(stop)
+
+	for !s.podController.HasSynced() || !s.rcController.HasSynced() || !s.rsController.HasSynced() {
+		select {
+		case <-signals:
+			close(stop)
+			return nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	close(ready)
+	logger.Info("started")
+
+	<-signals
+	close(stop)
+	return nil
+}
+
+// selects the objects carrying a process guid, leaving the rest of the
+// cluster out of the caches
+var processesSelector = func() labels.Selector {
+	selector, err := labels.Parse(ProcessGuidLabel)
+	if err != nil {
+		panic(err)
+	}
+	return selector
+}()
+
+func onlyProcesses(options api.ListOptions) api.ListOptions {
+	options.LabelSelector = processesSelector
+	return options
+}
+
+type podsByKey []v1.Pod
+
+func (p podsByKey) Len() int           { return len(p) }
+func (p podsByKey) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+func (p podsByKey) Less(i, j int) bool { return key(p[i].ObjectMeta) < key(p[j].ObjectMeta) }
+
+type rcsByKey []v1.ReplicationController
+
+func (r rcsByKey) Len() int           { return len(r) }
+func (r rcsByKey) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r rcsByKey) Less(i, j int) bool { return key(r[i].ObjectMeta) < key(r[j].ObjectMeta) }
+
+type rssByKey []v1beta1.ReplicaSet
+
+func (r rssByKey) Len() int           { return len(r) }
+func (r rssByKey) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r rssByKey) Less(i, j int) bool { return key(r[i].ObjectMeta) < key(r[j].ObjectMeta) }
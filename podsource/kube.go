@@ -0,0 +1,75 @@
+package podsource
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	clientset "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3"
+	v1core "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1"
+	v1beta1extensions "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+type kubeSource struct {
+	k8sClient  v1core.CoreInterface
+	extensions v1beta1extensions.ExtensionsInterface
+}
+
+// NewKubeSource asks the API server on every call
+func NewKubeSource(k8sClient clientset.Interface) PodSource {
+	source := newKubeSource(k8sClient)
+	return &source
+}
+
+func newKubeSource(k8sClient clientset.Interface) kubeSource {
+	return kubeSource{
+		k8sClient:  k8sClient.Core(),
+		extensions: k8sClient.Extensions(),
+	}
+}
+
+func (s *kubeSource) Pods(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+	podList, err := s.k8sClient.Pods(namespace).List(api.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+func (s *kubeSource) ReplicationControllers(namespace string, selector labels.Selector) ([]v1.ReplicationController, error) {
+	rcList, err := s.k8sClient.ReplicationControllers(namespace).List(api.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return rcList.Items, nil
+}
+
+func (s *kubeSource) ReplicaSets(namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error) {
+	rsList, err := s.extensions.ReplicaSets(namespace).List(api.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	return rsList.Items, nil
+}
+
+func (s *kubeSource) PodEvents(pod v1.Pod) ([]v1.Event, error) {
+	eventList, err := s.k8sClient.Events(pod.ObjectMeta.Namespace).List(api.ListOptions{
+		FieldSelector: podEventsSelector(pod),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return eventList.Items, nil
+}
+
+func (s *kubeSource) DeletePod(pod v1.Pod) error {
+	return s.k8sClient.Pods(pod.ObjectMeta.Namespace).Delete(pod.ObjectMeta.Name, nil)
+}
+
+func podEventsSelector(pod v1.Pod) fields.Selector {
+	return fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": pod.ObjectMeta.Name,
+	}.AsSelector()
+}
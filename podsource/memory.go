@@ -0,0 +1,179 @@
+package podsource
+
+import (
+	"sort"
+	"sync"
+
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+// MemorySource is a PodSource over objects added by the caller, for tests
+// that would otherwise stub a whole clientset
+type MemorySource struct {
+	lock   sync.Mutex
+	pods   map[string]v1.Pod
+	rcs    map[string]v1.ReplicationController
+	rss    map[string]v1beta1.ReplicaSet
+	events []v1.Event
+	err    error
+}
+
+func NewMemorySource() *MemorySource {
+	return &MemorySource{
+		pods: map[string]v1.Pod{},
+		rcs:  map[string]v1.ReplicationController{},
+		rss:  map[string]v1beta1.ReplicaSet{},
+	}
+}
+
+// AddPod adds the pod, replacing any pod with the same namespace and name
+func (s *MemorySource) AddPod(pods ...v1.Pod) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, pod := range pods {
+		s.pods[key(pod.ObjectMeta)] = pod
+	}
+}
+
+func (s *MemorySource) AddReplicationController(rcs ...v1.ReplicationController) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, rc := range rcs {
+		s.rcs[key(rc.ObjectMeta)] = rc
+	}
+}
+
+func (s *MemorySource) AddReplicaSet(rss ...v1beta1.ReplicaSet) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, rs := range rss {
+		s.rss[key(rs.ObjectMeta)] = rs
+	}
+}
+
+func (s *MemorySource) AddEvent(events ...v1.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.events = append(s.events, events...)
+}
+
+// FailWith makes every later call return err, or succeed again when err
+// is nil
+func (s *MemorySource) FailWith(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.err = err
+}
+
+func (s *MemorySource) Pods(namespace string, selector labels.Selector) ([]v1.Pod, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	keys := []string{}
+	for k, pod := range s.pods {
+		if matches(pod.ObjectMeta, namespace, selector) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pods := make([]v1.Pod, 0, len(keys))
+	for _, k := range keys {
+		pods = append(pods, s.pods[k])
+	}
+	return pods, nil
+}
+
+func (s *MemorySource) ReplicationControllers(namespace string, selector labels.Selector) ([]v1.ReplicationController, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	keys := []string{}
+	for k, rc := range s.rcs {
+		if matches(rc.ObjectMeta, namespace, selector) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	rcs := make([]v1.ReplicationController, 0, len(keys))
+	for _, k := range keys {
+		rcs = append(rcs, s.rcs[k])
+	}
+	return rcs, nil
+}
+
+func (s *MemorySource) ReplicaSets(namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	keys := []string{}
+	for k, rs := range s.rss {
+		if matches(rs.ObjectMeta, namespace, selector) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	rss := make([]v1beta1.ReplicaSet, 0, len(keys))
+	for _, k := range keys {
+		rss = append(rss, s.rss[k])
+	}
+	return rss, nil
+}
+
+func (s *MemorySource) PodEvents(pod v1.Pod) ([]v1.Event, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	events := []v1.Event{}
+	for _, event := range s.events {
+		if event.ObjectMeta.Namespace == pod.ObjectMeta.Namespace &&
+			event.InvolvedObject.Kind == "Pod" &&
+			event.InvolvedObject.Name == pod.ObjectMeta.Name {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// DeletePod removes the pod; like the API server it fails with a not found
+// error when there is no such pod
+func (s *MemorySource) DeletePod(pod v1.Pod) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	k := key(pod.ObjectMeta)
+	if _, found := s.pods[k]; !found {
+		return notFound("pods", pod.ObjectMeta.Name)
+	}
+	delete(s.pods, k)
+	return nil
+}
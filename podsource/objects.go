@@ -0,0 +1,24 @@
+package podsource
+
+import (
+	"k8s.io/kubernetes/pkg/api"
+	kubeerrors "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/unversioned"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+func key(meta v1.ObjectMeta) string {
+	return meta.Namespace + "/" + meta.Name
+}
+
+func matches(meta v1.ObjectMeta, namespace string, selector labels.Selector) bool {
+	if namespace != api.NamespaceAll && meta.Namespace != namespace {
+		return false
+	}
+	return selector.Matches(labels.Set(meta.Labels))
+}
+
+func notFound(resource, name string) error {
+	return kubeerrors.NewNotFound(unversioned.GroupResource{Resource: resource}, name)
+}
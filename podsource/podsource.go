@@ -0,0 +1,43 @@
+package podsource
+
+import (
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/labels"
+)
+
+// the labels nsync puts on the pods and controllers it creates
+const (
+	AppGuidLabel     = "cloudfoundry.org/app-guid"
+	ProcessGuidLabel = "cloudfoundry.org/process-guid"
+)
+
+// the annotation nsync puts on the pods it creates for the loggregator guid
+// of their app
+const LogGuidAnnotation = "cloudfoundry.org/log-guid"
+
+// set on the pods returned by a FederatedSource to the name of the cluster
+// they were found in
+const ClusterAnnotation = "tps.cloudfoundry.org/cluster"
+
+//go:generate counterfeiter -o fakes/fake_pod_source.go . PodSource
+
+// PodSource is all the handlers need from kubernetes. Only the adapters in
+// this package know which clientset serves it, so moving to another client
+// version does not touch the handlers.
+type PodSource interface {
+	// the pods in the namespace, or in every namespace when it is
+	// api.NamespaceAll, that match the selector
+	Pods(namespace string, selector labels.Selector) ([]v1.Pod, error)
+
+	ReplicationControllers(namespace string, selector labels.Selector) ([]v1.ReplicationController, error)
+
+	// processes deployed through a Deployment are kept at their desired
+	// instances by its replica sets rather than by a replication controller
+	ReplicaSets(namespace string, selector labels.Selector) ([]v1beta1.ReplicaSet, error)
+
+	// the events kubernetes recorded about the pod
+	PodEvents(pod v1.Pod) ([]v1.Event, error)
+
+	DeletePod(pod v1.Pod) error
+}
@@ -0,0 +1,13 @@
+package podsource_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPodSource(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "PodSource Suite")
+}
@@ -0,0 +1,342 @@
+package podsource_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+	"k8s.io/kubernetes/pkg/api"
+	kubeerrors "k8s.io/kubernetes/pkg/api/errors"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/apis/extensions/v1beta1"
+	"k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/fake"
+	"k8s.io/kubernetes/pkg/client/testing/core"
+	"k8s.io/kubernetes/pkg/fields"
+	"k8s.io/kubernetes/pkg/labels"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	"github.com/cloudfoundry-incubator/tps/podsource"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newPod(namespace, name, processGuid string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{"cloudfoundry.org/process-guid": processGuid},
+		},
+	}
+}
+
+func newRC(namespace, name, processGuid string) v1.ReplicationController {
+	return v1.ReplicationController{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{"cloudfoundry.org/process-guid": processGuid},
+		},
+	}
+}
+
+func newRS(namespace, name, processGuid string) v1beta1.ReplicaSet {
+	return v1beta1.ReplicaSet{
+		ObjectMeta: v1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    map[string]string{"cloudfoundry.org/process-guid": processGuid},
+		},
+	}
+}
+
+func names(pods []v1.Pod) []string {
+	podNames := []string{}
+	for _, pod := range pods {
+		podNames = append(podNames, pod.ObjectMeta.Namespace+"/"+pod.ObjectMeta.Name)
+	}
+	return podNames
+}
+
+var processSelector = labels.Set{"cloudfoundry.org/process-guid": "guid-a"}.AsSelector()
+
+var _ = Describe("MemorySource", func() {
+	var source *podsource.MemorySource
+
+	BeforeEach(func() {
+		source = podsource.NewMemorySource()
+		source.AddPod(
+			newPod("ns-2", "pod-a-2", "guid-a"),
+			newPod("ns-1", "pod-a-1", "guid-a"),
+			newPod("ns-1", "pod-b-1", "guid-b"),
+		)
+		source.AddReplicationController(newRC("ns-1", "rc-a", "guid-a"), newRC("ns-1", "rc-b", "guid-b"))
+		source.AddReplicaSet(newRS("ns-2", "rs-a", "guid-a"), newRS("ns-2", "rs-b", "guid-b"))
+	})
+
+	It("lists the matching pods across namespaces in namespace and name order", func() {
+		pods, err := source.Pods(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(pods)).To(Equal([]string{"ns-1/pod-a-1", "ns-2/pod-a-2"}))
+	})
+
+	It("lists the matching pods of a namespace", func() {
+		pods, err := source.Pods("ns-2", labels.Everything())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(pods)).To(Equal([]string{"ns-2/pod-a-2"}))
+	})
+
+	It("lists the matching replication controllers", func() {
+		rcs, err := source.ReplicationControllers(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rcs).To(HaveLen(1))
+		Expect(rcs[0].ObjectMeta.Name).To(Equal("rc-a"))
+	})
+
+	It("lists the matching replica sets", func() {
+		rss, err := source.ReplicaSets(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rss).To(HaveLen(1))
+		Expect(rss[0].ObjectMeta.Name).To(Equal("rs-a"))
+	})
+
+	It("returns the events of a pod", func() {
+		pod := newPod("ns-1", "pod-a-1", "guid-a")
+		source.AddEvent(
+			v1.Event{ObjectMeta: v1.ObjectMeta{Namespace: "ns-1"}, InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "pod-a-1"}, Reason: "Started"},
+			v1.Event{ObjectMeta: v1.ObjectMeta{Namespace: "ns-1"}, InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "pod-b-1"}, Reason: "Pulled"},
+			v1.Event{ObjectMeta: v1.ObjectMeta{Namespace: "ns-2"}, InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "pod-a-1"}, Reason: "Killing"},
+		)
+
+		events, err := source.PodEvents(pod)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Reason).To(Equal("Started"))
+	})
+
+	Describe("DeletePod", func() {
+		It("removes the pod", func() {
+			Expect(source.DeletePod(newPod("ns-1", "pod-a-1", "guid-a"))).To(Succeed())
+
+			pods, err := source.Pods(api.NamespaceAll, processSelector)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(pods)).To(Equal([]string{"ns-2/pod-a-2"}))
+		})
+
+		It("fails with not found for an unknown pod", func() {
+			err := source.DeletePod(newPod("ns-1", "pod-c", "guid-c"))
+			Expect(kubeerrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("when told to fail", func() {
+		BeforeEach(func() {
+			source.FailWith(errors.New("boom"))
+		})
+
+		It("fails every call", func() {
+			_, err := source.Pods(api.NamespaceAll, labels.Everything())
+			Expect(err).To(MatchError("boom"))
+			_, err = source.ReplicationControllers(api.NamespaceAll, labels.Everything())
+			Expect(err).To(MatchError("boom"))
+			_, err = source.ReplicaSets(api.NamespaceAll, labels.Everything())
+			Expect(err).To(MatchError("boom"))
+			Expect(source.DeletePod(newPod("ns-1", "pod-a-1", "guid-a"))).To(MatchError("boom"))
+		})
+	})
+})
+
+var _ = Describe("KubeSource", func() {
+	var (
+		clientset *fake.Clientset
+		source    podsource.PodSource
+	)
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+		source = podsource.NewKubeSource(clientset)
+	})
+
+	It("lists pods with the selector", func() {
+		clientset.PrependReactor("list", "pods", func(core.Action) (bool, runtime.Object, error) {
+			return true, &v1.PodList{Items: []v1.Pod{newPod("ns-1", "pod-a-1", "guid-a")}}, nil
+		})
+
+		pods, err := source.Pods("ns-1", processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(pods)).To(Equal([]string{"ns-1/pod-a-1"}))
+
+		action := clientset.Actions()[0].(core.ListAction)
+		Expect(action.GetNamespace()).To(Equal("ns-1"))
+		Expect(action.GetListRestrictions().Labels).To(Equal(processSelector))
+	})
+
+	It("lists replication controllers with the selector", func() {
+		clientset.PrependReactor("list", "replicationcontrollers", func(core.Action) (bool, runtime.Object, error) {
+			return true, &v1.ReplicationControllerList{Items: []v1.ReplicationController{newRC("ns-1", "rc-a", "guid-a")}}, nil
+		})
+
+		rcs, err := source.ReplicationControllers(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rcs).To(HaveLen(1))
+
+		action := clientset.Actions()[0].(core.ListAction)
+		Expect(action.GetNamespace()).To(Equal(api.NamespaceAll))
+		Expect(action.GetListRestrictions().Labels).To(Equal(processSelector))
+	})
+
+	It("lists replica sets with the selector", func() {
+		clientset.PrependReactor("list", "replicasets", func(core.Action) (bool, runtime.Object, error) {
+			return true, &v1beta1.ReplicaSetList{Items: []v1beta1.ReplicaSet{newRS("ns-1", "rs-a", "guid-a")}}, nil
+		})
+
+		rss, err := source.ReplicaSets(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rss).To(HaveLen(1))
+
+		action := clientset.Actions()[0].(core.ListAction)
+		Expect(action.Matches("list", "replicasets")).To(BeTrue())
+		Expect(action.GetListRestrictions().Labels).To(Equal(processSelector))
+	})
+
+	It("returns list errors", func() {
+		clientset.PrependReactor("list", "pods", func(core.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("boom")
+		})
+
+		_, err := source.Pods(api.NamespaceAll, processSelector)
+		Expect(err).To(MatchError("boom"))
+	})
+
+	It("lists the events involving a pod", func() {
+		events := []v1.Event{{Reason: "Started"}}
+		clientset.PrependReactor("list", "events", func(core.Action) (bool, runtime.Object, error) {
+			return true, &v1.EventList{Items: events}, nil
+		})
+
+		podEvents, err := source.PodEvents(newPod("ns-1", "pod-a-1", "guid-a"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(podEvents).To(Equal(events))
+
+		action := clientset.Actions()[0].(core.ListAction)
+		Expect(action.GetNamespace()).To(Equal("ns-1"))
+		fieldSelector := action.GetListRestrictions().Fields
+		Expect(fieldSelector.Matches(fields.Set{"involvedObject.kind": "Pod", "involvedObject.name": "pod-a-1"})).To(BeTrue())
+		Expect(fieldSelector.Matches(fields.Set{"involvedObject.kind": "Pod", "involvedObject.name": "pod-b-1"})).To(BeFalse())
+	})
+
+	It("deletes pods by namespace and name", func() {
+		clientset.PrependReactor("delete", "pods", func(core.Action) (bool, runtime.Object, error) {
+			return true, nil, nil
+		})
+
+		Expect(source.DeletePod(newPod("ns-1", "pod-a-1", "guid-a"))).To(Succeed())
+
+		action := clientset.Actions()[0].(core.DeleteAction)
+		Expect(action.GetNamespace()).To(Equal("ns-1"))
+		Expect(action.GetName()).To(Equal("pod-a-1"))
+	})
+})
+
+var _ = Describe("InformerSource", func() {
+	var (
+		clientset *fake.Clientset
+		podWatch  *watch.FakeWatcher
+		source    *podsource.InformerSource
+		process   ifrit.Process
+	)
+
+	podLists := func() []core.ListAction {
+		lists := []core.ListAction{}
+		for _, action := range clientset.Actions() {
+			if action.Matches("list", "pods") {
+				lists = append(lists, action.(core.ListAction))
+			}
+		}
+		return lists
+	}
+
+	BeforeEach(func() {
+		podWatch = watch.NewFake()
+
+		clientset = fake.NewSimpleClientset()
+		clientset.PrependReactor("list", "pods", func(core.Action) (bool, runtime.Object, error) {
+			return true, &v1.PodList{Items: []v1.Pod{newPod("ns-1", "pod-a-1", "guid-a"), newPod("ns-1", "pod-b-1", "guid-b")}}, nil
+		})
+		clientset.PrependReactor("list", "replicationcontrollers", func(core.Action) (bool, runtime.Object, error) {
+			return true, &v1.ReplicationControllerList{Items: []v1.ReplicationController{newRC("ns-1", "rc-a", "guid-a")}}, nil
+		})
+		clientset.PrependReactor("list", "replicasets", func(core.Action) (bool, runtime.Object, error) {
+			return true, &v1beta1.ReplicaSetList{Items: []v1beta1.ReplicaSet{newRS("ns-1", "rs-a", "guid-a")}}, nil
+		})
+		clientset.PrependReactor("delete", "pods", func(core.Action) (bool, runtime.Object, error) {
+			return true, nil, nil
+		})
+		clientset.PrependWatchReactor("pods", core.DefaultWatchReactor(podWatch, nil))
+		clientset.PrependWatchReactor("replicationcontrollers", core.DefaultWatchReactor(watch.NewFake(), nil))
+		clientset.PrependWatchReactor("replicasets", core.DefaultWatchReactor(watch.NewFake(), nil))
+
+		source = podsource.NewInformerSource(clientset, clientset, time.Hour, lagertest.NewTestLogger("test"))
+		process = ifrit.Invoke(source)
+	})
+
+	AfterEach(func() {
+		process.Signal(nil)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	It("only caches the pods and controllers of processes", func() {
+		Expect(podLists()).NotTo(BeEmpty())
+		Expect(podLists()[0].GetListRestrictions().Labels.String()).To(Equal("cloudfoundry.org/process-guid"))
+
+		for _, action := range clientset.Actions() {
+			if action.Matches("list", "replicationcontrollers") || action.Matches("list", "replicasets") {
+				Expect(action.(core.ListAction).GetListRestrictions().Labels.String()).To(Equal("cloudfoundry.org/process-guid"))
+			}
+		}
+	})
+
+	It("serves the listed pods and controllers from its cache", func() {
+		pods, err := source.Pods(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names(pods)).To(Equal([]string{"ns-1/pod-a-1"}))
+
+		rcs, err := source.ReplicationControllers("ns-1", processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rcs).To(HaveLen(1))
+
+		rss, err := source.ReplicaSets("ns-1", processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rss).To(HaveLen(1))
+
+		listCalls := len(podLists())
+		_, err = source.Pods(api.NamespaceAll, processSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(podLists()).To(HaveLen(listCalls))
+	})
+
+	It("follows the changes it watches", func() {
+		pod := newPod("ns-2", "pod-a-2", "guid-a")
+		podWatch.Add(&pod)
+
+		Eventually(func() []string {
+			pods, _ := source.Pods(api.NamespaceAll, processSelector)
+			return names(pods)
+		}).Should(Equal([]string{"ns-1/pod-a-1", "ns-2/pod-a-2"}))
+	})
+
+	It("deletes pods through the API server", func() {
+		Expect(source.DeletePod(newPod("ns-1", "pod-a-1", "guid-a"))).To(Succeed())
+
+		deletes := 0
+		for _, action := range clientset.Actions() {
+			if action.Matches("delete", "pods") {
+				deletes++
+			}
+		}
+		Expect(deletes).To(Equal(1))
+	})
+})
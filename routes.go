@@ -6,10 +6,37 @@ const (
 	LRPStatus     = "LRPStatus"
 	LRPStats      = "LRPStats"
 	BulkLRPStatus = "BulkLRPStatus"
+	LRPEvents     = "LRPEvents"
+
+	LRPInstanceStatus  = "LRPInstanceStatus"
+	RestartLRPInstance = "RestartLRPInstance"
+
+	NamespaceLRPs = "NamespaceLRPs"
+	AllLRPs       = "AllLRPs"
+
+	AppSummary     = "AppSummary"
+	BulkAppSummary = "BulkAppSummary"
+
+	LRPStatusV2     = "LRPStatusV2"
+	LRPStatsV2      = "LRPStatsV2"
+	BulkLRPStatusV2 = "BulkLRPStatusV2"
 )
 
 var Routes = rata.Routes{
 	{Path: "/v1/bulk_actual_lrp_status", Method: "GET", Name: BulkLRPStatus},
 	{Path: "/v1/actual_lrps/:guid", Method: "GET", Name: LRPStatus},
 	{Path: "/v1/actual_lrps/:guid/stats", Method: "GET", Name: LRPStats},
+	{Path: "/v1/actual_lrps/:guid/events", Method: "GET", Name: LRPEvents},
+	{Path: "/v1/actual_lrps/:guid/instances/:index", Method: "GET", Name: LRPInstanceStatus},
+	{Path: "/v1/actual_lrps/:guid/instances/:index", Method: "DELETE", Name: RestartLRPInstance},
+
+	{Path: "/v1/namespaces/:ns/actual_lrps", Method: "GET", Name: NamespaceLRPs},
+	{Path: "/v1/actual_lrps", Method: "GET", Name: AllLRPs},
+
+	{Path: "/v1/apps/:guid/summary", Method: "GET", Name: AppSummary},
+	{Path: "/v1/bulk_app_summary", Method: "GET", Name: BulkAppSummary},
+
+	{Path: "/v2/bulk_actual_lrp_status", Method: "GET", Name: BulkLRPStatusV2},
+	{Path: "/v2/actual_lrps/:guid", Method: "GET", Name: LRPStatusV2},
+	{Path: "/v2/actual_lrps/:guid/stats", Method: "GET", Name: LRPStatsV2},
 }
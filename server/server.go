@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+	"github.com/tedsuo/ifrit"
+)
+
+type drainingServer struct {
+	address      string
+	handler      http.Handler
+	drainTimeout time.Duration
+	logger       lager.Logger
+}
+
+// New serves handler on address until signalled. It then stops accepting
+// connections and waits up to drainTimeout for the requests being served to
+// finish, closing the connections of any still running after it.
+func New(address string, handler http.Handler, drainTimeout time.Duration, logger lager.Logger) ifrit.Runner {
+	return &drainingServer{
+		address:      address,
+		handler:      handler,
+		drainTimeout: drainTimeout,
+		logger:       logger.Session("server"),
+	}
+}
+
+func (s *drainingServer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: s.handler}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	close(ready)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signals:
+	}
+
+	s.logger.Info("draining", lager.Data{"timeout": s.drainTimeout.String()})
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
+	defer cancel()
+
+	// closes the listener and idle connections, then waits for the active
+	// ones; responses finished meanwhile close their connection
+	err = server.Shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		s.logger.Info("drain-timed-out")
+		if err := server.Close(); err != nil {
+			s.logger.Error("closing-connections-failed", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("drained")
+	return nil
+}
@@ -0,0 +1,113 @@
+package server_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/server"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		address  string
+		release  chan struct{}
+		started  chan struct{}
+		process  ifrit.Process
+		timeout  time.Duration
+		logger   *lagertest.TestLogger
+		response chan string
+	)
+
+	get := func() {
+		go func() {
+			defer GinkgoRecover()
+			res, err := http.Get("http://" + address)
+			if err != nil {
+				response <- err.Error()
+				return
+			}
+			body, _ := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			response <- string(body)
+		}()
+	}
+
+	BeforeEach(func() {
+		address = fmt.Sprintf("127.0.0.1:%d", 8900+GinkgoParallelNode())
+		release = make(chan struct{})
+		started = make(chan struct{}, 1)
+		response = make(chan string, 1)
+		timeout = time.Second
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	JustBeforeEach(func() {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-release
+			w.Write([]byte("done"))
+		})
+		process = ifrit.Invoke(server.New(address, handler, timeout, logger))
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	It("finishes the requests in flight when signalled", func() {
+		get()
+		Eventually(started).Should(Receive())
+
+		process.Signal(os.Interrupt)
+		Consistently(process.Wait(), 100*time.Millisecond).ShouldNot(Receive())
+
+		close(release)
+		Eventually(response).Should(Receive(Equal("done")))
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+		Expect(logger.LogMessages()).To(ContainElement("test.server.drained"))
+	})
+
+	It("stops accepting connections while draining", func() {
+		get()
+		Eventually(started).Should(Receive())
+		process.Signal(os.Interrupt)
+
+		Eventually(func() error {
+			conn, err := net.Dial("tcp", address)
+			if err == nil {
+				conn.Close()
+			}
+			return err
+		}).Should(HaveOccurred())
+
+		close(release)
+	})
+
+	Context("when the requests take longer than the drain timeout", func() {
+		BeforeEach(func() {
+			timeout = 100 * time.Millisecond
+		})
+
+		It("stops waiting for them and closes their connections", func() {
+			get()
+			Eventually(started).Should(Receive())
+
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+			Expect(logger.LogMessages()).To(ContainElement("test.server.drain-timed-out"))
+			Eventually(response).Should(Receive(Not(Equal("done"))))
+
+			close(release)
+		})
+	})
+})
@@ -0,0 +1,153 @@
+package tc_client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/sonde-go/events"
+	"github.com/gogo/protobuf/proto"
+	"github.com/pivotal-golang/lager"
+)
+
+const containerMetricsPath = "/apps/%s/containermetrics"
+
+// TcClient fetches container metrics from the traffic controller, as the
+// noaa consumer does, but with a timeout on the request itself, so a slow
+// traffic controller does not leave requests behind after the API has given
+// up on them
+type TcClient struct {
+	baseURL    string
+	httpClient *http.Client
+	logger     lager.Logger
+}
+
+type BadResponseError struct {
+	StatusCode int
+}
+
+func (b *BadResponseError) Error() string {
+	return fmt.Sprintf("container metrics GET failed with %d", b.StatusCode)
+}
+
+// PartialResultError comes with the metrics that could be decoded when some
+// parts of the response could not be; Err is the first decoding failure
+type PartialResultError struct {
+	Failed int
+	Err    error
+}
+
+func (p *PartialResultError) Error() string {
+	return fmt.Sprintf("%d container metrics envelopes could not be decoded: %s", p.Failed, p.Err)
+}
+
+// NewTcClient builds a client for the traffic controller at trafficControllerURL,
+// whose ws or wss scheme is taken as http or https. Requests taking longer
+// than timeout are cancelled, or never when it is zero.
+func NewTcClient(trafficControllerURL string, skipCertVerify bool, timeout time.Duration, logger lager.Logger) *TcClient {
+	return &TcClient{
+		logger:  logger.Session("tc-client"),
+		baseURL: httpURL(trafficControllerURL),
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				Proxy: http.ProxyFromEnvironment,
+				Dial: (&net.Dialer{
+					Timeout:   10 * time.Second,
+					KeepAlive: 30 * time.Second,
+				}).Dial,
+				TLSHandshakeTimeout: 10 * time.Second,
+				TLSClientConfig: &tls.Config{
+					InsecureSkipVerify: skipCertVerify,
+					MinVersion:         tls.VersionTLS10,
+				},
+			},
+		},
+	}
+}
+
+// ContainerMetrics returns the metrics of every instance of the app. Parts
+// of the response that cannot be decoded are logged and skipped, and the
+// metrics of the others are returned with a *PartialResultError.
+func (tc *TcClient) ContainerMetrics(appGuid string, authToken string) ([]*events.ContainerMetric, error) {
+	request, err := http.NewRequest("GET", tc.baseURL+fmt.Sprintf(containerMetricsPath, url.QueryEscape(appGuid)), nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", authToken)
+
+	response, err := tc.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, &BadResponseError{response.StatusCode}
+	}
+
+	_, params, err := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := []*events.ContainerMetric{}
+	var partial *PartialResultError
+	reader := multipart.NewReader(response.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		envelope := &events.Envelope{}
+		if err := proto.Unmarshal(data, envelope); err != nil {
+			tc.logger.Error("decoding-envelope-failed", err, lager.Data{"app-guid": appGuid})
+			if partial == nil {
+				partial = &PartialResultError{Err: err}
+			}
+			partial.Failed++
+			continue
+		}
+		if envelope.GetEventType() == events.Envelope_ContainerMetric {
+			metrics = append(metrics, envelope.GetContainerMetric())
+		}
+	}
+
+	if partial != nil {
+		return metrics, partial
+	}
+	return metrics, nil
+}
+
+// the client keeps no connection open between requests
+func (tc *TcClient) Close() error {
+	return nil
+}
+
+func httpURL(trafficControllerURL string) string {
+	trafficControllerURL = strings.TrimRight(trafficControllerURL, "/")
+	switch {
+	case strings.HasPrefix(trafficControllerURL, "wss://"):
+		return "https://" + strings.TrimPrefix(trafficControllerURL, "wss://")
+	case strings.HasPrefix(trafficControllerURL, "ws://"):
+		return "http://" + strings.TrimPrefix(trafficControllerURL, "ws://")
+	}
+	return trafficControllerURL
+}
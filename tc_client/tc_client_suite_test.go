@@ -0,0 +1,13 @@
+package tc_client_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTcClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TcClient Suite")
+}
@@ -0,0 +1,165 @@
+package tc_client_test
+
+import (
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/tc_client"
+	"github.com/cloudfoundry/sonde-go/events"
+	"github.com/gogo/protobuf/proto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("TC Client", func() {
+	var (
+		fakeTC   *ghttp.Server
+		tcClient *tc_client.TcClient
+		logger   *lagertest.TestLogger
+	)
+
+	metric := &events.ContainerMetric{
+		ApplicationId: proto.String("log-guid"),
+		InstanceIndex: proto.Int32(1),
+		CpuPercentage: proto.Float64(50),
+		MemoryBytes:   proto.Uint64(1024),
+		DiskBytes:     proto.Uint64(2048),
+	}
+
+	respondWithEnvelopes := func(envelopes ...*events.Envelope) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			writer := multipart.NewWriter(w)
+			w.Header().Set("Content-Type", "multipart/x-protobuf; boundary="+writer.Boundary())
+			w.WriteHeader(http.StatusOK)
+
+			for _, envelope := range envelopes {
+				data, err := proto.Marshal(envelope)
+				Expect(err).NotTo(HaveOccurred())
+				part, err := writer.CreatePart(nil)
+				Expect(err).NotTo(HaveOccurred())
+				part.Write(data)
+			}
+			writer.Close()
+		}
+	}
+
+	BeforeEach(func() {
+		fakeTC = ghttp.NewServer()
+		logger = lagertest.NewTestLogger("test")
+		tcClient = tc_client.NewTcClient(fakeTC.URL(), true, 100*time.Millisecond, logger)
+	})
+
+	AfterEach(func() {
+		fakeTC.Close()
+	})
+
+	It("returns the container metrics of the app", func() {
+		fakeTC.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/apps/log-guid/containermetrics"),
+			ghttp.VerifyHeaderKV("Authorization", "bearer token"),
+			respondWithEnvelopes(
+				&events.Envelope{
+					Origin:          proto.String("test"),
+					EventType:       events.Envelope_ContainerMetric.Enum(),
+					ContainerMetric: metric,
+				},
+				&events.Envelope{
+					Origin:    proto.String("test"),
+					EventType: events.Envelope_LogMessage.Enum(),
+				},
+			),
+		))
+
+		metrics, err := tcClient.ContainerMetrics("log-guid", "bearer token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics).To(HaveLen(1))
+		Expect(metrics[0].GetInstanceIndex()).To(BeEquivalentTo(1))
+		Expect(metrics[0].GetMemoryBytes()).To(BeEquivalentTo(1024))
+	})
+
+	It("talks http to a traffic controller given by its websocket url", func() {
+		fakeTC.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/apps/log-guid/containermetrics"),
+			respondWithEnvelopes(),
+		))
+
+		tcClient = tc_client.NewTcClient(strings.Replace(fakeTC.URL(), "http://", "ws://", 1), true, time.Second, logger)
+		metrics, err := tcClient.ContainerMetrics("log-guid", "bearer token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics).To(BeEmpty())
+	})
+
+	Context("when some parts cannot be decoded", func() {
+		BeforeEach(func() {
+			fakeTC.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				writer := multipart.NewWriter(w)
+				w.Header().Set("Content-Type", "multipart/x-protobuf; boundary="+writer.Boundary())
+				w.WriteHeader(http.StatusOK)
+
+				data, err := proto.Marshal(&events.Envelope{
+					Origin:          proto.String("test"),
+					EventType:       events.Envelope_ContainerMetric.Enum(),
+					ContainerMetric: metric,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				part, err := writer.CreatePart(nil)
+				Expect(err).NotTo(HaveOccurred())
+				part.Write(data)
+
+				part, err = writer.CreatePart(nil)
+				Expect(err).NotTo(HaveOccurred())
+				part.Write([]byte("not a protobuf"))
+				writer.Close()
+			})
+		})
+
+		It("returns the metrics it could decode with a partial result error", func() {
+			metrics, err := tcClient.ContainerMetrics("log-guid", "bearer token")
+			Expect(metrics).To(HaveLen(1))
+			Expect(err).To(BeAssignableToTypeOf(&tc_client.PartialResultError{}))
+			Expect(err.(*tc_client.PartialResultError).Failed).To(Equal(1))
+		})
+
+		It("logs the failure", func() {
+			tcClient.ContainerMetrics("log-guid", "bearer token")
+			Expect(logger).To(gbytes.Say("decoding-envelope-failed"))
+		})
+	})
+
+	It("fails on a response other than 200", func() {
+		fakeTC.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, nil))
+
+		_, err := tcClient.ContainerMetrics("log-guid", "bearer token")
+		Expect(err).To(Equal(&tc_client.BadResponseError{StatusCode: http.StatusUnauthorized}))
+	})
+
+	Context("when the traffic controller is slower than the timeout", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			fakeTC.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+			})
+		})
+
+		AfterEach(func() {
+			close(release)
+		})
+
+		It("cancels the request", func() {
+			errs := make(chan error, 1)
+			go func() {
+				_, err := tcClient.ContainerMetrics("log-guid", "bearer token")
+				errs <- err
+			}()
+
+			Eventually(errs, time.Second).Should(Receive(HaveOccurred()))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/tps/topology"
+)
+
+type FakeNodeLister struct {
+	PlacementStub        func(nodeName string) (topology.Placement, bool)
+	placementMutex       sync.RWMutex
+	placementArgsForCall []struct {
+		nodeName string
+	}
+	placementReturns struct {
+		result1 topology.Placement
+		result2 bool
+	}
+}
+
+func (fake *FakeNodeLister) Placement(nodeName string) (topology.Placement, bool) {
+	fake.placementMutex.Lock()
+	fake.placementArgsForCall = append(fake.placementArgsForCall, struct {
+		nodeName string
+	}{nodeName})
+	fake.placementMutex.Unlock()
+	if fake.PlacementStub != nil {
+		return fake.PlacementStub(nodeName)
+	} else {
+		return fake.placementReturns.result1, fake.placementReturns.result2
+	}
+}
+
+func (fake *FakeNodeLister) PlacementCallCount() int {
+	fake.placementMutex.RLock()
+	defer fake.placementMutex.RUnlock()
+	return len(fake.placementArgsForCall)
+}
+
+func (fake *FakeNodeLister) PlacementArgsForCall(i int) string {
+	fake.placementMutex.RLock()
+	defer fake.placementMutex.RUnlock()
+	return fake.placementArgsForCall[i].nodeName
+}
+
+func (fake *FakeNodeLister) PlacementReturns(result1 topology.Placement, result2 bool) {
+	fake.PlacementStub = nil
+	fake.placementReturns = struct {
+		result1 topology.Placement
+		result2 bool
+	}{result1, result2}
+}
+
+var _ topology.NodeLister = new(FakeNodeLister)
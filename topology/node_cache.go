@@ -0,0 +1,84 @@
+package topology
+
+import (
+	"os"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+	"k8s.io/kubernetes/pkg/api"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/client/cache"
+	v1core "k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/typed/core/v1"
+	"k8s.io/kubernetes/pkg/controller/framework"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+)
+
+// NodeCache is a NodeLister kept up to date by watching the nodes of the
+// cluster, so that serving a request does not cost a call per node. It is
+// an ifrit runner and is ready at once: until the nodes are synced, or when
+// they cannot be listed at all, instances are reported without a zone.
+type NodeCache struct {
+	store      cache.Store
+	controller *framework.Controller
+	logger     lager.Logger
+}
+
+func NewNodeCache(k8sClient v1core.CoreInterface, resyncPeriod time.Duration, logger lager.Logger) *NodeCache {
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options api.ListOptions) (runtime.Object, error) {
+			return k8sClient.Nodes().List(options)
+		},
+		WatchFunc: func(options api.ListOptions) (watch.Interface, error) {
+			return k8sClient.Nodes().Watch(options)
+		},
+	}
+
+	store, controller := framework.NewInformer(listWatch, &v1.Node{}, resyncPeriod, framework.ResourceEventHandlerFuncs{})
+
+	return &NodeCache{
+		store:      store,
+		controller: controller,
+		logger:     logger,
+	}
+}
+
+func (c *NodeCache) Placement(nodeName string) (Placement, bool) {
+	obj, exists, err := c.store.GetByKey(nodeName)
+	if err != nil || !exists {
+		return Placement{}, false
+	}
+
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return Placement{}, false
+	}
+
+	return PlacementOf(*node), true
+}
+
+func (c *NodeCache) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := c.logger.Session("node-cache")
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	stop := make(chan struct{})
+	go c.controller.Run(stop)
+
+	close(ready)
+	logger.Info("started")
+
+	for !c.controller.HasSynced() {
+		select {
+		case <-signals:
+			close(stop)
+			return nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	logger.Info("synced")
+
+	<-signals
+	close(stop)
+	return nil
+}
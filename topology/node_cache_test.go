@@ -0,0 +1,74 @@
+package topology_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/topology"
+	"github.com/pivotal-golang/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+	"k8s.io/kubernetes/pkg/api/v1"
+	"k8s.io/kubernetes/pkg/client/clientset_generated/release_1_3/fake"
+	"k8s.io/kubernetes/pkg/client/testing/core"
+	"k8s.io/kubernetes/pkg/runtime"
+	"k8s.io/kubernetes/pkg/watch"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NodeCache", func() {
+	var (
+		clientset *fake.Clientset
+		nodeCache *topology.NodeCache
+		process   ifrit.Process
+	)
+
+	BeforeEach(func() {
+		clientset = fake.NewSimpleClientset()
+		clientset.PrependWatchReactor("nodes", core.DefaultWatchReactor(watch.NewFake(), nil))
+		nodeCache = topology.NewNodeCache(clientset.Core(), time.Hour, lagertest.NewTestLogger("test"))
+	})
+
+	AfterEach(func() {
+		process.Signal(nil)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Context("when the nodes can be listed", func() {
+		BeforeEach(func() {
+			clientset.PrependReactor("list", "nodes", func(core.Action) (bool, runtime.Object, error) {
+				return true, &v1.NodeList{Items: []v1.Node{{
+					ObjectMeta: v1.ObjectMeta{
+						Name:   "node-1",
+						Labels: map[string]string{topology.ZoneLabel: "us-east-1a"},
+					},
+				}}}, nil
+			})
+			process = ifrit.Invoke(nodeCache)
+		})
+
+		It("serves their placement once synced", func() {
+			Eventually(func() topology.Placement {
+				placement, _ := nodeCache.Placement("node-1")
+				return placement
+			}).Should(Equal(topology.Placement{Zone: "us-east-1a"}))
+		})
+	})
+
+	Context("when the nodes cannot be listed", func() {
+		BeforeEach(func() {
+			clientset.PrependReactor("list", "nodes", func(core.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("nodes is forbidden")
+			})
+			process = ifrit.Invoke(nodeCache)
+		})
+
+		It("is ready anyway and knows no nodes", func() {
+			Consistently(process.Wait()).ShouldNot(Receive())
+
+			_, found := nodeCache.Placement("node-1")
+			Expect(found).To(BeFalse())
+		})
+	})
+})
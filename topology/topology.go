@@ -0,0 +1,79 @@
+package topology
+
+import (
+	"github.com/cloudfoundry-incubator/tps"
+	"k8s.io/kubernetes/pkg/api/v1"
+)
+
+// the beta labels are set by kubernetes up to 1.16, the GA ones from 1.17
+const (
+	ZoneLabel       = "topology.kubernetes.io/zone"
+	RegionLabel     = "topology.kubernetes.io/region"
+	BetaZoneLabel   = "failure-domain.beta.kubernetes.io/zone"
+	BetaRegionLabel = "failure-domain.beta.kubernetes.io/region"
+)
+
+type Placement struct {
+	Zone   string
+	Region string
+}
+
+//go:generate counterfeiter -o fakes/fake_node_lister.go . NodeLister
+type NodeLister interface {
+	// the placement of the named node; false when the node is not known
+	Placement(nodeName string) (Placement, bool)
+}
+
+func PlacementOf(node v1.Node) Placement {
+	return Placement{
+		Zone:   label(node, ZoneLabel, BetaZoneLabel),
+		Region: label(node, RegionLabel, BetaRegionLabel),
+	}
+}
+
+func label(node v1.Node, names ...string) string {
+	for _, name := range names {
+		if value := node.ObjectMeta.Labels[name]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// sets the zone and region of each instance from the node its pod runs on,
+// looked up in the cluster the pod was found in, and returns how many of
+// the current instances run in each zone
+func Place(instances []tps.LRPInstance, nodes NodeListers) map[string]int {
+	zones := map[string]int{}
+	for i := range instances {
+		if instances[i].NodeName == "" {
+			continue
+		}
+
+		placement, ok := nodes.Placement(instances[i].Cluster, instances[i].NodeName)
+		if !ok {
+			continue
+		}
+
+		instances[i].Zone = placement.Zone
+		instances[i].Region = placement.Region
+		if instances[i].Current && placement.Zone != "" {
+			zones[placement.Zone]++
+		}
+	}
+	return zones
+}
+
+// NodeListers holds the node lister of each cluster by cluster name; the
+// single cluster given by the kubernetes flags is unnamed. Node names are
+// only unique within a cluster, so a node is only looked up in the cluster
+// its pod was found in.
+type NodeListers map[string]NodeLister
+
+func (listers NodeListers) Placement(cluster, nodeName string) (Placement, bool) {
+	lister, found := listers[cluster]
+	if !found {
+		return Placement{}, false
+	}
+	return lister.Placement(nodeName)
+}
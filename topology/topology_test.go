@@ -0,0 +1,126 @@
+package topology_test
+
+import (
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps"
+	"github.com/cloudfoundry-incubator/tps/topology"
+	"github.com/cloudfoundry-incubator/tps/topology/fakes"
+	"k8s.io/kubernetes/pkg/api/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Topology", func() {
+	Describe("PlacementOf", func() {
+		It("reads the zone and region labels", func() {
+			node := v1.Node{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{
+				topology.ZoneLabel:   "us-east-1a",
+				topology.RegionLabel: "us-east-1",
+			}}}
+
+			Expect(topology.PlacementOf(node)).To(Equal(topology.Placement{Zone: "us-east-1a", Region: "us-east-1"}))
+		})
+
+		It("falls back to the beta failure-domain labels", func() {
+			node := v1.Node{ObjectMeta: v1.ObjectMeta{Labels: map[string]string{
+				topology.BetaZoneLabel:   "us-east-1b",
+				topology.BetaRegionLabel: "us-east-1",
+			}}}
+
+			Expect(topology.PlacementOf(node)).To(Equal(topology.Placement{Zone: "us-east-1b", Region: "us-east-1"}))
+		})
+
+		It("leaves the placement empty for unlabelled nodes", func() {
+			Expect(topology.PlacementOf(v1.Node{})).To(Equal(topology.Placement{}))
+		})
+	})
+
+	Describe("NodeListers", func() {
+		It("looks the node up in the lister of its cluster", func() {
+			east := &fakes.FakeNodeLister{}
+			west := &fakes.FakeNodeLister{}
+			west.PlacementReturns(topology.Placement{Zone: "us-west-1a"}, true)
+
+			placement, ok := topology.NodeListers{"east": east, "west": west}.Placement("west", "node-1")
+			Expect(ok).To(BeTrue())
+			Expect(placement.Zone).To(Equal("us-west-1a"))
+			Expect(west.PlacementArgsForCall(0)).To(Equal("node-1"))
+			Expect(east.PlacementCallCount()).To(BeZero())
+		})
+
+		It("does not take a node of the same name in another cluster", func() {
+			east := &fakes.FakeNodeLister{}
+			east.PlacementReturns(topology.Placement{Zone: "us-east-1a"}, true)
+
+			_, ok := topology.NodeListers{"east": east, "west": &fakes.FakeNodeLister{}}.Placement("west", "node-1")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("reports nodes of unknown clusters as unknown", func() {
+			_, ok := topology.NodeListers{"east": &fakes.FakeNodeLister{}}.Placement("north", "node-1")
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Describe("Place", func() {
+		var (
+			nodeLister *fakes.FakeNodeLister
+			instances  []tps.LRPInstance
+			zones      map[string]int
+		)
+
+		BeforeEach(func() {
+			nodeLister = &fakes.FakeNodeLister{}
+			nodeLister.PlacementStub = func(nodeName string) (topology.Placement, bool) {
+				switch nodeName {
+				case "node-a":
+					return topology.Placement{Zone: "zone-a", Region: "region"}, true
+				case "node-b":
+					return topology.Placement{Zone: "zone-b", Region: "region"}, true
+				default:
+					return topology.Placement{}, false
+				}
+			}
+
+			instances = []tps.LRPInstance{
+				{NodeName: "node-a", Current: true},
+				{NodeName: "node-a", Current: true},
+				{NodeName: "node-b", Current: true},
+				{NodeName: "node-b"},
+				{NodeName: "gone", Current: true},
+				{LRPInstance: cc_messages.LRPInstance{State: cc_messages.LRPInstanceStateDown}, Current: true},
+			}
+		})
+
+		JustBeforeEach(func() {
+			zones = topology.Place(instances, topology.NodeListers{"": nodeLister})
+		})
+
+		It("sets the zone and region of the instances on known nodes", func() {
+			Expect(instances[0].Zone).To(Equal("zone-a"))
+			Expect(instances[0].Region).To(Equal("region"))
+			Expect(instances[2].Zone).To(Equal("zone-b"))
+			Expect(instances[4].Zone).To(BeEmpty())
+		})
+
+		It("counts the current instances per zone", func() {
+			Expect(zones).To(Equal(map[string]int{"zone-a": 2, "zone-b": 1}))
+		})
+
+		It("does not look up instances without a node", func() {
+			Expect(nodeLister.PlacementCallCount()).To(Equal(5))
+		})
+
+		Context("when an instance is from a cluster without a node lister", func() {
+			BeforeEach(func() {
+				instances[0].Cluster = "elsewhere"
+			})
+
+			It("leaves its zone empty", func() {
+				Expect(instances[0].Zone).To(BeEmpty())
+				Expect(zones).To(Equal(map[string]int{"zone-a": 1, "zone-b": 1}))
+			})
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package tps_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTps(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tps Suite")
+}
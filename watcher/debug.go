@@ -0,0 +1,35 @@
+package watcher
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// the path the queue view is served on by the debug server
+const QueueDebugPath = "/debug/queue"
+
+// QueueView is what the debug server shows of the crashes waiting to be
+// posted
+type QueueView struct {
+	Overflow OverflowPolicy `json:"overflow"`
+	Stats    QueueStats     `json:"stats"`
+	// the crashes whose post has not started yet, in the order they were
+	// received in
+	Pending []PendingCrash `json:"pending"`
+}
+
+func (watcher *Watcher) QueueView() QueueView {
+	return QueueView{
+		Overflow: watcher.overflow,
+		Stats:    watcher.QueueStats(),
+		Pending:  watcher.Pending(),
+	}
+}
+
+// NewQueueHandler serves the queue view of watcher as JSON
+func NewQueueHandler(watcher *Watcher) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(watcher.QueueView())
+	})
+}
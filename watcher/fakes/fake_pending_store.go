@@ -0,0 +1,85 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/tps/watcher"
+)
+
+type FakePendingStore struct {
+	SaveStub        func(crashes []watcher.PendingCrash) error
+	saveMutex       sync.RWMutex
+	saveArgsForCall []struct {
+		crashes []watcher.PendingCrash
+	}
+	saveReturns struct {
+		result1 error
+	}
+	TakeStub        func() ([]watcher.PendingCrash, error)
+	takeMutex       sync.RWMutex
+	takeArgsForCall []struct{}
+	takeReturns     struct {
+		result1 []watcher.PendingCrash
+		result2 error
+	}
+}
+
+func (fake *FakePendingStore) Save(crashes []watcher.PendingCrash) error {
+	fake.saveMutex.Lock()
+	fake.saveArgsForCall = append(fake.saveArgsForCall, struct {
+		crashes []watcher.PendingCrash
+	}{crashes})
+	fake.saveMutex.Unlock()
+	if fake.SaveStub != nil {
+		return fake.SaveStub(crashes)
+	} else {
+		return fake.saveReturns.result1
+	}
+}
+
+func (fake *FakePendingStore) SaveCallCount() int {
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return len(fake.saveArgsForCall)
+}
+
+func (fake *FakePendingStore) SaveArgsForCall(i int) []watcher.PendingCrash {
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return fake.saveArgsForCall[i].crashes
+}
+
+func (fake *FakePendingStore) SaveReturns(result1 error) {
+	fake.SaveStub = nil
+	fake.saveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePendingStore) Take() ([]watcher.PendingCrash, error) {
+	fake.takeMutex.Lock()
+	fake.takeArgsForCall = append(fake.takeArgsForCall, struct{}{})
+	fake.takeMutex.Unlock()
+	if fake.TakeStub != nil {
+		return fake.TakeStub()
+	} else {
+		return fake.takeReturns.result1, fake.takeReturns.result2
+	}
+}
+
+func (fake *FakePendingStore) TakeCallCount() int {
+	fake.takeMutex.RLock()
+	defer fake.takeMutex.RUnlock()
+	return len(fake.takeArgsForCall)
+}
+
+func (fake *FakePendingStore) TakeReturns(result1 []watcher.PendingCrash, result2 error) {
+	fake.TakeStub = nil
+	fake.takeReturns = struct {
+		result1 []watcher.PendingCrash
+		result2 error
+	}{result1, result2}
+}
+
+var _ watcher.PendingStore = new(FakePendingStore)
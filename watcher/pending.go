@@ -0,0 +1,90 @@
+package watcher
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/hashicorp/consul/api"
+)
+
+// the consul key the crashes a watcher could not post before it stopped are
+// saved under, for the next watcher to post
+const PendingCrashesKey = "v1/tps_watcher/pending_crashes"
+
+type PendingCrash struct {
+	ProcessGuid string                        `json:"process_guid"`
+	Request     cc_messages.AppCrashedRequest `json:"request"`
+}
+
+//go:generate counterfeiter -o fakes/fake_pending_store.go . PendingStore
+
+// PendingStore keeps the crashes a stopping watcher did not get to post
+type PendingStore interface {
+	// adds crashes to those already saved
+	Save(crashes []PendingCrash) error
+	// returns the saved crashes and forgets them
+	Take() ([]PendingCrash, error)
+}
+
+// the part of the consul KV API the pending store uses
+type KV interface {
+	Get(key string, q *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error)
+	Put(p *api.KVPair, q *api.WriteOptions) (*api.WriteMeta, error)
+}
+
+type consulPendingStore struct {
+	kv KV
+}
+
+func NewConsulPendingStore(kv KV) PendingStore {
+	return &consulPendingStore{kv: kv}
+}
+
+// only the watcher holding the lock writes the key, so reading and writing
+// it separately does not race with another watcher
+func (s *consulPendingStore) Save(crashes []PendingCrash) error {
+	saved, err := s.load()
+	if err != nil {
+		return err
+	}
+	return s.store(append(saved, crashes...))
+}
+
+func (s *consulPendingStore) Take() ([]PendingCrash, error) {
+	saved, err := s.load()
+	if err != nil || len(saved) == 0 {
+		return saved, err
+	}
+	return saved, s.store(nil)
+}
+
+func (s *consulPendingStore) load() ([]PendingCrash, error) {
+	pair, _, err := s.kv.Get(PendingCrashesKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil || len(pair.Value) == 0 {
+		return nil, nil
+	}
+
+	var crashes []PendingCrash
+	err = json.Unmarshal(pair.Value, &crashes)
+	if err != nil {
+		return nil, err
+	}
+	return crashes, nil
+}
+
+func (s *consulPendingStore) store(crashes []PendingCrash) error {
+	value := []byte{}
+	if len(crashes) > 0 {
+		var err error
+		value, err = json.Marshal(crashes)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.kv.Put(&api.KVPair{Key: PendingCrashesKey, Value: value}, nil)
+	return err
+}
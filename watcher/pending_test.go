@@ -0,0 +1,88 @@
+package watcher_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/tps/watcher"
+	"github.com/hashicorp/consul/api"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type memoryKV struct {
+	pairs  map[string]*api.KVPair
+	getErr error
+}
+
+func (kv *memoryKV) Get(key string, q *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error) {
+	if kv.getErr != nil {
+		return nil, nil, kv.getErr
+	}
+	return kv.pairs[key], nil, nil
+}
+
+func (kv *memoryKV) Put(p *api.KVPair, q *api.WriteOptions) (*api.WriteMeta, error) {
+	kv.pairs[p.Key] = p
+	return nil, nil
+}
+
+var _ = Describe("ConsulPendingStore", func() {
+	var (
+		kv    *memoryKV
+		store watcher.PendingStore
+	)
+
+	crash := func(index int) watcher.PendingCrash {
+		return watcher.PendingCrash{
+			ProcessGuid: "process-guid",
+			Request:     cc_messages.AppCrashedRequest{Instance: "instance-guid", Index: index, Reason: "CRASHED"},
+		}
+	}
+
+	BeforeEach(func() {
+		kv = &memoryKV{pairs: map[string]*api.KVPair{}}
+		store = watcher.NewConsulPendingStore(kv)
+	})
+
+	It("takes nothing when nothing was saved", func() {
+		crashes, err := store.Take()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(BeEmpty())
+	})
+
+	It("adds saved crashes to those already saved", func() {
+		Expect(store.Save([]watcher.PendingCrash{crash(0)})).To(Succeed())
+		Expect(store.Save([]watcher.PendingCrash{crash(1)})).To(Succeed())
+
+		Expect(kv.pairs).To(HaveKey(watcher.PendingCrashesKey))
+
+		crashes, err := store.Take()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(Equal([]watcher.PendingCrash{crash(0), crash(1)}))
+	})
+
+	It("forgets the crashes it takes", func() {
+		Expect(store.Save([]watcher.PendingCrash{crash(0)})).To(Succeed())
+
+		_, err := store.Take()
+		Expect(err).NotTo(HaveOccurred())
+
+		crashes, err := store.Take()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(crashes).To(BeEmpty())
+	})
+
+	Context("when consul fails", func() {
+		BeforeEach(func() {
+			kv.getErr = errors.New("consul down")
+		})
+
+		It("returns the error", func() {
+			_, err := store.Take()
+			Expect(err).To(MatchError("consul down"))
+			Expect(store.Save([]watcher.PendingCrash{crash(0)})).To(MatchError("consul down"))
+		})
+	})
+})
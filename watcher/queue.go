@@ -0,0 +1,382 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrInvalidWorkers = errors.New("workers must be positive")
+
+// OverflowPolicy decides what a full KeyedQueue does with more work
+type OverflowPolicy string
+
+const (
+	// wait for room, which holds up whoever submits the work
+	OverflowBlock OverflowPolicy = "block"
+	// discard the work that has waited longest
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// replace the newest work waiting under the same merge key, and wait for
+	// room when there is none
+	OverflowMerge OverflowPolicy = "merge"
+)
+
+func ParseOverflowPolicy(policy string) (OverflowPolicy, error) {
+	switch OverflowPolicy(policy) {
+	case OverflowBlock, OverflowDropOldest, OverflowMerge:
+		return OverflowPolicy(policy), nil
+	}
+	return "", fmt.Errorf("unknown overflow policy %q, must be one of %s, %s or %s",
+		policy, OverflowBlock, OverflowDropOldest, OverflowMerge)
+}
+
+// QueueStats is a snapshot of the work waiting in a KeyedQueue
+type QueueStats struct {
+	// work submitted and not yet started
+	Depth int `json:"depth"`
+	// how much work can wait before the overflow policy applies; zero when
+	// unbounded
+	Capacity int `json:"capacity"`
+	// keys with work waiting or running
+	Keys int `json:"keys"`
+	// the most work waiting under one key
+	MaxKeyDepth int `json:"max_key_depth"`
+	// work running
+	Busy int `json:"busy"`
+	// submitters waiting for room
+	Blocked int `json:"blocked"`
+	// work discarded by the drop-oldest and merge policies since the start
+	Dropped int `json:"dropped"`
+	Merged  int `json:"merged"`
+}
+
+type queuedWork struct {
+	seq      uint64
+	mergeKey string
+	run      func()
+	discard  func()
+}
+
+// KeyedQueue runs the work submitted under one key one at a time, in the
+// order it was submitted, while work under different keys runs in parallel
+// on a bounded number of workers. Keys take turns, so one busy key does not
+// hold up the others.
+type KeyedQueue struct {
+	lock     sync.Mutex
+	cond     *sync.Cond
+	room     *sync.Cond
+	workers  int
+	capacity int
+	policy   OverflowPolicy
+	running  int
+	busy     int
+	depth    int
+	blocked  int
+	dropped  int
+	merged   int
+	nextSeq  uint64
+	stopped  bool
+
+	// the waiting work of each key; a key is present while it has work
+	// waiting or running
+	queues map[string][]queuedWork
+	// keys with work waiting and none running, in turn order
+	ready []string
+}
+
+// NewKeyedQueue builds a queue that holds up to capacity pieces of waiting
+// work, or any amount when capacity is zero
+func NewKeyedQueue(workers, capacity int, policy OverflowPolicy) (*KeyedQueue, error) {
+	if workers <= 0 {
+		return nil, ErrInvalidWorkers
+	}
+	if capacity < 0 {
+		return nil, errors.New("capacity must not be negative")
+	}
+	if _, err := ParseOverflowPolicy(string(policy)); err != nil {
+		return nil, err
+	}
+
+	queue := &KeyedQueue{
+		workers:  workers,
+		capacity: capacity,
+		policy:   policy,
+		queues:   map[string][]queuedWork{},
+	}
+	queue.cond = sync.NewCond(&queue.lock)
+	queue.room = sync.NewCond(&queue.lock)
+	queue.startWorkers()
+	return queue, nil
+}
+
+// Submit queues work behind the work already submitted under key. When the
+// queue is full it applies the overflow policy; discard is called instead of
+// work for work the policy drops or replaces, or that is still waiting when
+// the queue stops. The key is also the merge key.
+func (q *KeyedQueue) Submit(key string, work func(), discard func()) {
+	q.SubmitOrAbort(key, key, work, discard, nil)
+}
+
+// SubmitOrAbort is Submit with a merge key of its own, which may be shared
+// by several keys: merging replaces the newest work waiting under any of
+// them, while the work of each key still runs in order. It gives up waiting
+// for room once abort is closed, and then returns false without calling
+// work or discard, leaving the caller to deal with the work.
+func (q *KeyedQueue) SubmitOrAbort(key, mergeKey string, work func(), discard func(), abort <-chan struct{}) bool {
+	q.lock.Lock()
+
+	var wake chan struct{}
+	defer func() {
+		if wake != nil {
+			close(wake)
+		}
+	}()
+
+	discarded := []func(){}
+	for q.full() && !q.stopped && !closed(abort) {
+		if q.policy == OverflowMerge {
+			if mergedKey, i, found := q.newestWaiting(mergeKey); found {
+				// the key keeps its place in turn when work is appended to
+				// it right after
+				discarded = append(discarded, q.remove(mergedKey, i, mergedKey == key))
+				q.merged++
+				continue
+			}
+		}
+
+		if q.policy == OverflowDropOldest {
+			discarded = append(discarded, q.dropOldest())
+			q.dropped++
+			continue
+		}
+
+		if wake == nil && abort != nil {
+			// the waiting below only ends on a broadcast
+			wake = make(chan struct{})
+			go func(wake <-chan struct{}) {
+				select {
+				case <-abort:
+					q.lock.Lock()
+					q.room.Broadcast()
+					q.lock.Unlock()
+				case <-wake:
+				}
+			}(wake)
+		}
+
+		q.blocked++
+		q.room.Wait()
+		q.blocked--
+	}
+
+	if q.stopped {
+		q.lock.Unlock()
+		q.discardAll(append(discarded, discard))
+		return true
+	}
+
+	if q.full() {
+		q.lock.Unlock()
+		q.discardAll(discarded)
+		return false
+	}
+
+	waiting, found := q.queues[key]
+	q.queues[key] = append(waiting, queuedWork{seq: q.nextSeq, mergeKey: mergeKey, run: work, discard: discard})
+	q.nextSeq++
+	q.depth++
+	if !found {
+		q.ready = append(q.ready, key)
+		q.cond.Signal()
+	}
+	q.lock.Unlock()
+
+	q.discardAll(discarded)
+	return true
+}
+
+// SetWorkers changes how many workers run work. Extra workers stop once
+// the work they are running is done.
+func (q *KeyedQueue) SetWorkers(workers int) error {
+	if workers <= 0 {
+		return ErrInvalidWorkers
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.workers = workers
+	q.startWorkers()
+	q.cond.Broadcast()
+	return nil
+}
+
+// Stop stops the workers once the work they are running is done. The work
+// still waiting, and any submitted later, is discarded.
+func (q *KeyedQueue) Stop() {
+	q.lock.Lock()
+	q.stopped = true
+	discarded := []func(){}
+	for _, waiting := range q.queues {
+		for _, work := range waiting {
+			discarded = append(discarded, work.discard)
+		}
+	}
+	q.queues = map[string][]queuedWork{}
+	q.ready = nil
+	q.depth = 0
+	q.cond.Broadcast()
+	q.room.Broadcast()
+	q.lock.Unlock()
+
+	q.discardAll(discarded)
+}
+
+func (q *KeyedQueue) Stats() QueueStats {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	stats := QueueStats{
+		Depth:    q.depth,
+		Capacity: q.capacity,
+		Keys:     len(q.queues),
+		Busy:     q.busy,
+		Blocked:  q.blocked,
+		Dropped:  q.dropped,
+		Merged:   q.merged,
+	}
+	for _, waiting := range q.queues {
+		if len(waiting) > stats.MaxKeyDepth {
+			stats.MaxKeyDepth = len(waiting)
+		}
+	}
+	return stats
+}
+
+// must be called with the lock held
+func (q *KeyedQueue) full() bool {
+	return q.capacity > 0 && q.depth >= q.capacity
+}
+
+// must be called with the lock held
+func (q *KeyedQueue) isReady(key string) bool {
+	for _, readyKey := range q.ready {
+		if readyKey == key {
+			return true
+		}
+	}
+	return false
+}
+
+// removes the work that has waited longest, which is the first waiting
+// under some key, and returns its discard. Must be called with the lock held
+// on a queue with work waiting.
+func (q *KeyedQueue) dropOldest() func() {
+	oldestKey := ""
+	var oldest *queuedWork
+	for key, waiting := range q.queues {
+		if len(waiting) > 0 && (oldest == nil || waiting[0].seq < oldest.seq) {
+			oldestKey, oldest = key, &waiting[0]
+		}
+	}
+
+	return q.remove(oldestKey, 0, false)
+}
+
+// finds the work submitted last under mergeKey among the work waiting. Must
+// be called with the lock held.
+func (q *KeyedQueue) newestWaiting(mergeKey string) (string, int, bool) {
+	newestKey, newest, found := "", 0, false
+	for key, waiting := range q.queues {
+		for i, work := range waiting {
+			if work.mergeKey == mergeKey && (!found || work.seq > q.queues[newestKey][newest].seq) {
+				newestKey, newest, found = key, i, true
+			}
+		}
+	}
+	return newestKey, newest, found
+}
+
+// removes the waiting work at i under key and returns its discard. A key
+// left without work gives up its turn unless keepTurn is set. Must be called
+// with the lock held.
+func (q *KeyedQueue) remove(key string, i int, keepTurn bool) func() {
+	waiting := q.queues[key]
+	removed := waiting[i]
+	q.queues[key] = append(waiting[:i:i], waiting[i+1:]...)
+	q.depth--
+
+	if len(q.queues[key]) == 0 && !keepTurn && q.isReady(key) {
+		delete(q.queues, key)
+		for i, readyKey := range q.ready {
+			if readyKey == key {
+				q.ready = append(q.ready[:i], q.ready[i+1:]...)
+				break
+			}
+		}
+	}
+	return removed.discard
+}
+
+func closed(abort <-chan struct{}) bool {
+	select {
+	case <-abort:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *KeyedQueue) discardAll(discards []func()) {
+	for _, discard := range discards {
+		if discard != nil {
+			discard()
+		}
+	}
+}
+
+// must be called with the lock held
+func (q *KeyedQueue) startWorkers() {
+	for ; q.running < q.workers; q.running++ {
+		go q.work()
+	}
+}
+
+func (q *KeyedQueue) work() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	for {
+		for len(q.ready) == 0 && !q.stopped && q.running <= q.workers {
+			q.cond.Wait()
+		}
+		if q.stopped || q.running > q.workers {
+			q.running--
+			// pass on a wake up meant for a worker that stays
+			if len(q.ready) > 0 {
+				q.cond.Signal()
+			}
+			return
+		}
+
+		key := q.ready[0]
+		q.ready = q.ready[1:]
+		work := q.queues[key][0]
+		q.queues[key] = q.queues[key][1:]
+		q.depth--
+		q.busy++
+		q.room.Signal()
+
+		q.lock.Unlock()
+		work.run()
+		q.lock.Lock()
+
+		q.busy--
+		if len(q.queues[key]) > 0 {
+			q.ready = append(q.ready, key)
+			q.cond.Signal()
+		} else {
+			delete(q.queues, key)
+		}
+	}
+}
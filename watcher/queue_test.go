@@ -0,0 +1,300 @@
+package watcher_test
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/tps/watcher"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KeyedQueue", func() {
+	var queue *watcher.KeyedQueue
+
+	BeforeEach(func() {
+		var err error
+		queue, err = watcher.NewKeyedQueue(4, 0, watcher.OverflowBlock)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		queue.Stop()
+	})
+
+	It("rejects fewer than one worker", func() {
+		_, err := watcher.NewKeyedQueue(0, 0, watcher.OverflowBlock)
+		Expect(err).To(Equal(watcher.ErrInvalidWorkers))
+		Expect(queue.SetWorkers(0)).To(Equal(watcher.ErrInvalidWorkers))
+	})
+
+	It("runs the work of one key one at a time, in order", func() {
+		lock := sync.Mutex{}
+		ran := []int{}
+		running, maxRunning := 0, 0
+
+		for i := 0; i < 20; i++ {
+			i := i
+			queue.Submit("key", func() {
+				lock.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				lock.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				lock.Lock()
+				running--
+				ran = append(ran, i)
+				lock.Unlock()
+			}, nil)
+		}
+
+		Eventually(func() int {
+			lock.Lock()
+			defer lock.Unlock()
+			return len(ran)
+		}).Should(Equal(20))
+
+		for i, index := range ran {
+			Expect(index).To(Equal(i))
+		}
+		Expect(maxRunning).To(Equal(1))
+	})
+
+	It("runs the work of different keys in parallel", func() {
+		release := make(chan struct{})
+		defer close(release)
+
+		started := make(chan string, 3)
+		for _, key := range []string{"a", "b", "c"} {
+			key := key
+			queue.Submit(key, func() {
+				started <- key
+				<-release
+			}, nil)
+		}
+
+		Eventually(started).Should(HaveLen(3))
+		Expect(queue.Stats().Busy).To(Equal(3))
+	})
+
+	It("runs no more work at once than it has workers", func() {
+		release := make(chan struct{})
+		defer close(release)
+
+		for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+			queue.Submit(key, func() { <-release }, nil)
+		}
+
+		Eventually(func() int { return queue.Stats().Busy }).Should(Equal(4))
+		Consistently(func() int { return queue.Stats().Busy }).Should(Equal(4))
+		Expect(queue.Stats().Depth).To(Equal(2))
+	})
+
+	It("lets other keys run while one key has work waiting", func() {
+		Expect(queue.SetWorkers(1)).To(Succeed())
+
+		gate := make(chan struct{})
+		ran := make(chan string, 10)
+		queue.Submit("busy", func() {
+			<-gate
+			ran <- "busy-1"
+		}, nil)
+		queue.Submit("busy", func() { ran <- "busy-2" }, nil)
+		queue.Submit("other", func() { ran <- "other" }, nil)
+		close(gate)
+
+		Eventually(ran).Should(Receive(Equal("busy-1")))
+		Eventually(ran).Should(Receive(Equal("other")))
+		Eventually(ran).Should(Receive(Equal("busy-2")))
+	})
+
+	Describe("Stats", func() {
+		It("reports the work waiting by key", func() {
+			release := make(chan struct{})
+			defer close(release)
+
+			queue.Submit("a", func() { <-release }, nil)
+			queue.Submit("a", func() {}, nil)
+			queue.Submit("a", func() {}, nil)
+			queue.Submit("b", func() { <-release }, nil)
+
+			Eventually(func() int { return queue.Stats().Busy }).Should(Equal(2))
+			Expect(queue.Stats()).To(Equal(watcher.QueueStats{
+				Depth:       2,
+				Keys:        2,
+				MaxKeyDepth: 2,
+				Busy:        2,
+			}))
+		})
+	})
+
+	Describe("SetWorkers", func() {
+		It("runs more work at once when workers are added", func() {
+			release := make(chan struct{})
+			defer close(release)
+
+			for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+				queue.Submit(key, func() { <-release }, nil)
+			}
+			Eventually(func() int { return queue.Stats().Busy }).Should(Equal(4))
+
+			Expect(queue.SetWorkers(6)).To(Succeed())
+			Eventually(func() int { return queue.Stats().Busy }).Should(Equal(6))
+		})
+	})
+
+	Describe("overflow", func() {
+		var (
+			release chan struct{}
+			ran     chan string
+			dropped chan string
+		)
+
+		submit := func(key, name string) {
+			queue.Submit(key, func() { ran <- name }, func() { dropped <- name })
+		}
+
+		// one worker held on other work, with a and b waiting in a full queue
+		fill := func(policy watcher.OverflowPolicy) {
+			var err error
+			queue, err = watcher.NewKeyedQueue(1, 2, policy)
+			Expect(err).NotTo(HaveOccurred())
+
+			queue.Submit("held", func() { <-release }, nil)
+			Eventually(func() int { return queue.Stats().Busy }).Should(Equal(1))
+
+			submit("a", "a-1")
+			submit("b", "b-1")
+		}
+
+		BeforeEach(func() {
+			queue.Stop()
+
+			release = make(chan struct{})
+			ran = make(chan string, 10)
+			dropped = make(chan string, 10)
+		})
+
+		It("rejects unknown policies", func() {
+			_, err := watcher.NewKeyedQueue(1, 2, "spill")
+			Expect(err).To(HaveOccurred())
+
+			_, err = watcher.ParseOverflowPolicy("spill")
+			Expect(err).To(HaveOccurred())
+		})
+
+		Context("when blocking", func() {
+			BeforeEach(func() {
+				fill(watcher.OverflowBlock)
+			})
+
+			It("holds up the submitter until there is room", func() {
+				submitted := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					submit("a", "a-2")
+					close(submitted)
+				}()
+
+				Eventually(func() int { return queue.Stats().Blocked }).Should(Equal(1))
+				Consistently(submitted).ShouldNot(BeClosed())
+
+				close(release)
+				Eventually(submitted).Should(BeClosed())
+				Eventually(ran).Should(Receive(Equal("a-1")))
+				Eventually(ran).Should(Receive(Equal("b-1")))
+				Eventually(ran).Should(Receive(Equal("a-2")))
+				Expect(dropped).To(BeEmpty())
+			})
+
+			It("gives up holding up the submitter when aborted", func() {
+				abort := make(chan struct{})
+				submitted := make(chan bool, 1)
+				go func() {
+					submitted <- queue.SubmitOrAbort("a", "a", func() { ran <- "a-2" }, func() { dropped <- "a-2" }, abort)
+				}()
+				Eventually(func() int { return queue.Stats().Blocked }).Should(Equal(1))
+
+				close(abort)
+				Eventually(submitted).Should(Receive(BeFalse()))
+				Expect(queue.Stats().Blocked).To(BeZero())
+
+				close(release)
+				Eventually(ran).Should(Receive(Equal("a-1")))
+				Eventually(ran).Should(Receive(Equal("b-1")))
+				Consistently(ran).ShouldNot(Receive())
+				Expect(dropped).To(BeEmpty())
+			})
+
+			It("discards what it was holding up when stopped", func() {
+				go submit("a", "a-2")
+				Eventually(func() int { return queue.Stats().Blocked }).Should(Equal(1))
+
+				queue.Stop()
+				close(release)
+
+				Eventually(dropped).Should(HaveLen(3))
+				Expect(ran).To(BeEmpty())
+			})
+		})
+
+		Context("when dropping the oldest", func() {
+			BeforeEach(func() {
+				fill(watcher.OverflowDropOldest)
+			})
+
+			It("discards the work that waited longest", func() {
+				submit("c", "c-1")
+				Expect(dropped).To(Receive(Equal("a-1")))
+				Expect(queue.Stats().Dropped).To(Equal(1))
+				Expect(queue.Stats().Keys).To(Equal(3))
+
+				close(release)
+				Eventually(ran).Should(Receive(Equal("b-1")))
+				Eventually(ran).Should(Receive(Equal("c-1")))
+			})
+		})
+
+		Context("when merging", func() {
+			BeforeEach(func() {
+				fill(watcher.OverflowMerge)
+			})
+
+			It("replaces the newest work waiting under the same key", func() {
+				submit("a", "a-2")
+				Expect(dropped).To(Receive(Equal("a-1")))
+				Expect(queue.Stats().Merged).To(Equal(1))
+
+				close(release)
+				Eventually(ran).Should(Receive(Equal("a-2")))
+				Eventually(ran).Should(Receive(Equal("b-1")))
+			})
+
+			It("replaces the newest work waiting under another key with the same merge key", func() {
+				queue.SubmitOrAbort("c", "a", func() { ran <- "c-1" }, func() { dropped <- "c-1" }, nil)
+				Expect(dropped).To(Receive(Equal("a-1")))
+				Expect(queue.Stats().Merged).To(Equal(1))
+				Expect(queue.Stats().Keys).To(Equal(3))
+
+				close(release)
+				Eventually(ran).Should(Receive(Equal("b-1")))
+				Eventually(ran).Should(Receive(Equal("c-1")))
+			})
+
+			It("blocks when no work of the key is waiting", func() {
+				go submit("c", "c-1")
+				Eventually(func() int { return queue.Stats().Blocked }).Should(Equal(1))
+
+				close(release)
+				Eventually(ran).Should(Receive(Equal("a-1")))
+				Eventually(ran).Should(Receive(Equal("b-1")))
+				Eventually(ran).Should(Receive(Equal("c-1")))
+			})
+		})
+	})
+})
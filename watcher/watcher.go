@@ -1,37 +1,69 @@
 package watcher
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/bbs"
 	"github.com/cloudfoundry-incubator/bbs/events"
 	"github.com/cloudfoundry-incubator/bbs/models"
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
+	"github.com/cloudfoundry-incubator/runtime-schema/metric"
 	"github.com/cloudfoundry-incubator/tps/cc_client"
-	"github.com/cloudfoundry/gunk/workpool"
 	"github.com/pivotal-golang/lager"
 )
 
 const DefaultRetryPauseInterval = time.Second
 
+const (
+	crashQueueDepth       = metric.Metric("CrashQueueDepth")
+	crashQueueInstances   = metric.Metric("CrashQueueInstances")
+	crashQueueMaxInstance = metric.Metric("CrashQueueMaxInstanceDepth")
+	crashQueueBlocked     = metric.Metric("CrashQueueBlocked")
+
+	crashesDropped = metric.Counter("CrashesDropped")
+	crashesMerged  = metric.Counter("CrashesMerged")
+)
+
 type Watcher struct {
-	bbsClient          bbs.Client
 	ccClient           cc_client.CcClient
 	logger             lager.Logger
 	retryPauseInterval time.Duration
+	drainTimeout       time.Duration
+	pendingStore       PendingStore
 
-	pool *workpool.WorkPool
+	lock        sync.Mutex
+	bbsClient   bbs.Client
+	queue       *KeyedQueue
+	overflow    OverflowPolicy
+	resubscribe chan struct{}
+
+	// the crashes whose post has not started yet, by the order they were
+	// received in
+	pending     map[int]PendingCrash
+	nextPending int
+	inFlight    sync.WaitGroup
+	stopped     bool
+	// closed on the first signal, so that posting a crash gives up
+	// waiting for room in the queue
+	stopping chan struct{}
 }
 
 func NewWatcher(
 	logger lager.Logger,
 	workPoolSize int,
+	queueCapacity int,
+	overflow OverflowPolicy,
 	retryPauseInterval time.Duration,
+	drainTimeout time.Duration,
+	pendingStore PendingStore,
 	bbsClient bbs.Client,
 	ccClient cc_client.CcClient,
 ) (*Watcher, error) {
-	workPool, err := workpool.NewWorkPool(workPoolSize)
+	queue, err := NewKeyedQueue(workPoolSize, queueCapacity, overflow)
 	if err != nil {
 		return nil, err
 	}
@@ -41,10 +73,78 @@ func NewWatcher(
 		ccClient:           ccClient,
 		logger:             logger,
 		retryPauseInterval: retryPauseInterval,
-		pool:               workPool,
+		drainTimeout:       drainTimeout,
+		pendingStore:       pendingStore,
+		queue:              queue,
+		overflow:           overflow,
+		resubscribe:        make(chan struct{}, 1),
+		pending:            map[int]PendingCrash{},
+		stopping:           make(chan struct{}),
 	}, nil
 }
 
+// SetWorkers changes how many crashes are posted at once
+func (watcher *Watcher) SetWorkers(workPoolSize int) error {
+	return watcher.queue.SetWorkers(workPoolSize)
+}
+
+// SetBBSClient makes the watcher subscribe again with client, such as one
+// built with reloaded TLS certificates
+func (watcher *Watcher) SetBBSClient(client bbs.Client) {
+	watcher.lock.Lock()
+	watcher.bbsClient = client
+	watcher.lock.Unlock()
+
+	select {
+	case watcher.resubscribe <- struct{}{}:
+	default:
+	}
+}
+
+func (watcher *Watcher) currentBBSClient() bbs.Client {
+	watcher.lock.Lock()
+	defer watcher.lock.Unlock()
+
+	return watcher.bbsClient
+}
+
+// QueueStats reports the crashes waiting to be posted
+func (watcher *Watcher) QueueStats() QueueStats {
+	return watcher.queue.Stats()
+}
+
+// Pending returns the crashes not yet posted, in the order they were
+// received in
+func (watcher *Watcher) Pending() []PendingCrash {
+	watcher.lock.Lock()
+	defer watcher.lock.Unlock()
+
+	return watcher.pendingCrashes()
+}
+
+// must be called with the lock held
+func (watcher *Watcher) pendingCrashes() []PendingCrash {
+	ids := make([]int, 0, len(watcher.pending))
+	for id := range watcher.pending {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	crashes := make([]PendingCrash, 0, len(ids))
+	for _, id := range ids {
+		crashes = append(crashes, watcher.pending[id])
+	}
+	return crashes
+}
+
+func (watcher *Watcher) sendQueueMetrics() {
+	stats := watcher.queue.Stats()
+	crashQueueDepth.Send(stats.Depth)
+	crashQueueInstances.Send(stats.Keys)
+	crashQueueMaxInstance.Send(stats.MaxKeyDepth)
+	crashQueueBlocked.Send(stats.Blocked)
+}
+
 func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
 	logger := watcher.logger.Session("watcher")
 	logger.Info("starting")
@@ -52,12 +152,22 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 
 	var subscription events.EventSource
 	subscriptionChan := make(chan events.EventSource, 1)
-	go subscribeToEvents(logger, watcher.bbsClient, subscriptionChan)
+	go subscribeToEvents(logger, watcher.currentBBSClient(), subscriptionChan)
 
 	eventChan := make(chan models.Event, 1)
 	errorChan := make(chan error, 1)
 	nextErrCount := 0
 
+	// events are handled in the loop below, where a crash may wait for room
+	// in the queue; the signal still gets through to it from here
+	stopping := make(chan os.Signal, 1)
+	go func() {
+		stopping <- <-signals
+		close(watcher.stopping)
+	}()
+
+	watcher.postSavedCrashes(logger)
+
 	close(ready)
 	logger.Info("started")
 
@@ -67,7 +177,7 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 			if subscription != nil {
 				go nextEvent(logger, subscription, eventChan, errorChan, watcher.retryPauseInterval)
 			} else {
-				go subscribeToEvents(logger, watcher.bbsClient, subscriptionChan)
+				go subscribeToEvents(logger, watcher.currentBBSClient(), subscriptionChan)
 			}
 
 		case event := <-eventChan:
@@ -77,7 +187,7 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 				nextErrCount += 1
 				if nextErrCount > 2 {
 					nextErrCount = 0
-					go subscribeToEvents(logger, watcher.bbsClient, subscriptionChan)
+					go subscribeToEvents(logger, watcher.currentBBSClient(), subscriptionChan)
 					break
 				}
 			}
@@ -87,19 +197,33 @@ func (watcher *Watcher) Run(signals <-chan os.Signal, ready chan<- struct{}) err
 			switch err {
 			case events.ErrSourceClosed:
 				logger.Debug("event-source-closed-resubscribe")
-				go subscribeToEvents(logger, watcher.bbsClient, subscriptionChan)
+				go subscribeToEvents(logger, watcher.currentBBSClient(), subscriptionChan)
 
 			case events.ErrUnrecognizedEventType:
 				logger.Debug("received-unexpected-event-type")
 				go nextEvent(logger, subscription, eventChan, errorChan, watcher.retryPauseInterval)
 			}
 
-		case <-signals:
+		case <-watcher.resubscribe:
+			// closing the subscription makes nextEvent report the source
+			// closed, which subscribes again with the new client
+			if subscription != nil {
+				logger.Info("resubscribing-with-new-client")
+				err := subscription.Close()
+				if err != nil {
+					logger.Error("failed-closing-event-source", err)
+				}
+			}
+
+		case <-stopping:
 			logger.Info("stopping")
-			err := subscription.Close()
-			if err != nil {
-				logger.Error("failed-closing-event-source", err)
+			if subscription != nil {
+				err := subscription.Close()
+				if err != nil {
+					logger.Error("failed-closing-event-source", err)
+				}
 			}
+			watcher.drain(logger)
 			return nil
 		}
 	}
@@ -113,31 +237,154 @@ func (watcher *Watcher) handleEvent(logger lager.Logger, event models.Event) {
 				"index":        crashed.ActualLRPKey.Index,
 			})
 
-			guid := crashed.ActualLRPKey.ProcessGuid
-			appCrashed := cc_messages.AppCrashedRequest{
-				Instance:        crashed.ActualLRPInstanceKey.InstanceGuid,
-				Index:           int(crashed.ActualLRPKey.Index),
-				Reason:          "CRASHED",
-				ExitDescription: crashed.CrashReason,
-				CrashCount:      int(crashed.CrashCount),
-				CrashTimestamp:  crashed.Since,
-			}
-
-			watcher.pool.Submit(func() {
-				logger := logger.WithData(lager.Data{
-					"process-guid": guid,
-					"index":        appCrashed.Index,
-				})
-				logger.Info("recording-app-crashed")
-				err := watcher.ccClient.AppCrashed(guid, appCrashed, logger)
-				if err != nil {
-					logger.Error("failed-recording-app-crashed", err)
-				}
+			watcher.postCrash(logger, PendingCrash{
+				ProcessGuid: crashed.ActualLRPKey.ProcessGuid,
+				Request: cc_messages.AppCrashedRequest{
+					Instance:        crashed.ActualLRPInstanceKey.InstanceGuid,
+					Index:           int(crashed.ActualLRPKey.Index),
+					Reason:          "CRASHED",
+					ExitDescription: crashed.CrashReason,
+					CrashCount:      int(crashed.CrashCount),
+					CrashTimestamp:  crashed.Since,
+				},
 			})
 		}
 	}
 }
 
+// postCrash posts crash to the CC after the crashes of the same instance
+// received before it, keeping it pending until the post is done. Posting the
+// crashes of an instance in order keeps the CC from recording an older crash
+// count over a newer one. When the queue is full, the overflow policy
+// either holds up reading events until there is room, or gives up crashes
+// that are then never posted. Merging gives up the newest crash waiting of
+// any instance of the process.
+func (watcher *Watcher) postCrash(logger lager.Logger, crash PendingCrash) {
+	watcher.lock.Lock()
+	id := watcher.nextPending
+	watcher.nextPending++
+	watcher.pending[id] = crash
+	watcher.inFlight.Add(1)
+	watcher.lock.Unlock()
+
+	key := fmt.Sprintf("%s/%d", crash.ProcessGuid, crash.Request.Index)
+	submitted := watcher.queue.SubmitOrAbort(key, crash.ProcessGuid, func() {
+		defer watcher.inFlight.Done()
+		defer watcher.sendQueueMetrics()
+
+		// a crash leaves pending as its post starts, so that one the CC may
+		// already have recorded is not posted again by the next watcher
+		watcher.lock.Lock()
+		stopped := watcher.stopped
+		if !stopped {
+			delete(watcher.pending, id)
+		}
+		watcher.lock.Unlock()
+		if stopped {
+			// saved for the next watcher when draining timed out
+			return
+		}
+
+		logger := logger.WithData(lager.Data{
+			"process-guid": crash.ProcessGuid,
+			"index":        crash.Request.Index,
+		})
+		logger.Info("recording-app-crashed")
+		err := watcher.ccClient.AppCrashed(crash.ProcessGuid, crash.Request, logger)
+		if err != nil {
+			logger.Error("failed-recording-app-crashed", err)
+		}
+	}, func() {
+		defer watcher.inFlight.Done()
+
+		watcher.lock.Lock()
+		delete(watcher.pending, id)
+		stopped := watcher.stopped
+		watcher.lock.Unlock()
+		if stopped {
+			// saved for the next watcher when draining timed out
+			return
+		}
+
+		data := lager.Data{"process-guid": crash.ProcessGuid, "index": crash.Request.Index}
+		if watcher.overflow == OverflowMerge {
+			logger.Info("merged-app-crashed", data)
+			crashesMerged.Increment()
+		} else {
+			logger.Info("dropped-app-crashed", data)
+			crashesDropped.Increment()
+		}
+	}, watcher.stopping)
+	if !submitted {
+		// left pending for drain to save
+		logger.Info("stopped-waiting-for-room", lager.Data{"process-guid": crash.ProcessGuid, "index": crash.Request.Index})
+		watcher.inFlight.Done()
+	}
+	watcher.sendQueueMetrics()
+}
+
+// posts the crashes a previous watcher saved when it stopped
+func (watcher *Watcher) postSavedCrashes(logger lager.Logger) {
+	if watcher.pendingStore == nil {
+		return
+	}
+
+	crashes, err := watcher.pendingStore.Take()
+	if err != nil {
+		logger.Error("failed-loading-pending-crashes", err)
+		return
+	}
+
+	if len(crashes) > 0 {
+		logger.Info("posting-pending-crashes", lager.Data{"count": len(crashes)})
+	}
+	for _, crash := range crashes {
+		watcher.postCrash(logger, crash)
+	}
+}
+
+// drain waits up to the drain timeout for the crashes being posted. Those
+// whose post has not started by then, or that were never queued as the
+// queue was full, are saved to the pending store instead; the posts under
+// way are left to finish or fail on their own.
+func (watcher *Watcher) drain(logger lager.Logger) {
+	defer watcher.queue.Stop()
+
+	drained := make(chan struct{})
+	go func() {
+		watcher.inFlight.Wait()
+		close(drained)
+	}()
+
+	timedOut := false
+	select {
+	case <-drained:
+	case <-time.After(watcher.drainTimeout):
+		timedOut = true
+	}
+
+	watcher.lock.Lock()
+	watcher.stopped = true
+	crashes := watcher.pendingCrashes()
+	watcher.lock.Unlock()
+
+	if timedOut {
+		logger.Info("drain-timed-out", lager.Data{"pending": len(crashes)})
+	} else {
+		logger.Info("drained", lager.Data{"pending": len(crashes)})
+	}
+	if watcher.pendingStore == nil || len(crashes) == 0 {
+		return
+	}
+
+	err := watcher.pendingStore.Save(crashes)
+	if err != nil {
+		logger.Error("failed-saving-pending-crashes", err)
+		return
+	}
+	logger.Info("saved-pending-crashes", lager.Data{"count": len(crashes)})
+}
+
 func subscribeToEvents(logger lager.Logger, bbsClient bbs.Client, subscriptionChan chan<- events.EventSource) {
 	logger.Info("subscribing-to-events")
 	eventSource, err := bbsClient.SubscribeToEvents(logger)
@@ -1,7 +1,10 @@
 package watcher_test
 
 import (
+	"encoding/json"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"sync/atomic"
 	"time"
@@ -14,6 +17,7 @@ import (
 	"github.com/cloudfoundry-incubator/runtime-schema/cc_messages"
 	"github.com/cloudfoundry-incubator/tps/cc_client/fakes"
 	"github.com/cloudfoundry-incubator/tps/watcher"
+	watcherfakes "github.com/cloudfoundry-incubator/tps/watcher/fakes"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
 	"github.com/tedsuo/ifrit"
@@ -34,6 +38,11 @@ var _ = Describe("Watcher", func() {
 		eventSource   *eventfakes.FakeEventSource
 		bbsClient     *fake_bbs.FakeInternalClient
 		ccClient      *fakes.FakeCcClient
+		pendingStore  *watcherfakes.FakePendingStore
+		drainTimeout  time.Duration
+		workers       int
+		queueCapacity int
+		overflow      watcher.OverflowPolicy
 		watcherRunner *watcher.Watcher
 		process       ifrit.Process
 
@@ -50,10 +59,11 @@ var _ = Describe("Watcher", func() {
 
 		logger = lagertest.NewTestLogger("test")
 		ccClient = new(fakes.FakeCcClient)
-
-		var err error
-		watcherRunner, err = watcher.NewWatcher(logger, 500, 10*time.Millisecond, bbsClient, ccClient)
-		Expect(err).NotTo(HaveOccurred())
+		pendingStore = new(watcherfakes.FakePendingStore)
+		drainTimeout = time.Second
+		workers = 500
+		queueCapacity = 0
+		overflow = watcher.OverflowBlock
 
 		nextErr = atomic.Value{}
 		nextErr := nextErr
@@ -84,6 +94,10 @@ var _ = Describe("Watcher", func() {
 	})
 
 	JustBeforeEach(func() {
+		var err error
+		watcherRunner, err = watcher.NewWatcher(logger, workers, queueCapacity, overflow, 10*time.Millisecond, drainTimeout, pendingStore, bbsClient, ccClient)
+		Expect(err).NotTo(HaveOccurred())
+
 		process = ifrit.Invoke(watcherRunner)
 	})
 
@@ -151,6 +165,48 @@ var _ = Describe("Watcher", func() {
 		})
 	})
 
+	Describe("several crashes of one instance", func() {
+		BeforeEach(func() {
+			crashEvents := []models.Event{}
+			for count := int32(1); count <= 5; count++ {
+				for _, guid := range []string{"process-guid", "other-process-guid"} {
+					actual := makeActualLRP(guid, "instance-guid", 0, count, count, cc_messages.AppLRPDomain, "out of memory")
+					crashEvents = append(crashEvents, models.NewActualLRPCrashedEvent(actual))
+				}
+			}
+
+			eventSource.NextStub = func() (models.Event, error) {
+				if len(crashEvents) == 0 {
+					time.Sleep(10 * time.Millisecond)
+					return nil, nil
+				}
+				event := crashEvents[0]
+				crashEvents = crashEvents[1:]
+				return event, nil
+			}
+
+			ccClient.AppCrashedStub = func(guid string, crashed cc_messages.AppCrashedRequest, logger lager.Logger) error {
+				// later crashes would overtake earlier ones if posted in parallel
+				time.Sleep(time.Duration(5-crashed.CrashCount) * 5 * time.Millisecond)
+				return nil
+			}
+		})
+
+		It("posts them in the order they crashed", func() {
+			Eventually(ccClient.AppCrashedCallCount).Should(Equal(10))
+
+			counts := map[string][]int{}
+			for i := 0; i < ccClient.AppCrashedCallCount(); i++ {
+				guid, crashed, _ := ccClient.AppCrashedArgsForCall(i)
+				counts[guid] = append(counts[guid], crashed.CrashCount)
+			}
+			Expect(counts).To(Equal(map[string][]int{
+				"process-guid":       {1, 2, 3, 4, 5},
+				"other-process-guid": {1, 2, 3, 4, 5},
+			}))
+		})
+	})
+
 	Describe("Unrecognized events", func() {
 		Context("when its not ActualLRPCrashed event", func() {
 
@@ -206,6 +262,269 @@ var _ = Describe("Watcher", func() {
 		})
 	})
 
+	Describe("SetBBSClient", func() {
+		var newBBSClient *fake_bbs.FakeInternalClient
+
+		BeforeEach(func() {
+			newBBSClient = new(fake_bbs.FakeInternalClient)
+			newBBSClient.SubscribeToEventsReturns(new(eventfakes.FakeEventSource), nil)
+		})
+
+		It("closes the subscription and subscribes again with the new client", func() {
+			Eventually(bbsClient.SubscribeToEventsCallCount).Should(Equal(1))
+
+			watcherRunner.SetBBSClient(newBBSClient)
+
+			Eventually(eventSource.CloseCallCount).Should(Equal(1))
+			Eventually(newBBSClient.SubscribeToEventsCallCount, 2*time.Second).Should(Equal(1))
+			Expect(bbsClient.SubscribeToEventsCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("stopping", func() {
+		var crash watcher.PendingCrash
+
+		BeforeEach(func() {
+			crash = watcher.PendingCrash{
+				ProcessGuid: "process-guid",
+				Request: cc_messages.AppCrashedRequest{
+					Instance:        "instance-guid",
+					Index:           1,
+					Reason:          "CRASHED",
+					ExitDescription: "out of memory",
+					CrashCount:      1,
+					CrashTimestamp:  3,
+				},
+			}
+		})
+
+		Context("when the crashes being posted finish within the drain timeout", func() {
+			BeforeEach(func() {
+				ccClient.AppCrashedStub = func(string, cc_messages.AppCrashedRequest, lager.Logger) error {
+					time.Sleep(100 * time.Millisecond)
+					return nil
+				}
+			})
+
+			It("waits for them and saves nothing", func() {
+				actual := makeActualLRP("process-guid", "instance-guid", 1, 3, 1, cc_messages.AppLRPDomain, "out of memory")
+				nextEvent.Store(EventHolder{models.NewActualLRPCrashedEvent(actual)})
+				Eventually(ccClient.AppCrashedCallCount).Should(Equal(1))
+
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive())
+
+				Expect(logger).To(Say("drained"))
+				Expect(pendingStore.SaveCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a post outlasts the drain timeout", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				drainTimeout = 50 * time.Millisecond
+				release = make(chan struct{})
+				ccClient.AppCrashedStub = func(string, cc_messages.AppCrashedRequest, lager.Logger) error {
+					<-release
+					return nil
+				}
+			})
+
+			AfterEach(func() {
+				close(release)
+			})
+
+			It("saves the crashes waiting behind it, but not the one being posted", func() {
+				actual := makeActualLRP("process-guid", "instance-guid", 1, 3, 1, cc_messages.AppLRPDomain, "out of memory")
+				nextEvent.Store(EventHolder{models.NewActualLRPCrashedEvent(actual)})
+				Eventually(ccClient.AppCrashedCallCount).Should(Equal(1))
+				Expect(watcherRunner.Pending()).To(BeEmpty())
+
+				actual = makeActualLRP("process-guid", "instance-guid", 1, 3, 2, cc_messages.AppLRPDomain, "out of memory")
+				nextEvent.Store(EventHolder{models.NewActualLRPCrashedEvent(actual)})
+				Eventually(watcherRunner.Pending).Should(HaveLen(1))
+
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive())
+
+				waiting := crash
+				waiting.Request.CrashCount = 2
+				Expect(pendingStore.SaveCallCount()).To(Equal(1))
+				Expect(pendingStore.SaveArgsForCall(0)).To(Equal([]watcher.PendingCrash{waiting}))
+				Expect(logger).To(Say("drain-timed-out"))
+				Expect(ccClient.AppCrashedCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when it has not subscribed yet", func() {
+			BeforeEach(func() {
+				bbsClient.SubscribeToEventsReturns(nil, errors.New("unavailable"))
+			})
+
+			It("stops without closing a subscription", func() {
+				Eventually(bbsClient.SubscribeToEventsCallCount).Should(BeNumerically(">=", 1))
+
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+			})
+		})
+
+		Context("when a previous watcher saved pending crashes", func() {
+			BeforeEach(func() {
+				pendingStore.TakeReturns([]watcher.PendingCrash{crash}, nil)
+			})
+
+			It("posts them when it starts", func() {
+				Eventually(ccClient.AppCrashedCallCount).Should(Equal(1))
+				guid, request, _ := ccClient.AppCrashedArgsForCall(0)
+				Expect(guid).To(Equal("process-guid"))
+				Expect(request).To(Equal(crash.Request))
+				Expect(pendingStore.TakeCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when loading the pending crashes fails", func() {
+			BeforeEach(func() {
+				pendingStore.TakeReturns(nil, errors.New("consul down"))
+			})
+
+			It("logs and keeps watching", func() {
+				Expect(logger).To(Say("failed-loading-pending-crashes"))
+				Consistently(process.Wait()).ShouldNot(Receive())
+			})
+		})
+	})
+
+	Describe("a full queue", func() {
+		var release chan struct{}
+
+		BeforeEach(func() {
+			workers = 1
+			queueCapacity = 1
+			overflow = watcher.OverflowDropOldest
+			release = make(chan struct{})
+
+			crashEvents := []models.Event{}
+			for _, guid := range []string{"process-guid-1", "process-guid-2", "process-guid-3"} {
+				actual := makeActualLRP(guid, "instance-guid", 0, 1, 1, cc_messages.AppLRPDomain, "out of memory")
+				crashEvents = append(crashEvents, models.NewActualLRPCrashedEvent(actual))
+			}
+
+			eventSource.NextStub = func() (models.Event, error) {
+				time.Sleep(10 * time.Millisecond)
+				if len(crashEvents) == 0 {
+					return nil, nil
+				}
+				event := crashEvents[0]
+				crashEvents = crashEvents[1:]
+				return event, nil
+			}
+
+			ccClient.AppCrashedStub = func(string, cc_messages.AppCrashedRequest, lager.Logger) error {
+				<-release
+				return nil
+			}
+		})
+
+		AfterEach(func() {
+			close(release)
+		})
+
+		It("applies the overflow policy and forgets the crashes it drops", func() {
+			Eventually(logger).Should(Say("dropped-app-crashed"))
+
+			// process-guid-1 is being posted, so only process-guid-3 waits
+			Eventually(func() []watcher.PendingCrash { return watcherRunner.Pending() }).Should(HaveLen(1))
+			Expect(watcherRunner.Pending()[0].ProcessGuid).To(Equal("process-guid-3"))
+			Expect(watcherRunner.QueueStats().Dropped).To(Equal(1))
+		})
+
+		It("shows the pending crashes on the debug queue view", func() {
+			Eventually(func() []watcher.PendingCrash { return watcherRunner.Pending() }).Should(HaveLen(1))
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("GET", watcher.QueueDebugPath, nil)
+			Expect(err).NotTo(HaveOccurred())
+			watcher.NewQueueHandler(watcherRunner).ServeHTTP(recorder, request)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var view watcher.QueueView
+			Expect(json.NewDecoder(recorder.Body).Decode(&view)).To(Succeed())
+			Expect(view.Overflow).To(Equal(watcher.OverflowDropOldest))
+			Expect(view.Stats.Capacity).To(Equal(1))
+			Expect(view.Stats.Busy).To(Equal(1))
+			Expect(view.Pending).To(Equal(watcherRunner.Pending()))
+		})
+
+		Context("when merging", func() {
+			BeforeEach(func() {
+				overflow = watcher.OverflowMerge
+
+				crashEvents := []models.Event{}
+				for _, index := range []int32{0, 1, 2} {
+					actual := makeActualLRP("process-guid", "instance-guid", index, 1, 1, cc_messages.AppLRPDomain, "out of memory")
+					crashEvents = append(crashEvents, models.NewActualLRPCrashedEvent(actual))
+				}
+
+				eventSource.NextStub = func() (models.Event, error) {
+					time.Sleep(10 * time.Millisecond)
+					if len(crashEvents) == 0 {
+						return nil, nil
+					}
+					event := crashEvents[0]
+					crashEvents = crashEvents[1:]
+					return event, nil
+				}
+			})
+
+			It("replaces the crash waiting of another instance of the process", func() {
+				Eventually(func() int { return watcherRunner.QueueStats().Merged }).Should(Equal(1))
+
+				// index 0 is being posted, so only index 2 waits
+				Eventually(func() []watcher.PendingCrash { return watcherRunner.Pending() }).Should(HaveLen(1))
+				Expect(watcherRunner.Pending()[0].Request.Index).To(Equal(2))
+			})
+		})
+
+		Context("when blocking", func() {
+			BeforeEach(func() {
+				overflow = watcher.OverflowBlock
+				drainTimeout = 50 * time.Millisecond
+			})
+
+			It("still stops when signalled, saving the crashes it was holding", func() {
+				Eventually(func() int { return watcherRunner.QueueStats().Blocked }).Should(Equal(1))
+
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+
+				Expect(pendingStore.SaveCallCount()).To(Equal(1))
+				guids := []string{}
+				for _, crash := range pendingStore.SaveArgsForCall(0) {
+					guids = append(guids, crash.ProcessGuid)
+				}
+				Expect(guids).To(Equal([]string{"process-guid-2", "process-guid-3"}))
+				Expect(ccClient.AppCrashedCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	Describe("SetWorkers", func() {
+		It("keeps posting crashes from the new pool", func() {
+			Expect(watcherRunner.SetWorkers(10)).To(Succeed())
+
+			actual := makeActualLRP("process-guid", "instance-guid", 1, 3, 1, cc_messages.AppLRPDomain, "out of memory")
+			nextEvent.Store(EventHolder{models.NewActualLRPCrashedEvent(actual)})
+
+			Eventually(ccClient.AppCrashedCallCount).Should(Equal(1))
+		})
+
+		It("rejects pool sizes below one", func() {
+			Expect(watcherRunner.SetWorkers(0)).NotTo(Succeed())
+		})
+	})
+
 })
 
 func makeActualLRP(processGuid, instanceGuid string, index, since, crashCount int32, domain, reason string) *models.ActualLRP {